@@ -37,3 +37,27 @@ func AdminBool(name string) bool {
 	}
 	return val != 0
 }
+
+func AdminString(name string) string {
+	key, err := openAdminKey()
+	if err != nil {
+		return ""
+	}
+	val, _, err := key.GetStringValue(name)
+	if err != nil {
+		return ""
+	}
+	return val
+}
+
+func AdminInt(name string) (uint64, bool) {
+	key, err := openAdminKey()
+	if err != nil {
+		return 0, false
+	}
+	val, _, err := key.GetIntegerValue(name)
+	if err != nil {
+		return 0, false
+	}
+	return val, true
+}
@@ -12,6 +12,7 @@ import (
 	"encoding/binary"
 	"fmt"
 	"net/netip"
+	"slices"
 	"strings"
 	"time"
 
@@ -33,6 +34,110 @@ type (
 	Bytes         uint64
 )
 
+type InboundPolicy int
+
+const (
+	InboundAllow InboundPolicy = iota
+	InboundBlock
+)
+
+// VirtualMachinePolicy determines whether the traffic of Hyper-V virtual machines and containers, such as those of
+// WSL2 and Docker Desktop, is permitted by the kill-switch.
+type VirtualMachinePolicy int
+
+const (
+	VirtualMachinesBlock VirtualMachinePolicy = iota
+	VirtualMachinesAllow
+	VirtualMachinesTunnel
+)
+
+// NetworkCategory is the Network List Manager category given to the network of the tunnel, which decides the
+// firewall profile that applies to it.
+type NetworkCategory int
+
+const (
+	NetworkCategoryUnchanged NetworkCategory = iota
+	NetworkCategoryPublic
+	NetworkCategoryPrivate
+)
+
+// EncryptedDNSServer attaches either a DNS over HTTPS URI template or a DNS over TLS hostname to one of the DNS
+// servers of an interface.
+type EncryptedDNSServer struct {
+	Server   netip.Addr
+	Template string // DNS over HTTPS, such as "https://dns.example/dns-query"
+	Hostname string // DNS over TLS, such as "dns.example"
+}
+
+// HostsEntry maps names to an address for as long as the tunnel is up, in the manner of the system's hosts file.
+type HostsEntry struct {
+	Address netip.Addr
+	Names   []string
+}
+
+// ProxySettings are applied to the WinINet proxy settings of logged-on users while the tunnel is up.
+type ProxySettings struct {
+	Server     string   // "proxy.example:8080", or per scheme, such as "http=proxy.example:8080;https=proxy.example:8443"
+	Bypass     []string // Such as "*.corp.example" or "<local>"
+	AutoConfig string   // URL of a PAC file
+}
+
+func (p *ProxySettings) IsEmpty() bool {
+	return len(p.Server) == 0 && len(p.AutoConfig) == 0
+}
+
+// RouteMetric overrides the metric of the route to one of the AllowedIPs of a peer, which is otherwise 0. Windows adds
+// the interface metric to it, and of several routes to the same destination, uses the one with the lowest sum.
+type RouteMetric struct {
+	Destination netip.Prefix
+	Metric      uint32
+}
+
+// Route is added to the interface in addition to the routes to the AllowedIPs of the peers, or, with Table = off,
+// instead of them. Packets are still sent to the peer whose AllowedIPs contain their destination.
+type Route struct {
+	Destination netip.Prefix
+	NextHop     netip.Addr // Invalid for on-link routes
+	Metric      uint32
+}
+
+// PresharedKeyProvider supplies new preshared keys for peers while the tunnel is running, such as from a
+// post-quantum key exchange, one line of the public key of a peer and its new preshared key, both in base64, at a time.
+type PresharedKeyProvider struct {
+	Pipe    string // The path of a named pipe to read from, such as `\\.\pipe\rosenpass`
+	Command string // A command to run and read the standard output of, which is a dangerous script
+}
+
+func (p *PresharedKeyProvider) IsEmpty() bool {
+	return len(p.Pipe) == 0 && len(p.Command) == 0
+}
+
+func (p *PresharedKeyProvider) String() string {
+	if len(p.Pipe) > 0 {
+		return "pipe " + p.Pipe
+	}
+	if len(p.Command) > 0 {
+		return "command " + p.Command
+	}
+	return ""
+}
+
+// UnsupportedKey is a key of the [Interface] section that other platforms support, such as FwMark on Linux, but that
+// has no effect on Windows. It is kept so that the configuration may be exported again as it was.
+type UnsupportedKey struct {
+	Key   string
+	Value string
+}
+
+// InboundRule permits inbound traffic of a protocol, optionally to a range of local ports and from a list of prefixes,
+// when the InboundPolicy is InboundBlock.
+type InboundRule struct {
+	Protocol string // "tcp", "udp", "icmp", or "any"
+	PortLow  uint16
+	PortHigh uint16
+	From     []netip.Prefix
+}
+
 type Config struct {
 	Name      string
 	Interface Interface
@@ -44,13 +149,34 @@ type Interface struct {
 	Addresses  []netip.Prefix
 	ListenPort uint16
 	MTU        uint16
+	Metric     uint32 // 0 for automatic, or for tunnels with a default route, the lowest metric
 	DNS        []netip.Addr
 	DNSSearch  []string
+	SplitDNS   []string
+	Hosts      []HostsEntry
+	Proxy      ProxySettings
 	PreUp      string
 	PostUp     string
 	PreDown    string
 	PostDown   string
 	TableOff   bool
+
+	AdapterGUID     string // Such as "{6B29FC40-CA47-1067-B31D-00DD010662DA}", or empty to derive it from the configuration
+	NetworkCategory NetworkCategory
+	Routes          []Route
+	RouteMetrics    []RouteMetric
+	MTUAutoProbe    bool // Whether the path MTU to the endpoints is probed, with MTU left at 0
+	SaveConfig      bool // Whether changes made at runtime, such as with wg(8), are saved to the configuration
+
+	BlockEncryptedDNS bool
+	EncryptedDNS      []EncryptedDNSServer
+	Inbound           InboundPolicy
+	AllowInbound      []InboundRule
+	VirtualMachines   VirtualMachinePolicy
+
+	PresharedKeyProvider PresharedKeyProvider
+	PrivateKeyFile       string // The name of a file in the Keys directory from which PrivateKey is loaded, if any
+	Unsupported          []UnsupportedKey
 }
 
 type Peer struct {
@@ -60,33 +186,99 @@ type Peer struct {
 	Endpoint            Endpoint
 	PersistentKeepalive uint16
 
+	PersistentKeepaliveAuto bool   // Whether PersistentKeepalive is adjusted by the tunnel service, in which case it is 0 until then
+	FailoverGroup           string // The name of the group of peers that take turns with the same allowed IPs, if any
+	PresharedKeyFile        string // The name of a file in the Keys directory from which PresharedKey is loaded, if any
+
 	RxBytes           Bytes
 	TxBytes           Bytes
 	LastHandshakeTime HandshakeTime
 }
 
+// FailoverGroup is a group of peers that share the same allowed IPs, of which only one at a time is given them.
+type FailoverGroup struct {
+	Name  string
+	Peers []int // The indices of the peers, in order of preference
+}
+
+// FailoverGroups returns the failover groups of the peers, in the order in which they first appear.
+func (conf *Config) FailoverGroups() []FailoverGroup {
+	var groups []FailoverGroup
+	for i := range conf.Peers {
+		name := conf.Peers[i].FailoverGroup
+		if len(name) == 0 {
+			continue
+		}
+		j := slices.IndexFunc(groups, func(group FailoverGroup) bool { return group.Name == name })
+		if j < 0 {
+			groups = append(groups, FailoverGroup{Name: name})
+			j = len(groups) - 1
+		}
+		groups[j].Peers = append(groups[j].Peers, i)
+	}
+	return groups
+}
+
+// routeMetric returns the sum of the interface metric and the metric of the route to the destination, if the
+// interface metric is not automatic.
+func (conf *Config) routeMetric(destination netip.Prefix) (uint32, bool) {
+	if conf.Interface.Metric == 0 {
+		return 0, false
+	}
+	for _, m := range conf.Interface.RouteMetrics {
+		if m.Destination == destination {
+			return conf.Interface.Metric + m.Metric, true
+		}
+	}
+	for _, r := range conf.Interface.Routes {
+		if r.Destination == destination {
+			return conf.Interface.Metric + r.Metric, true
+		}
+	}
+	return conf.Interface.Metric, true
+}
+
+// IntersectsWith reports whether the addresses or routes of the two tunnels overlap, such that they cannot both be
+// up at the same time. Routes to the same destination do not count when explicit metrics decide between them.
 func (conf *Config) IntersectsWith(other *Config) bool {
-	allRoutes := make(map[netip.Prefix]bool, len(conf.Interface.Addresses)*2+len(conf.Peers)*3)
+	addresses := make(map[netip.Prefix]bool, len(conf.Interface.Addresses)*2)
+	routes := make(map[netip.Prefix]bool, len(conf.Peers)*3)
 	for _, a := range conf.Interface.Addresses {
-		allRoutes[netip.PrefixFrom(a.Addr(), a.Addr().BitLen())] = true
-		allRoutes[a.Masked()] = true
+		addresses[netip.PrefixFrom(a.Addr(), a.Addr().BitLen())] = true
+		addresses[a.Masked()] = true
 	}
 	for i := range conf.Peers {
 		for _, a := range conf.Peers[i].AllowedIPs {
-			allRoutes[a.Masked()] = true
+			routes[a.Masked()] = true
 		}
 	}
+	for _, r := range conf.Interface.Routes {
+		routes[r.Destination] = true
+	}
 	for _, a := range other.Interface.Addresses {
-		if allRoutes[netip.PrefixFrom(a.Addr(), a.Addr().BitLen())] {
-			return true
-		}
-		if allRoutes[a.Masked()] {
-			return true
+		for _, p := range [...]netip.Prefix{netip.PrefixFrom(a.Addr(), a.Addr().BitLen()), a.Masked()} {
+			if addresses[p] || routes[p] {
+				return true
+			}
 		}
 	}
+	otherRoutes := make([]netip.Prefix, 0, len(other.Peers)*3+len(other.Interface.Routes))
 	for i := range other.Peers {
 		for _, a := range other.Peers[i].AllowedIPs {
-			if allRoutes[a.Masked()] {
+			otherRoutes = append(otherRoutes, a.Masked())
+		}
+	}
+	for _, r := range other.Interface.Routes {
+		otherRoutes = append(otherRoutes, r.Destination)
+	}
+	for _, p := range otherRoutes {
+		if addresses[p] {
+			return true
+		}
+		if routes[p] {
+			metric, ok := conf.routeMetric(p)
+			otherMetric, otherOk := other.routeMetric(p)
+			if !ok || !otherOk || metric == otherMetric {
 				return true
 			}
 		}
@@ -101,6 +293,53 @@ func (e *Endpoint) String() string {
 	return fmt.Sprintf("%s:%d", e.Host, e.Port)
 }
 
+func (r *InboundRule) String() string {
+	var output strings.Builder
+	output.WriteString(r.Protocol)
+	if r.PortLow != 0 {
+		output.WriteString(fmt.Sprintf("/%d", r.PortLow))
+		if r.PortHigh != r.PortLow {
+			output.WriteString(fmt.Sprintf("-%d", r.PortHigh))
+		}
+	}
+	if len(r.From) > 0 {
+		prefixStrings := make([]string, len(r.From))
+		for i, prefix := range r.From {
+			prefixStrings[i] = prefix.String()
+		}
+		output.WriteString(" from ")
+		output.WriteString(strings.Join(prefixStrings, ", "))
+	}
+	return output.String()
+}
+
+func (s *EncryptedDNSServer) String() string {
+	if len(s.Template) > 0 {
+		return fmt.Sprintf("%s %s", s.Server, s.Template)
+	}
+	return fmt.Sprintf("%s tls://%s", s.Server, s.Hostname)
+}
+
+func (h *HostsEntry) String() string {
+	return fmt.Sprintf("%s %s", h.Address, strings.Join(h.Names, " "))
+}
+
+func (r *Route) String() string {
+	var output strings.Builder
+	output.WriteString(r.Destination.String())
+	if r.NextHop.IsValid() {
+		output.WriteString(fmt.Sprintf(" via %s", r.NextHop))
+	}
+	if r.Metric > 0 {
+		output.WriteString(fmt.Sprintf(" metric %d", r.Metric))
+	}
+	return output.String()
+}
+
+func (m *RouteMetric) String() string {
+	return fmt.Sprintf("%s %d", m.Destination, m.Metric)
+}
+
 func (e *Endpoint) IsEmpty() bool {
 	return len(e.Host) == 0
 }
@@ -17,9 +17,11 @@ import (
 	"golang.zx2c4.com/wireguard/windows/services"
 )
 
-func resolveHostname(name string) (resolvedIPString string, err error) {
+// resolveHostname retries temporary failures, and, if patient, also failures to find the host, for when the
+// network may not be fully up yet.
+func resolveHostname(name string, patient bool) (resolvedIPString string, err error) {
 	maxTries := 10
-	if services.StartedAtBoot() {
+	if patient {
 		maxTries *= 3
 	}
 	for i := 0; i < maxTries; i++ {
@@ -34,8 +36,8 @@ func resolveHostname(name string) (resolvedIPString string, err error) {
 			log.Printf("Temporary DNS error when resolving %s, so sleeping for 4 seconds", name)
 			continue
 		}
-		if err == windows.WSAHOST_NOT_FOUND && services.StartedAtBoot() {
-			log.Printf("Host not found when resolving %s at boot time, so sleeping for 4 seconds", name)
+		if err == windows.WSAHOST_NOT_FOUND && patient {
+			log.Printf("Host not found when resolving %s while the network is coming up, so sleeping for 4 seconds", name)
 			continue
 		}
 		return
@@ -83,12 +85,22 @@ func resolveHostnameOnce(name string) (resolvedIPString string, err error) {
 }
 
 func (config *Config) ResolveEndpoints() error {
+	return config.resolveEndpoints(services.StartedAtBoot())
+}
+
+// ResolveEndpointsPatiently is like ResolveEndpoints, but retries as long as at boot, for when the network is just
+// coming back, such as after resuming from sleep or switching networks.
+func (config *Config) ResolveEndpointsPatiently() error {
+	return config.resolveEndpoints(true)
+}
+
+func (config *Config) resolveEndpoints(patient bool) error {
 	for i := range config.Peers {
 		if config.Peers[i].Endpoint.IsEmpty() {
 			continue
 		}
 		var err error
-		config.Peers[i].Endpoint.Host, err = resolveHostname(config.Peers[i].Endpoint.Host)
+		config.Peers[i].Endpoint.Host, err = resolveHostname(config.Peers[i].Endpoint.Host, patient)
 		if err != nil {
 			return err
 		}
@@ -85,10 +85,13 @@ func migrateUnencryptedConfigs(sharingBase int, migrated MigrationCallback) {
 		if err != nil {
 			goto error
 		}
-		config, err = FromWgQuickWithUnknownEncoding(string(bytes), strings.TrimSuffix(name, configFileUnencryptedSuffix))
+		config, err = FromWgQuickTolerantWithUnknownEncoding(string(bytes), strings.TrimSuffix(name, configFileUnencryptedSuffix))
 		if err != nil {
 			goto error
 		}
+		for _, unsupported := range config.Interface.Unsupported {
+			log.Printf("Keeping unsupported %s = %s of %#q, which has no effect on Windows", unsupported.Key, unsupported.Value, path)
+		}
 		err = config.Save(false)
 		if err != nil {
 			goto error
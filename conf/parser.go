@@ -8,6 +8,8 @@ package conf
 import (
 	"encoding/base64"
 	"net/netip"
+	"net/url"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -83,6 +85,115 @@ func parseMTU(s string) (uint16, error) {
 	return uint16(m), nil
 }
 
+func parseMetric(s string) (uint32, error) {
+	m, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, err
+	}
+	if m < 1 || m > 9999 {
+		return 0, &ParseError{l18n.Sprintf("Invalid metric"), s}
+	}
+	return uint32(m), nil
+}
+
+func parseRouteMetric(s string) (*RouteMetric, error) {
+	fields := strings.Fields(s)
+	if len(fields) != 2 {
+		return nil, &ParseError{l18n.Sprintf("Route metrics must be a destination followed by a metric"), s}
+	}
+	destination, err := parseIPCidr(fields[0])
+	if err != nil {
+		return nil, err
+	}
+	m, err := strconv.Atoi(fields[1])
+	if err != nil || m < 0 || m > 9999 {
+		return nil, &ParseError{l18n.Sprintf("Invalid metric"), fields[1]}
+	}
+	return &RouteMetric{destination.Masked(), uint32(m)}, nil
+}
+
+func parsePresharedKeyProvider(s string) (*PresharedKeyProvider, error) {
+	kind, target, _ := strings.Cut(s, " ")
+	target = strings.TrimSpace(target)
+	switch strings.ToLower(kind) {
+	case "pipe":
+		if !strings.HasPrefix(strings.ToLower(target), `\\.\pipe\`) || len(target) == len(`\\.\pipe\`) {
+			return nil, &ParseError{l18n.Sprintf("Invalid named pipe"), target}
+		}
+		return &PresharedKeyProvider{Pipe: target}, nil
+	case "command":
+		if len(target) == 0 {
+			return nil, &ParseError{l18n.Sprintf("Preshared key providers must be pipe or command followed by its path"), s}
+		}
+		return &PresharedKeyProvider{Command: target}, nil
+	}
+	return nil, &ParseError{l18n.Sprintf("Preshared key providers must be pipe or command followed by its path"), s}
+}
+
+// parseKeyFile accepts the name of a file in the Keys directory, which must not lead elsewhere.
+func parseKeyFile(s string) (string, error) {
+	if s == "." || s == ".." || strings.ContainsAny(s, `\/:*?"<>|`) {
+		return "", &ParseError{l18n.Sprintf("Key files must be the name of a file in the Keys directory"), s}
+	}
+	return s, nil
+}
+
+func parseRoute(s string) (*Route, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 || len(fields)%2 != 1 {
+		return nil, &ParseError{l18n.Sprintf("Routes must be a destination optionally followed by via and metric"), s}
+	}
+	destination, err := parseIPCidr(fields[0])
+	if err != nil {
+		return nil, err
+	}
+	route := &Route{Destination: destination.Masked()}
+	sawMetric := false
+	for i := 1; i < len(fields); i += 2 {
+		switch strings.ToLower(fields[i]) {
+		case "via":
+			if route.NextHop.IsValid() {
+				return nil, &ParseError{l18n.Sprintf("Routes may only have one next hop"), s}
+			}
+			nextHop, err := netip.ParseAddr(fields[i+1])
+			if err != nil || nextHop.Is4() != destination.Addr().Is4() || nextHop.Zone() != "" {
+				return nil, &ParseError{l18n.Sprintf("Invalid next hop"), fields[i+1]}
+			}
+			route.NextHop = nextHop
+		case "metric":
+			if sawMetric {
+				return nil, &ParseError{l18n.Sprintf("Routes may only have one metric"), s}
+			}
+			m, err := strconv.Atoi(fields[i+1])
+			if err != nil || m < 0 || m > 9999 {
+				return nil, &ParseError{l18n.Sprintf("Invalid metric"), fields[i+1]}
+			}
+			route.Metric = uint32(m)
+			sawMetric = true
+		default:
+			return nil, &ParseError{l18n.Sprintf("Routes must be a destination optionally followed by via and metric"), fields[i]}
+		}
+	}
+	return route, nil
+}
+
+func parseGUID(s string) (string, error) {
+	guid := strings.ToUpper(strings.TrimSuffix(strings.TrimPrefix(s, "{"), "}"))
+	valid := len(guid) == 36 && guid != "00000000-0000-0000-0000-000000000000"
+	for i := 0; valid && i < len(guid); i++ {
+		switch i {
+		case 8, 13, 18, 23:
+			valid = guid[i] == '-'
+		default:
+			valid = (guid[i] >= '0' && guid[i] <= '9') || (guid[i] >= 'A' && guid[i] <= 'F')
+		}
+	}
+	if !valid {
+		return "", &ParseError{l18n.Sprintf("Invalid GUID"), s}
+	}
+	return "{" + guid + "}", nil
+}
+
 func parsePort(s string) (uint16, error) {
 	m, err := strconv.Atoi(s)
 	if err != nil {
@@ -118,6 +229,190 @@ func parseTableOff(s string) (bool, error) {
 	return false, err
 }
 
+func parseBool(s string) (bool, error) {
+	switch strings.ToLower(s) {
+	case "true", "yes", "on":
+		return true, nil
+	case "false", "no", "off":
+		return false, nil
+	}
+	return false, &ParseError{l18n.Sprintf("Invalid boolean"), s}
+}
+
+func parseInboundPolicy(s string) (InboundPolicy, error) {
+	switch strings.ToLower(s) {
+	case "allow":
+		return InboundAllow, nil
+	case "block":
+		return InboundBlock, nil
+	}
+	return InboundAllow, &ParseError{l18n.Sprintf("Invalid inbound policy"), s}
+}
+
+func parseNetworkCategory(s string) (NetworkCategory, error) {
+	switch strings.ToLower(s) {
+	case "unchanged":
+		return NetworkCategoryUnchanged, nil
+	case "public":
+		return NetworkCategoryPublic, nil
+	case "private":
+		return NetworkCategoryPrivate, nil
+	}
+	return NetworkCategoryUnchanged, &ParseError{l18n.Sprintf("Invalid network category"), s}
+}
+
+func parseVirtualMachinePolicy(s string) (VirtualMachinePolicy, error) {
+	switch strings.ToLower(s) {
+	case "block":
+		return VirtualMachinesBlock, nil
+	case "allow":
+		return VirtualMachinesAllow, nil
+	case "tunnel":
+		return VirtualMachinesTunnel, nil
+	}
+	return VirtualMachinesBlock, &ParseError{l18n.Sprintf("Invalid virtual machine policy"), s}
+}
+
+func parseDomain(s string) (string, error) {
+	domain := strings.TrimSuffix(strings.TrimPrefix(strings.ToLower(s), "."), ".")
+	if len(domain) == 0 || len(domain) > 253 {
+		return "", &ParseError{l18n.Sprintf("Invalid domain"), s}
+	}
+	for _, label := range strings.Split(domain, ".") {
+		if len(label) == 0 || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return "", &ParseError{l18n.Sprintf("Invalid domain"), s}
+		}
+		for _, c := range label {
+			if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '-' && c != '_' {
+				return "", &ParseError{l18n.Sprintf("Invalid domain"), s}
+			}
+		}
+	}
+	return domain, nil
+}
+
+func parseEncryptedDNSServer(s string) (*EncryptedDNSServer, error) {
+	fields := strings.Fields(s)
+	if len(fields) != 2 {
+		return nil, &ParseError{l18n.Sprintf("Encrypted DNS must be a DNS server followed by an https:// template or tls:// hostname"), s}
+	}
+	server, err := netip.ParseAddr(fields[0])
+	if err != nil {
+		return nil, &ParseError{l18n.Sprintf("Invalid IP address"), fields[0]}
+	}
+	encrypted := &EncryptedDNSServer{Server: server}
+	switch {
+	case len(fields[1]) > 8 && strings.EqualFold(fields[1][:8], "https://"):
+		u, err := url.Parse(fields[1])
+		if err != nil || len(u.Host) == 0 || u.User != nil || len(u.Fragment) > 0 {
+			return nil, &ParseError{l18n.Sprintf("Invalid DNS over HTTPS template"), fields[1]}
+		}
+		encrypted.Template = fields[1]
+	case len(fields[1]) > 6 && strings.EqualFold(fields[1][:6], "tls://"):
+		hostname, err := parseDomain(fields[1][6:])
+		if err != nil {
+			return nil, err
+		}
+		encrypted.Hostname = hostname
+	default:
+		return nil, &ParseError{l18n.Sprintf("Encrypted DNS must be a DNS server followed by an https:// template or tls:// hostname"), s}
+	}
+	return encrypted, nil
+}
+
+func parseHostsEntry(s string) (*HostsEntry, error) {
+	fields := strings.Fields(s)
+	if len(fields) < 2 {
+		return nil, &ParseError{l18n.Sprintf("Hosts entries must be an address followed by one or more names"), s}
+	}
+	address, err := netip.ParseAddr(fields[0])
+	if err != nil {
+		return nil, &ParseError{l18n.Sprintf("Invalid IP address"), fields[0]}
+	}
+	entry := &HostsEntry{Address: address}
+	for _, name := range fields[1:] {
+		n, err := parseDomain(name)
+		if err != nil {
+			return nil, err
+		}
+		entry.Names = append(entry.Names, n)
+	}
+	return entry, nil
+}
+
+func parseProxyServer(s string) (string, error) {
+	if strings.ContainsAny(s, " \t") {
+		return "", &ParseError{l18n.Sprintf("Invalid proxy server"), s}
+	}
+	for _, server := range strings.Split(s, ";") {
+		if scheme, hostPort, ok := strings.Cut(server, "="); ok {
+			if len(scheme) == 0 {
+				return "", &ParseError{l18n.Sprintf("Invalid proxy server"), s}
+			}
+			server = hostPort
+		}
+		if _, err := parseEndpoint(server); err != nil {
+			return "", &ParseError{l18n.Sprintf("Invalid proxy server"), s}
+		}
+	}
+	return s, nil
+}
+
+func parseProxyAutoConfig(s string) (string, error) {
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "file") || (u.Scheme != "file" && len(u.Host) == 0) {
+		return "", &ParseError{l18n.Sprintf("Invalid proxy auto-config URL"), s}
+	}
+	return s, nil
+}
+
+func parseInboundRule(s string) (*InboundRule, error) {
+	rule := &InboundRule{}
+	what, from := s, ""
+	if i := strings.Index(strings.ToLower(s), " from "); i >= 0 {
+		what, from = s[:i], s[i+len(" from "):]
+	}
+	protocol, ports, hasPorts := strings.Cut(strings.TrimSpace(what), "/")
+	rule.Protocol = strings.ToLower(protocol)
+	switch rule.Protocol {
+	case "tcp", "udp":
+	case "icmp", "any":
+		if hasPorts {
+			return nil, &ParseError{l18n.Sprintf("Ports may only be specified for TCP and UDP"), s}
+		}
+	default:
+		return nil, &ParseError{l18n.Sprintf("Invalid inbound rule protocol"), protocol}
+	}
+	if hasPorts {
+		low, high, isRange := strings.Cut(ports, "-")
+		var err error
+		rule.PortLow, err = parsePort(strings.TrimSpace(low))
+		if err != nil {
+			return nil, err
+		}
+		rule.PortHigh = rule.PortLow
+		if isRange {
+			rule.PortHigh, err = parsePort(strings.TrimSpace(high))
+			if err != nil {
+				return nil, err
+			}
+		}
+		if rule.PortLow == 0 || rule.PortHigh < rule.PortLow {
+			return nil, &ParseError{l18n.Sprintf("Invalid port range"), ports}
+		}
+	}
+	if len(from) > 0 {
+		for _, prefix := range strings.Split(from, ",") {
+			p, err := parseIPCidr(strings.TrimSpace(prefix))
+			if err != nil {
+				return nil, err
+			}
+			rule.From = append(rule.From, p)
+		}
+	}
+	return rule, nil
+}
+
 func parseKeyBase64(s string) (*Key, error) {
 	k, err := base64.StdEncoding.DecodeString(s)
 	if err != nil {
@@ -157,7 +452,30 @@ func (c *Config) maybeAddPeer(p *Peer) {
 	}
 }
 
+// unsupportedKeys are the keys of the [Interface] section that FromWgQuickTolerant keeps in Interface.Unsupported,
+// by their lowercase name.
+var unsupportedKeys = map[string]string{
+	// Of wg-quick(8) on Linux.
+	"fwmark": "FwMark",
+
+	// Of the Android client, which are not part of wg-quick(8).
+	"includedapplications": "IncludedApplications",
+	"excludedapplications": "ExcludedApplications",
+}
+
 func FromWgQuick(s, name string) (*Config, error) {
+	return fromWgQuick(s, name, false)
+}
+
+// FromWgQuickTolerant is like FromWgQuick, but accepts the keys of configurations from other platforms that have no
+// effect on Windows, such as FwMark and Table with a routing table number of wg-quick(8) on Linux, and
+// IncludedApplications and ExcludedApplications of the Android client, and keeps them in Interface.Unsupported for the
+// caller to warn about, rather than failing.
+func FromWgQuickTolerant(s, name string) (*Config, error) {
+	return fromWgQuick(s, name, true)
+}
+
+func fromWgQuick(s, name string, tolerant bool) (*Config, error) {
 	if !TunnelNameIsValid(name) {
 		return nil, &ParseError{l18n.Sprintf("Tunnel name is not valid"), name}
 	}
@@ -208,6 +526,13 @@ func FromWgQuick(s, name string) (*Config, error) {
 				}
 				conf.Interface.PrivateKey = *k
 				sawPrivateKey = true
+			case "privatekeyfile":
+				f, err := parseKeyFile(val)
+				if err != nil {
+					return nil, err
+				}
+				conf.Interface.PrivateKeyFile = f
+				sawPrivateKey = true
 			case "listenport":
 				p, err := parsePort(val)
 				if err != nil {
@@ -215,11 +540,47 @@ func FromWgQuick(s, name string) (*Config, error) {
 				}
 				conf.Interface.ListenPort = p
 			case "mtu":
+				if strings.EqualFold(val, "auto-probe") {
+					conf.Interface.MTU = 0
+					conf.Interface.MTUAutoProbe = true
+					break
+				}
 				m, err := parseMTU(val)
 				if err != nil {
 					return nil, err
 				}
 				conf.Interface.MTU = m
+				conf.Interface.MTUAutoProbe = false
+			case "metric":
+				m, err := parseMetric(val)
+				if err != nil {
+					return nil, err
+				}
+				conf.Interface.Metric = m
+			case "adapterguid":
+				guid, err := parseGUID(val)
+				if err != nil {
+					return nil, err
+				}
+				conf.Interface.AdapterGUID = guid
+			case "networkcategory":
+				category, err := parseNetworkCategory(val)
+				if err != nil {
+					return nil, err
+				}
+				conf.Interface.NetworkCategory = category
+			case "route":
+				r, err := parseRoute(val)
+				if err != nil {
+					return nil, err
+				}
+				conf.Interface.Routes = append(conf.Interface.Routes, *r)
+			case "routemetric":
+				m, err := parseRouteMetric(val)
+				if err != nil {
+					return nil, err
+				}
+				conf.Interface.RouteMetrics = append(conf.Interface.RouteMetrics, *m)
 			case "address":
 				addresses, err := splitList(val)
 				if err != nil {
@@ -245,6 +606,42 @@ func FromWgQuick(s, name string) (*Config, error) {
 						conf.Interface.DNS = append(conf.Interface.DNS, a)
 					}
 				}
+			case "splitdns":
+				domains, err := splitList(val)
+				if err != nil {
+					return nil, err
+				}
+				for _, domain := range domains {
+					d, err := parseDomain(domain)
+					if err != nil {
+						return nil, err
+					}
+					conf.Interface.SplitDNS = append(conf.Interface.SplitDNS, d)
+				}
+			case "hosts":
+				entry, err := parseHostsEntry(val)
+				if err != nil {
+					return nil, err
+				}
+				conf.Interface.Hosts = append(conf.Interface.Hosts, *entry)
+			case "proxy":
+				server, err := parseProxyServer(val)
+				if err != nil {
+					return nil, err
+				}
+				conf.Interface.Proxy.Server = server
+			case "proxybypass":
+				bypass, err := splitList(val)
+				if err != nil {
+					return nil, err
+				}
+				conf.Interface.Proxy.Bypass = append(conf.Interface.Proxy.Bypass, bypass...)
+			case "proxyautoconfig":
+				autoConfig, err := parseProxyAutoConfig(val)
+				if err != nil {
+					return nil, err
+				}
+				conf.Interface.Proxy.AutoConfig = autoConfig
 			case "preup":
 				conf.Interface.PreUp = val
 			case "postup":
@@ -253,13 +650,62 @@ func FromWgQuick(s, name string) (*Config, error) {
 				conf.Interface.PreDown = val
 			case "postdown":
 				conf.Interface.PostDown = val
+			case "presharedkeyprovider":
+				p, err := parsePresharedKeyProvider(val)
+				if err != nil {
+					return nil, err
+				}
+				conf.Interface.PresharedKeyProvider = *p
 			case "table":
 				tableOff, err := parseTableOff(val)
 				if err != nil {
 					return nil, err
 				}
 				conf.Interface.TableOff = tableOff
+				if tolerant && !tableOff && val != "auto" && val != "main" {
+					conf.Interface.Unsupported = append(conf.Interface.Unsupported, UnsupportedKey{"Table", val})
+				}
+			case "saveconfig":
+				saveConfig, err := parseBool(val)
+				if err != nil {
+					return nil, err
+				}
+				conf.Interface.SaveConfig = saveConfig
+			case "blockencrypteddns":
+				blockEncryptedDNS, err := parseBool(val)
+				if err != nil {
+					return nil, err
+				}
+				conf.Interface.BlockEncryptedDNS = blockEncryptedDNS
+			case "encrypteddns":
+				encrypted, err := parseEncryptedDNSServer(val)
+				if err != nil {
+					return nil, err
+				}
+				conf.Interface.EncryptedDNS = append(conf.Interface.EncryptedDNS, *encrypted)
+			case "inbound":
+				inbound, err := parseInboundPolicy(val)
+				if err != nil {
+					return nil, err
+				}
+				conf.Interface.Inbound = inbound
+			case "allowinbound":
+				rule, err := parseInboundRule(val)
+				if err != nil {
+					return nil, err
+				}
+				conf.Interface.AllowInbound = append(conf.Interface.AllowInbound, *rule)
+			case "virtualmachines":
+				virtualMachines, err := parseVirtualMachinePolicy(val)
+				if err != nil {
+					return nil, err
+				}
+				conf.Interface.VirtualMachines = virtualMachines
 			default:
+				if tolerant && len(unsupportedKeys[key]) > 0 {
+					conf.Interface.Unsupported = append(conf.Interface.Unsupported, UnsupportedKey{unsupportedKeys[key], val})
+					break
+				}
 				return nil, &ParseError{l18n.Sprintf("Invalid key for [Interface] section"), key}
 			}
 		} else if parserState == inPeerSection {
@@ -276,6 +722,12 @@ func FromWgQuick(s, name string) (*Config, error) {
 					return nil, err
 				}
 				peer.PresharedKey = *k
+			case "presharedkeyfile":
+				f, err := parseKeyFile(val)
+				if err != nil {
+					return nil, err
+				}
+				peer.PresharedKeyFile = f
 			case "allowedips":
 				addresses, err := splitList(val)
 				if err != nil {
@@ -289,17 +741,28 @@ func FromWgQuick(s, name string) (*Config, error) {
 					peer.AllowedIPs = append(peer.AllowedIPs, a)
 				}
 			case "persistentkeepalive":
+				if strings.EqualFold(val, "auto") {
+					peer.PersistentKeepalive = 0
+					peer.PersistentKeepaliveAuto = true
+					break
+				}
 				p, err := parsePersistentKeepalive(val)
 				if err != nil {
 					return nil, err
 				}
 				peer.PersistentKeepalive = p
+				peer.PersistentKeepaliveAuto = false
 			case "endpoint":
 				e, err := parseEndpoint(val)
 				if err != nil {
 					return nil, err
 				}
 				peer.Endpoint = *e
+			case "failovergroup":
+				if strings.ContainsAny(val, " \t") {
+					return nil, &ParseError{l18n.Sprintf("Invalid failover group"), val}
+				}
+				peer.FailoverGroup = val
 			default:
 				return nil, &ParseError{l18n.Sprintf("Invalid key for [Peer] section"), key}
 			}
@@ -310,24 +773,111 @@ func FromWgQuick(s, name string) (*Config, error) {
 	if !sawPrivateKey {
 		return nil, &ParseError{l18n.Sprintf("An interface must have a private key"), l18n.Sprintf("[none specified]")}
 	}
+	if len(conf.Interface.PrivateKeyFile) > 0 && !conf.Interface.PrivateKey.IsZero() {
+		return nil, &ParseError{l18n.Sprintf("An interface may not have both a private key and a private key file"), conf.Interface.PrivateKeyFile}
+	}
 	for _, p := range conf.Peers {
 		if p.PublicKey.IsZero() {
 			return nil, &ParseError{l18n.Sprintf("All peers must have public keys"), l18n.Sprintf("[none specified]")}
 		}
+		if len(p.PresharedKeyFile) > 0 && !p.PresharedKey.IsZero() {
+			return nil, &ParseError{l18n.Sprintf("A peer may not have both a preshared key and a preshared key file"), p.PresharedKeyFile}
+		}
+	}
+	for _, group := range conf.FailoverGroups() {
+		if len(group.Peers) < 2 {
+			return nil, &ParseError{l18n.Sprintf("Failover groups must have at least two peers"), group.Name}
+		}
+		first := &conf.Peers[group.Peers[0]]
+		for _, i := range group.Peers {
+			p := &conf.Peers[i]
+			if p.PersistentKeepalive == 0 && !p.PersistentKeepaliveAuto {
+				return nil, &ParseError{l18n.Sprintf("Peers of failover groups require a persistent keepalive"), group.Name}
+			}
+			if !samePrefixes(p.AllowedIPs, first.AllowedIPs) {
+				return nil, &ParseError{l18n.Sprintf("Peers of failover groups must have the same allowed IPs"), group.Name}
+			}
+		}
+	}
+	if len(conf.Interface.AllowInbound) > 0 && conf.Interface.Inbound != InboundBlock {
+		return nil, &ParseError{l18n.Sprintf("Inbound rules require an inbound policy of block"), conf.Interface.AllowInbound[0].String()}
+	}
+	for i, encrypted := range conf.Interface.EncryptedDNS {
+		if !slices.Contains(conf.Interface.DNS, encrypted.Server) {
+			return nil, &ParseError{l18n.Sprintf("Encrypted DNS servers must also be listed as DNS servers"), encrypted.Server.String()}
+		}
+		for _, other := range conf.Interface.EncryptedDNS[:i] {
+			if other.Server == encrypted.Server {
+				return nil, &ParseError{l18n.Sprintf("Encrypted DNS servers may only be listed once"), encrypted.Server.String()}
+			}
+		}
+	}
+	for i, r := range conf.Interface.Routes {
+		for _, other := range conf.Interface.Routes[:i] {
+			if other.Destination == r.Destination && other.NextHop == r.NextHop {
+				return nil, &ParseError{l18n.Sprintf("Routes may only be given once per destination and next hop"), r.String()}
+			}
+		}
+		if !r.NextHop.IsValid() && !conf.Interface.TableOff && slices.ContainsFunc(conf.Peers, func(p Peer) bool {
+			return slices.ContainsFunc(p.AllowedIPs, func(a netip.Prefix) bool { return a.Masked() == r.Destination })
+		}) {
+			return nil, &ParseError{l18n.Sprintf("Routes to allowed IPs are already added, unless the table is off"), r.String()}
+		}
+	}
+	for i, m := range conf.Interface.RouteMetrics {
+		if !slices.ContainsFunc(conf.Peers, func(p Peer) bool {
+			return slices.ContainsFunc(p.AllowedIPs, func(a netip.Prefix) bool { return a.Masked() == m.Destination })
+		}) {
+			return nil, &ParseError{l18n.Sprintf("Route metrics must be for one of the allowed IPs of a peer"), m.Destination.String()}
+		}
+		for _, other := range conf.Interface.RouteMetrics[:i] {
+			if other.Destination == m.Destination {
+				return nil, &ParseError{l18n.Sprintf("Route metrics may only be given once per destination"), m.Destination.String()}
+			}
+		}
+	}
+	if len(conf.Interface.Proxy.Bypass) > 0 && len(conf.Interface.Proxy.Server) == 0 {
+		return nil, &ParseError{l18n.Sprintf("Proxy bypass requires a proxy server"), conf.Interface.Proxy.Bypass[0]}
+	}
+	if len(conf.Interface.SplitDNS) > 0 && len(conf.Interface.DNS) == 0 {
+		return nil, &ParseError{l18n.Sprintf("Split DNS requires DNS servers"), conf.Interface.SplitDNS[0]}
 	}
 
 	return &conf, nil
 }
 
+// samePrefixes reports whether both lists contain the same prefixes, in any order.
+func samePrefixes(a, b []netip.Prefix) bool {
+	for _, prefix := range a {
+		if !slices.ContainsFunc(b, func(other netip.Prefix) bool { return other.Masked() == prefix.Masked() }) {
+			return false
+		}
+	}
+	for _, prefix := range b {
+		if !slices.ContainsFunc(a, func(other netip.Prefix) bool { return other.Masked() == prefix.Masked() }) {
+			return false
+		}
+	}
+	return true
+}
+
 func FromWgQuickWithUnknownEncoding(s, name string) (*Config, error) {
-	c, firstErr := FromWgQuick(s, name)
+	return fromWgQuickWithUnknownEncoding(s, name, false)
+}
+
+func FromWgQuickTolerantWithUnknownEncoding(s, name string) (*Config, error) {
+	return fromWgQuickWithUnknownEncoding(s, name, true)
+}
+
+func fromWgQuickWithUnknownEncoding(s, name string, tolerant bool) (*Config, error) {
+	c, firstErr := fromWgQuick(s, name, tolerant)
 	if firstErr == nil {
 		return c, nil
 	}
 	for _, encoding := range unicode.All {
 		decoded, err := encoding.NewDecoder().String(s)
 		if err == nil {
-			c, err := FromWgQuick(decoded, name)
+			c, err := fromWgQuick(decoded, name, tolerant)
 			if err == nil {
 				return c, nil
 			}
@@ -343,12 +893,32 @@ func FromDriverConfiguration(interfaze *driver.Interface, existingConfig *Config
 			Addresses: existingConfig.Interface.Addresses,
 			DNS:       existingConfig.Interface.DNS,
 			DNSSearch: existingConfig.Interface.DNSSearch,
+			SplitDNS:  existingConfig.Interface.SplitDNS,
+			Hosts:     existingConfig.Interface.Hosts,
+			Proxy:     existingConfig.Interface.Proxy,
 			MTU:       existingConfig.Interface.MTU,
+			Metric:    existingConfig.Interface.Metric,
 			PreUp:     existingConfig.Interface.PreUp,
 			PostUp:    existingConfig.Interface.PostUp,
 			PreDown:   existingConfig.Interface.PreDown,
 			PostDown:  existingConfig.Interface.PostDown,
 			TableOff:  existingConfig.Interface.TableOff,
+
+			AdapterGUID:       existingConfig.Interface.AdapterGUID,
+			NetworkCategory:   existingConfig.Interface.NetworkCategory,
+			Routes:            existingConfig.Interface.Routes,
+			RouteMetrics:      existingConfig.Interface.RouteMetrics,
+			MTUAutoProbe:      existingConfig.Interface.MTUAutoProbe,
+			SaveConfig:        existingConfig.Interface.SaveConfig,
+			BlockEncryptedDNS: existingConfig.Interface.BlockEncryptedDNS,
+			EncryptedDNS:      existingConfig.Interface.EncryptedDNS,
+			Inbound:           existingConfig.Interface.Inbound,
+			AllowInbound:      existingConfig.Interface.AllowInbound,
+			VirtualMachines:   existingConfig.Interface.VirtualMachines,
+
+			PresharedKeyProvider: existingConfig.Interface.PresharedKeyProvider,
+			PrivateKeyFile:       existingConfig.Interface.PrivateKeyFile,
+			Unsupported:          existingConfig.Interface.Unsupported,
 		},
 	}
 	if interfaze.Flags&driver.InterfaceHasPrivateKey != 0 {
@@ -378,6 +948,14 @@ func FromDriverConfiguration(interfaze *driver.Interface, existingConfig *Config
 		if p.Flags&driver.PeerHasPersistentKeepalive != 0 {
 			peer.PersistentKeepalive = p.PersistentKeepalive
 		}
+		for j := range existingConfig.Peers {
+			if existingConfig.Peers[j].PublicKey == peer.PublicKey {
+				peer.PersistentKeepaliveAuto = existingConfig.Peers[j].PersistentKeepaliveAuto
+				peer.FailoverGroup = existingConfig.Peers[j].FailoverGroup
+				peer.PresharedKeyFile = existingConfig.Peers[j].PresharedKeyFile
+				break
+			}
+		}
 		peer.TxBytes = Bytes(p.TxBytes)
 		peer.RxBytes = Bytes(p.RxBytes)
 		if p.LastHandshake != 0 {
@@ -402,3 +980,43 @@ func FromDriverConfiguration(interfaze *driver.Interface, existingConfig *Config
 	}
 	return &conf
 }
+
+// MergeDriverConfiguration returns the stored configuration with the keys and peers of the running interface, such
+// as after changes made with wg(8), for SaveConfig. What the driver does not know of, such as addresses, DNS and
+// scripts, is kept from the stored configuration, and so are the endpoints of known peers, which may be hostnames and
+// which the driver updates as peers roam, and the allowed IPs of failover groups, which the tunnel service moves.
+func MergeDriverConfiguration(interfaze *driver.Interface, storedConfig *Config) *Config {
+	conf := FromDriverConfiguration(interfaze, storedConfig)
+	if storedConfig.Interface.ListenPort == 0 {
+		// Otherwise the port that was picked at random would be kept from then on.
+		conf.Interface.ListenPort = 0
+	}
+	for i := range conf.Peers {
+		peer := &conf.Peers[i]
+		if peer.PersistentKeepaliveAuto {
+			peer.PersistentKeepalive = 0
+		}
+		j := slices.IndexFunc(storedConfig.Peers, func(p Peer) bool { return p.PublicKey == peer.PublicKey })
+		if j < 0 {
+			continue
+		}
+		stored := &storedConfig.Peers[j]
+		if !stored.Endpoint.IsEmpty() {
+			peer.Endpoint = stored.Endpoint
+		}
+		if len(peer.FailoverGroup) > 0 {
+			peer.AllowedIPs = stored.AllowedIPs
+		}
+		if !storedConfig.Interface.PresharedKeyProvider.IsEmpty() {
+			// Rotated preshared keys are only good for as long as the provider that supplied them is running.
+			peer.PresharedKey = stored.PresharedKey
+		}
+	}
+	// Peers of failover groups may have been removed, leaving a group that is no longer valid.
+	for _, group := range conf.FailoverGroups() {
+		if len(group.Peers) < 2 {
+			conf.Peers[group.Peers[0]].FailoverGroup = ""
+		}
+	}
+	return conf
+}
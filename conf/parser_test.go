@@ -9,6 +9,7 @@ import (
 	"net/netip"
 	"reflect"
 	"runtime"
+	"strings"
 	"testing"
 )
 
@@ -127,3 +128,430 @@ func TestParseEndpoint(t *testing.T) {
 		t.Error("Error was expected")
 	}
 }
+
+func TestParseBool(t *testing.T) {
+	for _, s := range []string{"true", "Yes", "ON"} {
+		b, err := parseBool(s)
+		if noError(t, err) {
+			equal(t, true, b)
+		}
+	}
+	for _, s := range []string{"false", "No", "off"} {
+		b, err := parseBool(s)
+		if noError(t, err) {
+			equal(t, false, b)
+		}
+	}
+	_, err := parseBool("1")
+	if err == nil {
+		t.Error("Error was expected")
+	}
+}
+
+func TestParseInboundRule(t *testing.T) {
+	r, err := parseInboundRule("tcp/22 from 10.0.0.0/8, fd00::/8")
+	if noError(t, err) {
+		equal(t, "tcp", r.Protocol)
+		equal(t, uint16(22), r.PortLow)
+		equal(t, uint16(22), r.PortHigh)
+		lenTest(t, r.From, 2)
+		contains(t, r.From, netip.MustParsePrefix("fd00::/8"))
+		equal(t, "tcp/22 from 10.0.0.0/8, fd00::/8", r.String())
+	}
+	r, err = parseInboundRule("UDP/60000-61000")
+	if noError(t, err) {
+		equal(t, "udp", r.Protocol)
+		equal(t, uint16(60000), r.PortLow)
+		equal(t, uint16(61000), r.PortHigh)
+		equal(t, "udp/60000-61000", r.String())
+	}
+	r, err = parseInboundRule("icmp")
+	if noError(t, err) {
+		equal(t, "icmp", r.Protocol)
+		lenTest(t, r.From, 0)
+	}
+	for _, s := range []string{"icmp/8", "tcp/61000-60000", "tcp/0", "sctp", "any from 10.0.0.0/33"} {
+		_, err = parseInboundRule(s)
+		if err == nil {
+			t.Errorf("Error was expected for %q", s)
+		}
+	}
+}
+
+func TestInboundRequiresBlock(t *testing.T) {
+	const input = `[Interface]
+PrivateKey = yAnz5TF+lXXJte14tji3zlMNq+hd2rYUIgJBgB3fBmk=
+AllowInbound = tcp/22`
+	_, err := FromWgQuick(input, "test")
+	if err == nil {
+		t.Error("Error was expected")
+	}
+	conf, err := FromWgQuick(input+"\nInbound = block", "test")
+	if noError(t, err) {
+		equal(t, InboundBlock, conf.Interface.Inbound)
+		lenTest(t, conf.Interface.AllowInbound, 1)
+	}
+}
+
+func TestParseVirtualMachinePolicy(t *testing.T) {
+	for input, expected := range map[string]VirtualMachinePolicy{
+		"block":  VirtualMachinesBlock,
+		"Allow":  VirtualMachinesAllow,
+		"TUNNEL": VirtualMachinesTunnel,
+	} {
+		policy, err := parseVirtualMachinePolicy(input)
+		if noError(t, err) {
+			equal(t, expected, policy)
+		}
+	}
+	_, err := parseVirtualMachinePolicy("nat")
+	if err == nil {
+		t.Error("Error was expected")
+	}
+}
+
+func TestSplitDNS(t *testing.T) {
+	const input = `[Interface]
+PrivateKey = yAnz5TF+lXXJte14tji3zlMNq+hd2rYUIgJBgB3fBmk=
+SplitDNS = Corp.Example., .example.internal`
+	_, err := FromWgQuick(input, "test")
+	if err == nil {
+		t.Error("Error was expected")
+	}
+	conf, err := FromWgQuick(input+"\nDNS = 10.0.0.1", "test")
+	if noError(t, err) {
+		equal(t, []string{"corp.example", "example.internal"}, conf.Interface.SplitDNS)
+	}
+	for _, invalid := range []string{"", ".", "corp..example", "-corp.example", "corp example"} {
+		_, err = parseDomain(invalid)
+		if err == nil {
+			t.Errorf("Error was expected for %#q", invalid)
+		}
+	}
+}
+
+func TestEncryptedDNS(t *testing.T) {
+	const input = `[Interface]
+PrivateKey = yAnz5TF+lXXJte14tji3zlMNq+hd2rYUIgJBgB3fBmk=
+DNS = 10.0.0.1, fd00::1
+EncryptedDNS = 10.0.0.1 https://dns.example/dns-query{?dns}
+EncryptedDNS = fd00::1 tls://DNS.Example`
+	conf, err := FromWgQuick(input, "test")
+	if noError(t, err) {
+		equal(t, []EncryptedDNSServer{
+			{Server: netip.MustParseAddr("10.0.0.1"), Template: "https://dns.example/dns-query{?dns}"},
+			{Server: netip.MustParseAddr("fd00::1"), Hostname: "dns.example"},
+		}, conf.Interface.EncryptedDNS)
+	}
+	for _, invalid := range []string{
+		"\nEncryptedDNS = 10.0.0.2 tls://dns.example",
+		"\nEncryptedDNS = 10.0.0.1 tls://dns.example",
+	} {
+		_, err = FromWgQuick(input+invalid, "test")
+		if err == nil {
+			t.Errorf("Error was expected for %#q", invalid)
+		}
+	}
+	for _, invalid := range []string{
+		"10.0.0.1",
+		"dns.example https://dns.example/dns-query",
+		"10.0.0.1 http://dns.example/dns-query",
+		"10.0.0.1 https://user@dns.example/dns-query",
+		"10.0.0.1 tls://dns..example",
+		"10.0.0.1 tls://dns.example extra",
+	} {
+		_, err = parseEncryptedDNSServer(invalid)
+		if err == nil {
+			t.Errorf("Error was expected for %#q", invalid)
+		}
+	}
+}
+
+func TestParseHostsEntry(t *testing.T) {
+	entry, err := parseHostsEntry("10.0.0.5  Git.Corp.Example\twiki.corp.example.")
+	if noError(t, err) {
+		equal(t, netip.MustParseAddr("10.0.0.5"), entry.Address)
+		equal(t, []string{"git.corp.example", "wiki.corp.example"}, entry.Names)
+		equal(t, "10.0.0.5 git.corp.example wiki.corp.example", entry.String())
+	}
+	for _, invalid := range []string{"10.0.0.5", "nas 10.0.0.5", "10.0.0.5 bad..name"} {
+		_, err = parseHostsEntry(invalid)
+		if err == nil {
+			t.Errorf("Error was expected for %#q", invalid)
+		}
+	}
+}
+
+func TestProxy(t *testing.T) {
+	const input = `[Interface]
+PrivateKey = yAnz5TF+lXXJte14tji3zlMNq+hd2rYUIgJBgB3fBmk=
+ProxyBypass = *.corp.example, <local>`
+	_, err := FromWgQuick(input, "test")
+	if err == nil {
+		t.Error("Error was expected")
+	}
+	conf, err := FromWgQuick(input+"\nProxy = proxy.corp.example:8080\nProxyAutoConfig = http://wpad.corp.example/proxy.pac", "test")
+	if noError(t, err) {
+		equal(t, ProxySettings{
+			Server:     "proxy.corp.example:8080",
+			Bypass:     []string{"*.corp.example", "<local>"},
+			AutoConfig: "http://wpad.corp.example/proxy.pac",
+		}, conf.Interface.Proxy)
+	}
+	for _, valid := range []string{"proxy.corp.example:8080", "[fd00::1]:3128", "http=proxy.corp.example:8080;https=proxy.corp.example:8443"} {
+		_, err = parseProxyServer(valid)
+		noError(t, err)
+	}
+	for _, invalid := range []string{"proxy.corp.example", "=proxy.corp.example:8080", "proxy.corp.example:8080 extra"} {
+		_, err = parseProxyServer(invalid)
+		if err == nil {
+			t.Errorf("Error was expected for %#q", invalid)
+		}
+	}
+	for _, invalid := range []string{"wpad.corp.example/proxy.pac", "ftp://wpad.corp.example/proxy.pac", "http:///proxy.pac"} {
+		_, err = parseProxyAutoConfig(invalid)
+		if err == nil {
+			t.Errorf("Error was expected for %#q", invalid)
+		}
+	}
+}
+
+func TestMetric(t *testing.T) {
+	const input = `[Interface]
+PrivateKey = yAnz5TF+lXXJte14tji3zlMNq+hd2rYUIgJBgB3fBmk=
+Metric = 5
+RouteMetric = 10.1.2.3/8 20
+[Peer]
+PublicKey = TrMvSoP4jYQlY6RIzBgbssQqY3vxI2Pi+y71lOWWXX0=
+AllowedIPs = 0.0.0.0/0, 10.0.0.0/8`
+	conf, err := FromWgQuick(input, "test")
+	if noError(t, err) {
+		equal(t, uint32(5), conf.Interface.Metric)
+		equal(t, []RouteMetric{{netip.MustParsePrefix("10.0.0.0/8"), 20}}, conf.Interface.RouteMetrics)
+	}
+	for _, invalid := range []string{"Metric = 0", "Metric = 10000", "RouteMetric = 10.0.0.0/8", "RouteMetric = 192.168.0.0/16 20", "RouteMetric = 10.0.0.0/8 1"} {
+		_, err = FromWgQuick(strings.Replace(input, "[Peer]", invalid+"\n[Peer]", 1), "test")
+		if err == nil {
+			t.Errorf("Error was expected for %#q", invalid)
+		}
+	}
+
+	other, err := FromWgQuick(strings.Replace(input, "Metric = 5", "Metric = 25", 1), "other")
+	if noError(t, err) {
+		equal(t, false, conf.IntersectsWith(other))
+		other.Interface.RouteMetrics[0].Metric = 0 // 10.0.0.0/8 now has a metric of 25 in both.
+		equal(t, true, conf.IntersectsWith(other))
+		other.Interface.RouteMetrics = nil
+		other.Interface.Metric = 0
+		equal(t, true, conf.IntersectsWith(other))
+	}
+}
+
+func TestRoute(t *testing.T) {
+	const input = `[Interface]
+PrivateKey = yAnz5TF+lXXJte14tji3zlMNq+hd2rYUIgJBgB3fBmk=
+Route = 192.168.50.1/24 via 10.0.0.2 Metric 10
+Route = fd00:50::/64
+[Peer]
+PublicKey = TrMvSoP4jYQlY6RIzBgbssQqY3vxI2Pi+y71lOWWXX0=
+AllowedIPs = 10.0.0.0/8`
+	conf, err := FromWgQuick(input, "test")
+	if noError(t, err) {
+		equal(t, []Route{
+			{netip.MustParsePrefix("192.168.50.0/24"), netip.MustParseAddr("10.0.0.2"), 10},
+			{Destination: netip.MustParsePrefix("fd00:50::/64")},
+		}, conf.Interface.Routes)
+		equal(t, "192.168.50.0/24 via 10.0.0.2 metric 10", conf.Interface.Routes[0].String())
+		equal(t, "fd00:50::/64", conf.Interface.Routes[1].String())
+	}
+	for _, invalid := range []string{"Route = 10.0.0.0/8", "Route = fd00:50::/64", "Route = 192.168.60.0/24 via fd00::2",
+		"Route = 192.168.60.0/24 via", "Route = 192.168.60.0/24 metric 1 metric 2", "Route = 192.168.60.0/24 dev wg0"} {
+		_, err = FromWgQuick(strings.Replace(input, "[Peer]", invalid+"\n[Peer]", 1), "test")
+		if err == nil {
+			t.Errorf("Error was expected for %#q", invalid)
+		}
+	}
+	_, err = FromWgQuick(strings.Replace(input, "[Peer]", "Table = off\nRoute = 10.0.0.0/8\n[Peer]", 1), "test")
+	noError(t, err)
+}
+
+func TestParseGUID(t *testing.T) {
+	for _, valid := range []string{"{6b29fc40-ca47-1067-b31d-00dd010662da}", "6B29FC40-CA47-1067-B31D-00DD010662DA"} {
+		guid, err := parseGUID(valid)
+		if noError(t, err) {
+			equal(t, "{6B29FC40-CA47-1067-B31D-00DD010662DA}", guid)
+		}
+	}
+	for _, invalid := range []string{"{6B29FC40-CA47-1067-B31D-00DD010662D}", "{6B29FC40CA47-1067-B31D-00DD010662DAA}", "{6B29FC40-CA47-1067-B31D-00DD010662DG}", "{00000000-0000-0000-0000-000000000000}"} {
+		_, err := parseGUID(invalid)
+		if err == nil {
+			t.Errorf("Error was expected for %#q", invalid)
+		}
+	}
+}
+
+func TestParseNetworkCategory(t *testing.T) {
+	for input, expected := range map[string]NetworkCategory{
+		"unchanged": NetworkCategoryUnchanged,
+		"Public":    NetworkCategoryPublic,
+		"PRIVATE":   NetworkCategoryPrivate,
+	} {
+		category, err := parseNetworkCategory(input)
+		if noError(t, err) {
+			equal(t, expected, category)
+		}
+	}
+	_, err := parseNetworkCategory("domain")
+	if err == nil {
+		t.Error("Error was expected")
+	}
+}
+
+func TestMTUAutoProbe(t *testing.T) {
+	const input = `[Interface]
+PrivateKey = yAnz5TF+lXXJte14tji3zlMNq+hd2rYUIgJBgB3fBmk=
+MTU = auto-probe
+[Peer]
+PublicKey = TrMvSoP4jYQlY6RIzBgbssQqY3vxI2Pi+y71lOWWXX0=
+AllowedIPs = 0.0.0.0/0`
+	conf, err := FromWgQuick(input, "test")
+	if noError(t, err) {
+		equal(t, true, conf.Interface.MTUAutoProbe)
+		equal(t, uint16(0), conf.Interface.MTU)
+	}
+	conf, err = FromWgQuick(strings.Replace(input, "auto-probe", "1280", 1), "test")
+	if noError(t, err) {
+		equal(t, false, conf.Interface.MTUAutoProbe)
+		equal(t, uint16(1280), conf.Interface.MTU)
+	}
+	_, err = FromWgQuick(strings.Replace(input, "auto-probe", "auto", 1), "test")
+	if err == nil {
+		t.Error("Error was expected")
+	}
+}
+
+func TestPersistentKeepaliveAuto(t *testing.T) {
+	const input = `[Interface]
+PrivateKey = yAnz5TF+lXXJte14tji3zlMNq+hd2rYUIgJBgB3fBmk=
+[Peer]
+PublicKey = TrMvSoP4jYQlY6RIzBgbssQqY3vxI2Pi+y71lOWWXX0=
+AllowedIPs = 0.0.0.0/0
+PersistentKeepalive = auto`
+	conf, err := FromWgQuick(input, "test")
+	if noError(t, err) {
+		equal(t, true, conf.Peers[0].PersistentKeepaliveAuto)
+		equal(t, uint16(0), conf.Peers[0].PersistentKeepalive)
+	}
+	conf, err = FromWgQuick(strings.Replace(input, "auto", "25", 1), "test")
+	if noError(t, err) {
+		equal(t, false, conf.Peers[0].PersistentKeepaliveAuto)
+		equal(t, uint16(25), conf.Peers[0].PersistentKeepalive)
+	}
+}
+
+func TestFailoverGroup(t *testing.T) {
+	const input = `[Interface]
+PrivateKey = yAnz5TF+lXXJte14tji3zlMNq+hd2rYUIgJBgB3fBmk=
+[Peer]
+PublicKey = TrMvSoP4jYQlY6RIzBgbssQqY3vxI2Pi+y71lOWWXX0=
+AllowedIPs = 10.0.0.0/8, 172.16.0.0/12
+PersistentKeepalive = 25
+FailoverGroup = corp
+[Peer]
+PublicKey = gN65BkIKy1eCE9pP1wdc8ROUtkHLF2PfAqYdyYBz6EA=
+AllowedIPs = 172.16.0.0/12, 10.0.0.0/8
+PersistentKeepalive = auto
+FailoverGroup = corp`
+	conf, err := FromWgQuick(input, "test")
+	if noError(t, err) {
+		equal(t, []FailoverGroup{{"corp", []int{0, 1}}}, conf.FailoverGroups())
+	}
+	for _, invalid := range []string{
+		strings.Replace(input, "FailoverGroup = corp", "FailoverGroup = other", 1),
+		strings.Replace(input, "172.16.0.0/12, 10.0.0.0/8", "10.0.0.0/8", 1),
+		strings.Replace(input, "PersistentKeepalive = 25\n", "", 1),
+		strings.Replace(input, "= corp", "= my group", 1),
+	} {
+		_, err = FromWgQuick(invalid, "test")
+		if err == nil {
+			t.Errorf("Error was expected for %#q", invalid)
+		}
+	}
+}
+
+func TestParsePresharedKeyProvider(t *testing.T) {
+	for input, expected := range map[string]PresharedKeyProvider{
+		`pipe \\.\pipe\rosenpass`:                    {Pipe: `\\.\pipe\rosenpass`},
+		`Command C:\Program Files\rp.exe --exchange`: {Command: `C:\Program Files\rp.exe --exchange`},
+	} {
+		provider, err := parsePresharedKeyProvider(input)
+		if noError(t, err) {
+			equal(t, expected, *provider)
+		}
+	}
+	for _, invalid := range []string{`pipe`, `pipe \\.\pipe\`, `pipe C:\file`, `command`, `file C:\keys`} {
+		_, err := parsePresharedKeyProvider(invalid)
+		if err == nil {
+			t.Errorf("Error was expected for %#q", invalid)
+		}
+	}
+}
+
+func TestKeyFiles(t *testing.T) {
+	const input = `[Interface]
+PrivateKeyFile = corp.key.dpapi
+[Peer]
+PublicKey = TrMvSoP4jYQlY6RIzBgbssQqY3vxI2Pi+y71lOWWXX0=
+PresharedKeyFile = corp-psk.key`
+	conf, err := FromWgQuick(input, "test")
+	if noError(t, err) {
+		equal(t, "corp.key.dpapi", conf.Interface.PrivateKeyFile)
+		equal(t, true, conf.Interface.PrivateKey.IsZero())
+		equal(t, "corp-psk.key", conf.Peers[0].PresharedKeyFile)
+	}
+	for _, invalid := range []string{
+		strings.Replace(input, "corp.key.dpapi", `..\Configurations\other.conf.dpapi`, 1),
+		strings.Replace(input, "corp.key.dpapi", `C:corp.key`, 1),
+		strings.Replace(input, "corp.key.dpapi", "..", 1),
+		strings.Replace(input, "PrivateKeyFile = corp.key.dpapi", "PrivateKeyFile = corp.key\nPrivateKey = yAnz5TF+lXXJte14tji3zlMNq+hd2rYUIgJBgB3fBmk=", 1),
+		input + "\nPresharedKey = yAnz5TF+lXXJte14tji3zlMNq+hd2rYUIgJBgB3fBmk=",
+	} {
+		_, err = FromWgQuick(invalid, "test")
+		if err == nil {
+			t.Errorf("Error was expected for %#q", invalid)
+		}
+	}
+}
+
+func TestFromWgQuickTolerant(t *testing.T) {
+	const input = `[Interface]
+PrivateKey = yAnz5TF+lXXJte14tji3zlMNq+hd2rYUIgJBgB3fBmk=
+FwMark = 0xca6c
+Table = 1234
+ExcludedApplications = com.example.app
+[Peer]
+PublicKey = TrMvSoP4jYQlY6RIzBgbssQqY3vxI2Pi+y71lOWWXX0=`
+	_, err := FromWgQuick(input, "test")
+	if err == nil {
+		t.Error("Error was expected for unsupported keys")
+	}
+	conf, err := FromWgQuickTolerant(input, "test")
+	if noError(t, err) {
+		equal(t, []UnsupportedKey{{"FwMark", "0xca6c"}, {"Table", "1234"}, {"ExcludedApplications", "com.example.app"}}, conf.Interface.Unsupported)
+		equal(t, false, conf.Interface.TableOff)
+	}
+	conf, err = FromWgQuickTolerant(strings.Replace(input, "FwMark = 0xca6c\nTable = 1234\nExcludedApplications = com.example.app\n", "Table = main\nSaveConfig = true\n", 1), "test")
+	if noError(t, err) {
+		equal(t, 0, len(conf.Interface.Unsupported))
+		equal(t, true, conf.Interface.SaveConfig)
+	}
+	for _, invalid := range []string{
+		strings.Replace(input, "FwMark", "Mark", 1),
+		input + "\nFwMark = 0xca6c",
+	} {
+		_, err = FromWgQuickTolerant(invalid, "test")
+		if err == nil {
+			t.Errorf("Error was expected for %#q", invalid)
+		}
+	}
+}
@@ -37,6 +37,14 @@ func tunnelConfigurationsDirectory() (string, error) {
 	return cachedConfigFileDir, nil
 }
 
+func keysDirectory() (string, error) {
+	root, err := RootDirectory(true)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(root, "Keys"), nil
+}
+
 // PresetRootDirectory causes RootDirectory() to not try any automatic deduction, and instead
 // uses what's passed to it. This isn't used by wireguard-windows, but is useful for external
 // consumers of our libraries who might want to do strange things.
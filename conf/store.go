@@ -7,6 +7,7 @@ package conf
 
 import (
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -17,6 +18,7 @@ import (
 const (
 	configFileSuffix            = ".conf.dpapi"
 	configFileUnencryptedSuffix = ".conf"
+	keyFileSuffix               = ".dpapi"
 )
 
 func ListConfigNames() ([]string, error) {
@@ -77,7 +79,66 @@ func LoadFromPath(path string) (*Config, error) {
 			return nil, err
 		}
 	}
-	return FromWgQuickWithUnknownEncoding(string(bytes), name)
+	// Configurations may have been imported tolerantly, so they are loaded the same way.
+	config, err := FromWgQuickTolerantWithUnknownEncoding(string(bytes), name)
+	if err != nil {
+		return nil, err
+	}
+	err = config.loadKeyFiles()
+	if err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// loadKeyFiles loads the keys that the configuration references by PrivateKeyFile and PresharedKeyFile, keeping the
+// references, so that the keys are written as them again.
+func (config *Config) loadKeyFiles() error {
+	if len(config.Interface.PrivateKeyFile) > 0 {
+		k, err := loadKeyFile(config.Interface.PrivateKeyFile)
+		if err != nil {
+			return err
+		}
+		config.Interface.PrivateKey = *k
+	}
+	for i := range config.Peers {
+		if len(config.Peers[i].PresharedKeyFile) > 0 {
+			k, err := loadKeyFile(config.Peers[i].PresharedKeyFile)
+			if err != nil {
+				return err
+			}
+			config.Peers[i].PresharedKey = *k
+		}
+	}
+	return nil
+}
+
+// loadKeyFile reads a key in base64 from a file in the Keys directory of the data directory, which is encrypted
+// like configuration files if its name ends in .dpapi, with the rest of its name as the description.
+func loadKeyFile(name string) (*Key, error) {
+	keysDir, err := keysDirectory()
+	if err != nil {
+		return nil, err
+	}
+	bytes, err := os.ReadFile(filepath.Join(keysDir, name))
+	if err != nil {
+		return nil, err
+	}
+	if len(name) > len(keyFileSuffix) && strings.HasSuffix(name, keyFileSuffix) {
+		decrypted, err := dpapi.Decrypt(bytes, strings.TrimSuffix(name, keyFileSuffix))
+		clear(bytes)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to decrypt key file %s: %w", name, err)
+		}
+		bytes = decrypted
+	}
+	defer clear(bytes)
+	k, err := parseKeyBase64(strings.TrimSpace(string(bytes)))
+	if err != nil {
+		// The error of parseKeyBase64 contains what it failed to parse, which is not to be logged.
+		return nil, fmt.Errorf("Invalid key in key file %s", name)
+	}
+	return k, nil
 }
 
 func PathIsEncrypted(path string) bool {
@@ -20,7 +20,12 @@ func (conf *Config) ToWgQuick() string {
 	var output strings.Builder
 	output.WriteString("[Interface]\n")
 
-	output.WriteString(fmt.Sprintf("PrivateKey = %s\n", conf.Interface.PrivateKey.String()))
+	// Keys loaded from key files are written as the references to them, so that they stay out of the configuration.
+	if len(conf.Interface.PrivateKeyFile) > 0 {
+		output.WriteString(fmt.Sprintf("PrivateKeyFile = %s\n", conf.Interface.PrivateKeyFile))
+	} else {
+		output.WriteString(fmt.Sprintf("PrivateKey = %s\n", conf.Interface.PrivateKey.String()))
+	}
 
 	if conf.Interface.ListenPort > 0 {
 		output.WriteString(fmt.Sprintf("ListenPort = %d\n", conf.Interface.ListenPort))
@@ -43,9 +48,51 @@ func (conf *Config) ToWgQuick() string {
 		output.WriteString(fmt.Sprintf("DNS = %s\n", strings.Join(addrStrings[:], ", ")))
 	}
 
-	if conf.Interface.MTU > 0 {
+	if len(conf.Interface.SplitDNS) > 0 {
+		output.WriteString(fmt.Sprintf("SplitDNS = %s\n", strings.Join(conf.Interface.SplitDNS, ", ")))
+	}
+
+	for _, entry := range conf.Interface.Hosts {
+		output.WriteString(fmt.Sprintf("Hosts = %s\n", entry.String()))
+	}
+
+	if len(conf.Interface.Proxy.Server) > 0 {
+		output.WriteString(fmt.Sprintf("Proxy = %s\n", conf.Interface.Proxy.Server))
+	}
+	if len(conf.Interface.Proxy.Bypass) > 0 {
+		output.WriteString(fmt.Sprintf("ProxyBypass = %s\n", strings.Join(conf.Interface.Proxy.Bypass, ", ")))
+	}
+	if len(conf.Interface.Proxy.AutoConfig) > 0 {
+		output.WriteString(fmt.Sprintf("ProxyAutoConfig = %s\n", conf.Interface.Proxy.AutoConfig))
+	}
+
+	for _, encrypted := range conf.Interface.EncryptedDNS {
+		output.WriteString(fmt.Sprintf("EncryptedDNS = %s\n", encrypted.String()))
+	}
+
+	if conf.Interface.MTUAutoProbe {
+		output.WriteString("MTU = auto-probe\n")
+	} else if conf.Interface.MTU > 0 {
 		output.WriteString(fmt.Sprintf("MTU = %d\n", conf.Interface.MTU))
 	}
+	if len(conf.Interface.AdapterGUID) > 0 {
+		output.WriteString(fmt.Sprintf("AdapterGUID = %s\n", conf.Interface.AdapterGUID))
+	}
+	switch conf.Interface.NetworkCategory {
+	case NetworkCategoryPublic:
+		output.WriteString("NetworkCategory = public\n")
+	case NetworkCategoryPrivate:
+		output.WriteString("NetworkCategory = private\n")
+	}
+	if conf.Interface.Metric > 0 {
+		output.WriteString(fmt.Sprintf("Metric = %d\n", conf.Interface.Metric))
+	}
+	for _, route := range conf.Interface.Routes {
+		output.WriteString(fmt.Sprintf("Route = %s\n", route.String()))
+	}
+	for _, metric := range conf.Interface.RouteMetrics {
+		output.WriteString(fmt.Sprintf("RouteMetric = %s\n", metric.String()))
+	}
 
 	if len(conf.Interface.PreUp) > 0 {
 		output.WriteString(fmt.Sprintf("PreUp = %s\n", conf.Interface.PreUp))
@@ -62,13 +109,39 @@ func (conf *Config) ToWgQuick() string {
 	if conf.Interface.TableOff {
 		output.WriteString("Table = off\n")
 	}
+	if conf.Interface.SaveConfig {
+		output.WriteString("SaveConfig = true\n")
+	}
+	if conf.Interface.BlockEncryptedDNS {
+		output.WriteString("BlockEncryptedDNS = true\n")
+	}
+	if conf.Interface.Inbound == InboundBlock {
+		output.WriteString("Inbound = block\n")
+	}
+	for _, rule := range conf.Interface.AllowInbound {
+		output.WriteString(fmt.Sprintf("AllowInbound = %s\n", rule.String()))
+	}
+	switch conf.Interface.VirtualMachines {
+	case VirtualMachinesAllow:
+		output.WriteString("VirtualMachines = allow\n")
+	case VirtualMachinesTunnel:
+		output.WriteString("VirtualMachines = tunnel\n")
+	}
+	if !conf.Interface.PresharedKeyProvider.IsEmpty() {
+		output.WriteString(fmt.Sprintf("PresharedKeyProvider = %s\n", conf.Interface.PresharedKeyProvider.String()))
+	}
+	for _, unsupported := range conf.Interface.Unsupported {
+		output.WriteString(fmt.Sprintf("%s = %s\n", unsupported.Key, unsupported.Value))
+	}
 
 	for _, peer := range conf.Peers {
 		output.WriteString("\n[Peer]\n")
 
 		output.WriteString(fmt.Sprintf("PublicKey = %s\n", peer.PublicKey.String()))
 
-		if !peer.PresharedKey.IsZero() {
+		if len(peer.PresharedKeyFile) > 0 {
+			output.WriteString(fmt.Sprintf("PresharedKeyFile = %s\n", peer.PresharedKeyFile))
+		} else if !peer.PresharedKey.IsZero() {
 			output.WriteString(fmt.Sprintf("PresharedKey = %s\n", peer.PresharedKey.String()))
 		}
 
@@ -84,9 +157,15 @@ func (conf *Config) ToWgQuick() string {
 			output.WriteString(fmt.Sprintf("Endpoint = %s\n", peer.Endpoint.String()))
 		}
 
-		if peer.PersistentKeepalive > 0 {
+		if peer.PersistentKeepaliveAuto {
+			output.WriteString("PersistentKeepalive = auto\n")
+		} else if peer.PersistentKeepalive > 0 {
 			output.WriteString(fmt.Sprintf("PersistentKeepalive = %d\n", peer.PersistentKeepalive))
 		}
+
+		if len(peer.FailoverGroup) > 0 {
+			output.WriteString(fmt.Sprintf("FailoverGroup = %s\n", peer.FailoverGroup))
+		}
 	}
 	return output.String()
 }
@@ -96,6 +175,14 @@ func (config *Config) ToDriverConfiguration() (*driver.Interface, uint32) {
 	for i := range config.Peers {
 		preallocation += uintptr(len(config.Peers[i].AllowedIPs)) * unsafe.Sizeof(driver.AllowedIP{})
 	}
+	// Only the first peer of each failover group is given the allowed IPs at first, and the tunnel service moves
+	// them to the others as needed.
+	standby := make([]bool, len(config.Peers))
+	for _, group := range config.FailoverGroups() {
+		for _, i := range group.Peers[1:] {
+			standby[i] = true
+		}
+	}
 	var c driver.ConfigBuilder
 	c.Preallocate(uint32(preallocation))
 	c.AppendInterface(&driver.Interface{
@@ -105,10 +192,18 @@ func (config *Config) ToDriverConfiguration() (*driver.Interface, uint32) {
 		PeerCount:  uint32(len(config.Peers)),
 	})
 	for i := range config.Peers {
-		flags := driver.PeerHasPublicKey | driver.PeerHasPersistentKeepalive
+		flags := driver.PeerHasPublicKey
+		if !config.Peers[i].PersistentKeepaliveAuto {
+			// Otherwise the keepalive is left as adjusted by the tunnel service, or off for a new peer.
+			flags |= driver.PeerHasPersistentKeepalive
+		}
 		if !config.Peers[i].PresharedKey.IsZero() {
 			flags |= driver.PeerHasPresharedKey
 		}
+		allowedIPs := config.Peers[i].AllowedIPs
+		if standby[i] {
+			allowedIPs = nil
+		}
 		var endpoint winipcfg.RawSockaddrInet
 		if !config.Peers[i].Endpoint.IsEmpty() {
 			addr, err := netip.ParseAddr(config.Peers[i].Endpoint.Host)
@@ -123,9 +218,9 @@ func (config *Config) ToDriverConfiguration() (*driver.Interface, uint32) {
 			PresharedKey:        config.Peers[i].PresharedKey,
 			PersistentKeepalive: config.Peers[i].PersistentKeepalive,
 			Endpoint:            endpoint,
-			AllowedIPsCount:     uint32(len(config.Peers[i].AllowedIPs)),
+			AllowedIPsCount:     uint32(len(allowedIPs)),
 		})
-		for j := range config.Peers[i].AllowedIPs {
+		for j := range allowedIPs {
 			a := &driver.AllowedIP{Cidr: uint8(config.Peers[i].AllowedIPs[j].Bits())}
 			copy(a.Address[:], config.Peers[i].AllowedIPs[j].Addr().AsSlice())
 			if config.Peers[i].AllowedIPs[j].Addr().Is4() {
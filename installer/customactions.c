@@ -358,6 +358,11 @@ __declspec(dllexport) UINT __stdcall EvaluateWireGuardComponents(MSIHANDLE insta
 			log_errorf(installer, LOG_LEVEL_ERR, ret, TEXT("MsiSetProperty(\"RemoveAdapters\") failed"));
 			goto out;
 		}
+		ret = MsiSetProperty(installer, TEXT("DisableLockdown"), path);
+		if (ret != ERROR_SUCCESS) {
+			log_errorf(installer, LOG_LEVEL_ERR, ret, TEXT("MsiSetProperty(\"DisableLockdown\") failed"));
+			goto out;
+		}
 	}
 	ret = ERROR_SUCCESS;
 
@@ -584,10 +589,9 @@ out:
 	return ERROR_SUCCESS;
 }
 
-__declspec(dllexport) UINT __stdcall RemoveAdapters(MSIHANDLE installer)
+static void run_wireguard(MSIHANDLE installer, TCHAR *command_line)
 {
 	UINT ret;
-	bool is_com_initialized = SUCCEEDED(CoInitialize(NULL));
 	TCHAR path[MAX_PATH];
 	DWORD path_len = _countof(path);
 	HANDLE pipe;
@@ -603,20 +607,20 @@ __declspec(dllexport) UINT __stdcall RemoveAdapters(MSIHANDLE installer)
 	ret = MsiGetProperty(installer, TEXT("CustomActionData"), path, &path_len);
 	if (ret != ERROR_SUCCESS) {
 		log_errorf(installer, LOG_LEVEL_WARN, ret, TEXT("MsiGetProperty(\"CustomActionData\") failed"));
-		goto out;
+		return;
 	}
 	if (!path[0] || !PathAppend(path, TEXT("wireguard.exe")))
-		goto out;
+		return;
 
 	if (!CreatePipe(&pipe, &si.hStdOutput, NULL, 0)) {
 		log_errorf(installer, LOG_LEVEL_WARN, GetLastError(), TEXT("CreatePipe failed"));
-		goto out;
+		return;
 	}
 	if (!SetHandleInformation(si.hStdOutput, HANDLE_FLAG_INHERIT, HANDLE_FLAG_INHERIT)) {
 		log_errorf(installer, LOG_LEVEL_WARN, GetLastError(), TEXT("SetHandleInformation failed"));
 		goto cleanup_pipe_w;
 	}
-	if (!CreateProcess(path, TEXT("wireguard /removedriver"), NULL, NULL, TRUE, 0, NULL, NULL, &si, &pi)) {
+	if (!CreateProcess(path, command_line, NULL, NULL, TRUE, 0, NULL, NULL, &si, &pi)) {
 		log_errorf(installer, LOG_LEVEL_WARN, GetLastError(), TEXT("Failed to create \"%1\" process"), path);
 		goto cleanup_pipe_w;
 	}
@@ -648,7 +652,23 @@ cleanup_pipe_w:
 	CloseHandle(si.hStdOutput);
 cleanup_pipe_r:
 	CloseHandle(pipe);
-out:
+}
+
+__declspec(dllexport) UINT __stdcall RemoveAdapters(MSIHANDLE installer)
+{
+	bool is_com_initialized = SUCCEEDED(CoInitialize(NULL));
+
+	run_wireguard(installer, TEXT("wireguard /removedriver"));
+	if (is_com_initialized)
+		CoUninitialize();
+	return ERROR_SUCCESS;
+}
+
+__declspec(dllexport) UINT __stdcall DisableLockdown(MSIHANDLE installer)
+{
+	bool is_com_initialized = SUCCEEDED(CoInitialize(NULL));
+
+	run_wireguard(installer, TEXT("wireguard /disablelockdown"));
 	if (is_com_initialized)
 		CoUninitialize();
 	return ERROR_SUCCESS;
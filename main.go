@@ -7,6 +7,7 @@ package main
 
 import (
 	"debug/pe"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -26,6 +27,7 @@ import (
 	"golang.zx2c4.com/wireguard/windows/manager"
 	"golang.zx2c4.com/wireguard/windows/ringlogger"
 	"golang.zx2c4.com/wireguard/windows/tunnel"
+	"golang.zx2c4.com/wireguard/windows/tunnel/firewall"
 	"golang.zx2c4.com/wireguard/windows/ui"
 	"golang.zx2c4.com/wireguard/windows/updater"
 )
@@ -70,10 +72,15 @@ func usage() {
 		"/installtunnelservice CONFIG_PATH",
 		"/uninstallmanagerservice",
 		"/uninstalltunnelservice TUNNEL_NAME",
+		"/enablelockdown [TUNNEL_NAME...]",
+		"/disablelockdown",
+		"/captiveportal TUNNEL_NAME",
+		"/pinadapterguid TUNNEL_NAME",
 		"/managerservice",
 		"/tunnelservice CONFIG_PATH",
 		"/ui CMD_READ_HANDLE CMD_WRITE_HANDLE CMD_EVENT_HANDLE LOG_MAPPING_HANDLE",
 		"/dumplog [/tail]",
+		"/firewall [/outranking] [/json]",
 		"/update",
 		"/removedriver",
 	}
@@ -232,6 +239,40 @@ func main() {
 			fatal(err)
 		}
 		return
+	case "/enablelockdown":
+		err := manager.EnableLockdown(os.Args[2:])
+		if err != nil {
+			fatal(err)
+		}
+		return
+	case "/disablelockdown":
+		if len(os.Args) != 2 {
+			usage()
+		}
+		err := manager.DisableLockdown()
+		if err != nil {
+			fatal(err)
+		}
+		return
+	case "/captiveportal":
+		if len(os.Args) != 3 {
+			usage()
+		}
+		err := manager.OpenCaptivePortal(os.Args[2])
+		if err != nil {
+			fatal(err)
+		}
+		return
+	case "/pinadapterguid":
+		if len(os.Args) != 3 {
+			usage()
+		}
+		guid, err := tunnel.PinAdapterGUID(os.Args[2])
+		if err != nil {
+			fatal(err)
+		}
+		info(l18n.Sprintf("Adapter GUID Pinned"), "%s", guid)
+		return
 	case "/tunnelservice":
 		if len(os.Args) != 3 {
 			usage()
@@ -300,6 +341,61 @@ func main() {
 			fatal(err)
 		}
 		return
+	case "/firewall":
+		var outranking, asJSON bool
+		for _, arg := range os.Args[2:] {
+			switch {
+			case arg == "/outranking" && !outranking && !asJSON:
+				outranking = true
+			case arg == "/json" && !asJSON:
+				asJSON = true
+			default:
+				usage()
+			}
+		}
+		outputHandle, err := windows.GetStdHandle(windows.STD_OUTPUT_HANDLE)
+		if err != nil {
+			fatal(err)
+		}
+		if outputHandle == 0 {
+			fatal("Stdout must be set")
+		}
+		file := os.NewFile(uintptr(outputHandle), "stdout")
+		defer file.Close()
+		var items []fmt.Stringer
+		var result any
+		if outranking {
+			sublayers, err := firewall.OutrankingSublayers()
+			if err != nil {
+				fatal(err)
+			}
+			for i := range sublayers {
+				items = append(items, &sublayers[i])
+			}
+			result = sublayers
+		} else {
+			rules, err := firewall.InstalledRules()
+			if err != nil {
+				fatal(err)
+			}
+			for i := range rules {
+				items = append(items, &rules[i])
+			}
+			result = rules
+		}
+		if asJSON {
+			encoder := json.NewEncoder(file)
+			encoder.SetIndent("", "  ")
+			err = encoder.Encode(result)
+			if err != nil {
+				fatal(err)
+			}
+			return
+		}
+		for _, item := range items {
+			fmt.Fprintf(file, "%s\n\n", item)
+		}
+		return
 	case "/update":
 		if len(os.Args) != 2 {
 			usage()
@@ -17,6 +17,7 @@ import (
 	"golang.org/x/sys/windows/svc/mgr"
 
 	"golang.zx2c4.com/wireguard/windows/conf"
+	"golang.zx2c4.com/wireguard/windows/services"
 )
 
 var cachedServiceManager *mgr.Mgr
@@ -33,6 +34,11 @@ func serviceManager() (*mgr.Mgr, error) {
 	return cachedServiceManager, nil
 }
 
+// tunnelServiceSecurity is the default DACL of services, except that interactive and service users may not send
+// user-defined controls, such as services.ServiceControlCaptivePortal and services.ServiceControlSaveConfig, which
+// are therefore left to Administrators and the manager.
+const tunnelServiceSecurity = "D:(A;;CCLCSWRPWPDTLOCRRC;;;SY)(A;;CCDCLCSWRPWPDTLOCRSDRCWDWO;;;BA)(A;;CCLCSWLORC;;;IU)(A;;CCLCSWLORC;;;SU)"
+
 var ErrManagerAlreadyRunning = errors.New("Manager already installed and running")
 
 func InstallManager() error {
@@ -176,12 +182,30 @@ func InstallTunnel(configPath string) error {
 	if err != nil {
 		return err
 	}
+	err = setTunnelServiceSecurity(service)
+	if err != nil {
+		service.Delete()
+		service.Close()
+		return err
+	}
 
 	err = service.Start()
 	go trackTunnelService(name, service) // Pass off reference to handle.
 	return err
 }
 
+func setTunnelServiceSecurity(service *mgr.Service) error {
+	sd, err := windows.SecurityDescriptorFromString(tunnelServiceSecurity)
+	if err != nil {
+		return err
+	}
+	dacl, _, err := sd.DACL()
+	if err != nil {
+		return err
+	}
+	return windows.SetSecurityInfo(service.Handle, windows.SE_SERVICE, windows.DACL_SECURITY_INFORMATION, nil, nil, dacl, nil)
+}
+
 func UninstallTunnel(name string) error {
 	m, err := serviceManager()
 	if err != nil {
@@ -204,6 +228,42 @@ func UninstallTunnel(name string) error {
 	return err2
 }
 
+func OpenCaptivePortal(name string) error {
+	m, err := serviceManager()
+	if err != nil {
+		return err
+	}
+	serviceName, err := conf.ServiceNameOfTunnel(name)
+	if err != nil {
+		return err
+	}
+	service, err := m.OpenService(serviceName)
+	if err != nil {
+		return err
+	}
+	_, err = service.Control(services.ServiceControlCaptivePortal)
+	service.Close()
+	return err
+}
+
+func SaveTunnelConfig(name string) error {
+	m, err := serviceManager()
+	if err != nil {
+		return err
+	}
+	serviceName, err := conf.ServiceNameOfTunnel(name)
+	if err != nil {
+		return err
+	}
+	service, err := m.OpenService(serviceName)
+	if err != nil {
+		return err
+	}
+	_, err = service.Control(services.ServiceControlSaveConfig)
+	service.Close()
+	return err
+}
+
 func changeTunnelServiceConfigFilePath(name, oldPath, newPath string) {
 	var err error
 	defer func() {
@@ -37,6 +37,7 @@ const (
 	ManagerStoppingNotificationType
 	UpdateFoundNotificationType
 	UpdateProgressNotificationType
+	ProxyChangeNotificationType
 )
 
 type MethodType int
@@ -55,6 +56,9 @@ const (
 	QuitMethodType
 	UpdateStateMethodType
 	UpdateMethodType
+	OpenCaptivePortalMethodType
+	ProxySettingsMethodType
+	SaveRuntimeConfigMethodType
 )
 
 var (
@@ -108,6 +112,15 @@ var (
 	updateProgressCallbacksLock sync.RWMutex
 )
 
+type ProxyChangeCallback struct {
+	cb func(settings *conf.ProxySettings)
+}
+
+var (
+	proxyChangeCallbacks     = make(map[*ProxyChangeCallback]bool)
+	proxyChangeCallbacksLock sync.RWMutex
+)
+
 func InitializeIPCClient(reader, writer, events *os.File) {
 	rpcDecoder = gob.NewDecoder(reader)
 	rpcEncoder = gob.NewEncoder(writer)
@@ -208,6 +221,17 @@ func InitializeIPCClient(reader, writer, events *os.File) {
 					cb.cb(dp)
 				}
 				updateProgressCallbacksLock.RUnlock()
+			case ProxyChangeNotificationType:
+				var settings conf.ProxySettings
+				err = decoder.Decode(&settings)
+				if err != nil {
+					return
+				}
+				proxyChangeCallbacksLock.RLock()
+				for cb := range proxyChangeCallbacks {
+					cb.cb(&settings)
+				}
+				proxyChangeCallbacksLock.RUnlock()
 			}
 		}
 	}()
@@ -327,6 +351,38 @@ func (t *Tunnel) WaitForStop() (err error) {
 	return
 }
 
+func (t *Tunnel) OpenCaptivePortal() (err error) {
+	rpcMutex.Lock()
+	defer rpcMutex.Unlock()
+
+	err = rpcEncoder.Encode(OpenCaptivePortalMethodType)
+	if err != nil {
+		return
+	}
+	err = rpcEncoder.Encode(t.Name)
+	if err != nil {
+		return
+	}
+	err = rpcDecodeError()
+	return
+}
+
+func (t *Tunnel) SaveRuntimeConfig() (err error) {
+	rpcMutex.Lock()
+	defer rpcMutex.Unlock()
+
+	err = rpcEncoder.Encode(SaveRuntimeConfigMethodType)
+	if err != nil {
+		return
+	}
+	err = rpcEncoder.Encode(t.Name)
+	if err != nil {
+		return
+	}
+	err = rpcDecodeError()
+	return
+}
+
 func (t *Tunnel) Delete() (err error) {
 	rpcMutex.Lock()
 	defer rpcMutex.Unlock()
@@ -456,6 +512,18 @@ func IPCClientUpdate() error {
 	return rpcEncoder.Encode(UpdateMethodType)
 }
 
+func IPCClientProxySettings() (settings conf.ProxySettings, err error) {
+	rpcMutex.Lock()
+	defer rpcMutex.Unlock()
+
+	err = rpcEncoder.Encode(ProxySettingsMethodType)
+	if err != nil {
+		return
+	}
+	err = rpcDecoder.Decode(&settings)
+	return
+}
+
 func IPCClientRegisterTunnelChange(cb func(tunnel *Tunnel, state, globalState TunnelState, err error)) *TunnelChangeCallback {
 	s := &TunnelChangeCallback{cb}
 	tunnelChangeCallbacksLock.Lock()
@@ -525,3 +593,17 @@ func (cb *UpdateProgressCallback) Unregister() {
 	delete(updateProgressCallbacks, cb)
 	updateProgressCallbacksLock.Unlock()
 }
+
+func IPCClientRegisterProxyChange(cb func(settings *conf.ProxySettings)) *ProxyChangeCallback {
+	s := &ProxyChangeCallback{cb}
+	proxyChangeCallbacksLock.Lock()
+	proxyChangeCallbacks[s] = true
+	proxyChangeCallbacksLock.Unlock()
+	return s
+}
+
+func (cb *ProxyChangeCallback) Unregister() {
+	proxyChangeCallbacksLock.Lock()
+	delete(proxyChangeCallbacks, cb)
+	proxyChangeCallbacksLock.Unlock()
+}
@@ -8,6 +8,7 @@ package manager
 import (
 	"bytes"
 	"encoding/gob"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -20,6 +21,7 @@ import (
 	"golang.org/x/sys/windows/svc"
 
 	"golang.zx2c4.com/wireguard/windows/conf"
+	"golang.zx2c4.com/wireguard/windows/tunnel/winipcfg"
 	"golang.zx2c4.com/wireguard/windows/updater"
 )
 
@@ -63,6 +65,17 @@ func (s *ManagerService) RuntimeConfig(tunnelName string) (*conf.Config, error)
 		return nil, err
 	}
 	conf := conf.FromDriverConfiguration(runtimeConfig, storedConfig)
+	if conf.Interface.MTUAutoProbe {
+		// Report the MTU that the tunnel service settled on, which is the same for both families unless one of
+		// them hit its minimum.
+		luid := driverAdapter.LUID()
+		for _, family := range []winipcfg.AddressFamily{windows.AF_INET, windows.AF_INET6} {
+			if iface, err := luid.IPInterface(family); err == nil && iface.NLMTU <= 65535 {
+				conf.Interface.MTU = uint16(iface.NLMTU)
+				break
+			}
+		}
+	}
 	driverAdapter.Unlock()
 	if s.elevatedToken == 0 {
 		conf.Redact()
@@ -152,14 +165,40 @@ func (s *ManagerService) WaitForStop(tunnelName string) error {
 	}
 }
 
+func (s *ManagerService) OpenCaptivePortal(tunnelName string) error {
+	return OpenCaptivePortal(tunnelName)
+}
+
+func (s *ManagerService) SaveRuntimeConfig(tunnelName string) error {
+	if s.elevatedToken == 0 {
+		return windows.ERROR_ACCESS_DENIED
+	}
+	storedConfig, err := conf.LoadFromName(tunnelName)
+	if err != nil {
+		return err
+	}
+	if !storedConfig.Interface.SaveConfig {
+		return errors.New("SaveConfig is not enabled for this tunnel")
+	}
+	return SaveTunnelConfig(tunnelName)
+}
+
 func (s *ManagerService) Delete(tunnelName string) error {
 	if s.elevatedToken == 0 {
 		return windows.ERROR_ACCESS_DENIED
 	}
+	storedConfig, _ := conf.LoadFromName(tunnelName)
 	err := s.Stop(tunnelName)
 	if err != nil {
 		return err
 	}
+	if storedConfig != nil && storedConfig.Interface.SaveConfig {
+		// Otherwise the tunnel service might save its runtime configuration after it has been deleted.
+		err = s.WaitForStop(tunnelName)
+		if err != nil {
+			return err
+		}
+	}
 	return conf.DeleteName(tunnelName)
 }
 
@@ -275,6 +314,10 @@ func (s *ManagerService) Update() {
 	}()
 }
 
+func (s *ManagerService) ProxySettings() conf.ProxySettings {
+	return currentProxySettings()
+}
+
 func (s *ManagerService) ServeConn(reader io.Reader, writer io.Writer) {
 	decoder := gob.NewDecoder(reader)
 	encoder := gob.NewEncoder(writer)
@@ -354,6 +397,17 @@ func (s *ManagerService) ServeConn(reader io.Reader, writer io.Writer) {
 			if err != nil {
 				return
 			}
+		case OpenCaptivePortalMethodType:
+			var tunnelName string
+			err := decoder.Decode(&tunnelName)
+			if err != nil {
+				return
+			}
+			retErr := s.OpenCaptivePortal(tunnelName)
+			err = encoder.Encode(errToString(retErr))
+			if err != nil {
+				return
+			}
 		case DeleteMethodType:
 			var tunnelName string
 			err := decoder.Decode(&tunnelName)
@@ -437,6 +491,23 @@ func (s *ManagerService) ServeConn(reader io.Reader, writer io.Writer) {
 			}
 		case UpdateMethodType:
 			s.Update()
+		case ProxySettingsMethodType:
+			settings := s.ProxySettings()
+			err = encoder.Encode(settings)
+			if err != nil {
+				return
+			}
+		case SaveRuntimeConfigMethodType:
+			var tunnelName string
+			err := decoder.Decode(&tunnelName)
+			if err != nil {
+				return
+			}
+			retErr := s.SaveRuntimeConfig(tunnelName)
+			err = encoder.Encode(errToString(retErr))
+			if err != nil {
+				return
+			}
 		default:
 			return
 		}
@@ -521,6 +592,10 @@ func IPCServerNotifyUpdateProgress(dp updater.DownloadProgress) {
 	notifyAll(UpdateProgressNotificationType, true, dp.Activity, dp.BytesDownloaded, dp.BytesTotal, errToString(dp.Error), dp.Complete)
 }
 
+func IPCServerNotifyProxyChange(settings conf.ProxySettings) {
+	notifyAll(ProxyChangeNotificationType, false, settings)
+}
+
 func IPCServerNotifyManagerStopping() {
 	notifyAll(ManagerStoppingNotificationType, false)
 	time.Sleep(time.Millisecond * 200)
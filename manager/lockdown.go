@@ -0,0 +1,47 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2026 WireGuard LLC. All Rights Reserved.
+ */
+
+package manager
+
+import (
+	"log"
+	"strings"
+
+	"golang.zx2c4.com/wireguard/windows/conf"
+	"golang.zx2c4.com/wireguard/windows/tunnel/firewall"
+)
+
+// EnableLockdown installs persistent firewall rules that block all traffic outside of the named tunnels,
+// which remain in place when tunnels are stopped and across reboots, until DisableLockdown is called.
+func EnableLockdown(tunnelNames []string) error {
+	serviceNames := make([]string, 0, len(tunnelNames))
+	for _, name := range tunnelNames {
+		serviceName, err := conf.ServiceNameOfTunnel(name)
+		if err != nil {
+			return err
+		}
+		serviceNames = append(serviceNames, serviceName)
+	}
+	err := firewall.EnableLockdown(serviceNames)
+	if err != nil {
+		return err
+	}
+	if len(tunnelNames) == 0 {
+		log.Println("Lockdown mode enabled, blocking all traffic")
+	} else {
+		log.Printf("Lockdown mode enabled, permitting only tunnels: %s", strings.Join(tunnelNames, ", "))
+	}
+	return nil
+}
+
+// DisableLockdown removes the persistent firewall rules installed by EnableLockdown.
+func DisableLockdown() error {
+	err := firewall.DisableLockdown()
+	if err != nil {
+		return err
+	}
+	log.Println("Lockdown mode disabled")
+	return nil
+}
@@ -0,0 +1,73 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2026 WireGuard LLC. All Rights Reserved.
+ */
+
+package manager
+
+import (
+	"log"
+	"reflect"
+	"slices"
+	"sync"
+
+	"golang.zx2c4.com/wireguard/windows/conf"
+)
+
+type tunnelProxy struct {
+	name     string
+	settings conf.ProxySettings
+}
+
+var (
+	tunnelProxies     []tunnelProxy // In the order in which the tunnels started, with the last one in effect.
+	tunnelProxiesLock sync.Mutex
+)
+
+func currentProxySettingsLocked() conf.ProxySettings {
+	if len(tunnelProxies) == 0 {
+		return conf.ProxySettings{}
+	}
+	return tunnelProxies[len(tunnelProxies)-1].settings
+}
+
+func currentProxySettings() conf.ProxySettings {
+	tunnelProxiesLock.Lock()
+	defer tunnelProxiesLock.Unlock()
+	return currentProxySettingsLocked()
+}
+
+// updateTunnelProxy tracks the proxy settings of tunnels as they start and stop, and notifies the UI processes, which
+// apply them to the WinINet settings of their users, whenever the settings in effect change.
+func updateTunnelProxy(tunnelName string, state TunnelState) {
+	tunnelProxiesLock.Lock()
+	before := currentProxySettingsLocked()
+	i := slices.IndexFunc(tunnelProxies, func(p tunnelProxy) bool { return p.name == tunnelName })
+	switch state {
+	case TunnelStarted:
+		if i >= 0 {
+			break
+		}
+		config, err := conf.LoadFromName(tunnelName)
+		if err != nil || config.Interface.Proxy.IsEmpty() {
+			break
+		}
+		tunnelProxies = append(tunnelProxies, tunnelProxy{tunnelName, config.Interface.Proxy})
+	case TunnelStopping, TunnelStopped:
+		if i >= 0 {
+			tunnelProxies = slices.Delete(tunnelProxies, i, i+1)
+		}
+	}
+	after := currentProxySettingsLocked()
+	tunnelProxiesLock.Unlock()
+
+	if reflect.DeepEqual(before, after) {
+		return
+	}
+	if after.IsEmpty() {
+		log.Println("Restoring proxy settings of users")
+	} else {
+		log.Printf("[%s] Applying proxy settings to users", tunnelName)
+	}
+	IPCServerNotifyProxyChange(after)
+}
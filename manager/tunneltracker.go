@@ -163,6 +163,7 @@ func trackTunnelService(tunnelName string, service *mgr.Service) {
 			trackedTunnels[tunnelName] = TunnelStopped
 			trackedTunnelsLock.Unlock()
 			releaseDriverAdapter(tunnelName)
+			updateTunnelProxy(tunnelName, TunnelStopped)
 			IPCServerNotifyTunnelChange(tunnelName, TunnelStopped, nil)
 			return true
 		}
@@ -202,6 +203,7 @@ func trackTunnelService(tunnelName string, service *mgr.Service) {
 			if state == TunnelStopped {
 				releaseDriverAdapter(tunnelName)
 			}
+			updateTunnelProxy(tunnelName, state)
 			IPCServerNotifyTunnelChange(tunnelName, state, tunnelError)
 			lastState = state
 		}
@@ -215,6 +217,7 @@ func trackTunnelService(tunnelName string, service *mgr.Service) {
 		trackedTunnels[tunnelName] = TunnelStopped
 		trackedTunnelsLock.Unlock()
 		releaseDriverAdapter(tunnelName)
+		updateTunnelProxy(tunnelName, TunnelStopped)
 		IPCServerNotifyTunnelChange(tunnelName, TunnelStopped, fmt.Errorf("Unable to continue monitoring service, so stopping: %w", err))
 		service.Control(svc.Stop)
 	}
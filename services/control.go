@@ -0,0 +1,16 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2026 WireGuard LLC. All Rights Reserved.
+ */
+
+package services
+
+import "golang.org/x/sys/windows/svc"
+
+// ServiceControlCaptivePortal is a user-defined service control code, which asks a tunnel service to temporarily
+// permit signing into a captive portal despite its kill-switch.
+const ServiceControlCaptivePortal = svc.Cmd(128)
+
+// ServiceControlSaveConfig is a user-defined service control code, which asks a tunnel service with SaveConfig to save
+// its runtime configuration to its stored configuration.
+const ServiceControlSaveConfig = svc.Cmd(129)
@@ -6,15 +6,22 @@
 package tunnel
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"net/netip"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 
 	"golang.org/x/sys/windows"
 	"golang.zx2c4.com/wireguard/windows/conf"
 	"golang.zx2c4.com/wireguard/windows/services"
 	"golang.zx2c4.com/wireguard/windows/tunnel/firewall"
+	"golang.zx2c4.com/wireguard/windows/tunnel/hosts"
+	"golang.zx2c4.com/wireguard/windows/tunnel/nlm"
+	"golang.zx2c4.com/wireguard/windows/tunnel/nrpt"
 	"golang.zx2c4.com/wireguard/windows/tunnel/winipcfg"
 )
 
@@ -73,7 +80,7 @@ startOver:
 		for _, allowedip := range peer.AllowedIPs {
 			route := winipcfg.RouteData{
 				Destination: allowedip.Masked(),
-				Metric:      0,
+				Metric:      routeMetric(&conf.Interface, allowedip.Masked()),
 			}
 			if allowedip.Addr().Is4() {
 				foundRoute4 = true
@@ -92,14 +99,38 @@ startOver:
 		}
 	}
 
-	deduplicatedRoutes := make([]*winipcfg.RouteData, 0, len(routes))
-	for route := range routes {
-		r := route
-		deduplicatedRoutes = append(deduplicatedRoutes, &r)
+	installRoutes4 := foundRoute4 && !conf.Interface.TableOff
+	installRoutes6 := foundRoute6 && !conf.Interface.TableOff
+	deduplicatedRoutes := make([]*winipcfg.RouteData, 0, len(routes)+len(conf.Interface.Routes))
+	if !conf.Interface.TableOff {
+		for route := range routes {
+			r := route
+			deduplicatedRoutes = append(deduplicatedRoutes, &r)
+		}
+	}
+	for _, extra := range conf.Interface.Routes {
+		route := &winipcfg.RouteData{
+			Destination: extra.Destination,
+			NextHop:     extra.NextHop,
+			Metric:      extra.Metric,
+		}
+		if extra.Destination.Addr().Is4() {
+			foundRoute4, installRoutes4 = true, true
+			foundDefault4 = foundDefault4 || extra.Destination.Bits() == 0
+			if !route.NextHop.IsValid() {
+				route.NextHop = netip.IPv4Unspecified()
+			}
+		} else {
+			foundRoute6, installRoutes6 = true, true
+			foundDefault6 = foundDefault6 || extra.Destination.Bits() == 0
+			if !route.NextHop.IsValid() {
+				route.NextHop = netip.IPv6Unspecified()
+			}
+		}
+		deduplicatedRoutes = append(deduplicatedRoutes, route)
 	}
 
-	if !conf.Interface.TableOff &&
-		((foundRoute4 && family == windows.AF_INET) || (foundRoute6 && family == windows.AF_INET6)) {
+	if (installRoutes4 && family == windows.AF_INET) || (installRoutes6 && family == windows.AF_INET6) {
 		err = luid.SetRoutesForFamily(family, deduplicatedRoutes)
 		if err == windows.ERROR_NOT_FOUND && retryOnFailure {
 			goto startOver
@@ -142,7 +173,10 @@ startOver:
 			((foundAddress6 || foundRoute6) && family == windows.AF_INET6)) {
 		ipif.NLMTU = uint32(conf.Interface.MTU)
 	}
-	if (family == windows.AF_INET && foundDefault4) || (family == windows.AF_INET6 && foundDefault6) {
+	if conf.Interface.Metric > 0 {
+		ipif.UseAutomaticMetric = false
+		ipif.Metric = conf.Interface.Metric
+	} else if (family == windows.AF_INET && foundDefault4) || (family == windows.AF_INET6 && foundDefault6) {
 		ipif.UseAutomaticMetric = false
 		ipif.Metric = 0
 	}
@@ -153,7 +187,12 @@ startOver:
 		return fmt.Errorf("unable to set metric and MTU: %w", err)
 	}
 
-	err = luid.SetDNS(family, conf.Interface.DNS, conf.Interface.DNSSearch)
+	dnsServers := conf.Interface.DNS
+	if len(conf.Interface.SplitDNS) > 0 && !restrictsAllTraffic(conf) {
+		// The servers are only used for the split DNS domains, by way of NRPT.
+		dnsServers = nil
+	}
+	err = setDNS(family, &conf.Interface, luid, dnsServers)
 	if err == windows.ERROR_NOT_FOUND && retryOnFailure {
 		goto startOver
 	} else if err != nil {
@@ -162,16 +201,203 @@ startOver:
 	return nil
 }
 
-func enableFirewall(conf *conf.Config, luid winipcfg.LUID) error {
-	doNotRestrict := true
-	if len(conf.Peers) == 1 && !conf.Interface.TableOff {
-		for _, allowedip := range conf.Peers[0].AllowedIPs {
-			if allowedip.Bits() == 0 && allowedip == allowedip.Masked() {
-				doNotRestrict = false
-				break
+func routeMetric(interfaze *conf.Interface, destination netip.Prefix) uint32 {
+	for _, m := range interfaze.RouteMetrics {
+		if m.Destination == destination {
+			return m.Metric
+		}
+	}
+	return 0
+}
+
+// setDNS sets the DNS servers and search domains of the interface, using DNS over HTTPS for those servers that have
+// a template, if supported, and otherwise falling back to unencrypted DNS.
+func setDNS(family winipcfg.AddressFamily, interfaze *conf.Interface, luid winipcfg.LUID, servers []netip.Addr) error {
+	templates := make(map[netip.Addr]string, len(interfaze.EncryptedDNS))
+	for _, encrypted := range interfaze.EncryptedDNS {
+		if len(encrypted.Template) > 0 && slices.Contains(servers, encrypted.Server) {
+			templates[encrypted.Server] = encrypted.Template
+		}
+	}
+	if len(templates) > 0 && winipcfg.EncryptedDNSSupported() {
+		err := luid.SetEncryptedDNS(family, servers, interfaze.DNSSearch, templates)
+		if !errors.Is(err, winipcfg.ErrEncryptedDNSUnsupported) {
+			return err
+		}
+		log.Println("Warning: unable to set DNS over HTTPS templates, so queries to the tunnel's DNS servers will not be encrypted")
+	}
+	return luid.SetDNS(family, servers, interfaze.DNSSearch)
+}
+
+const networkCategoryTimeout = time.Minute
+
+// networkCategorizer waits for Windows to identify the network of the adapter, and then sets its category.
+// Failures are only logged, since the tunnel works regardless.
+type networkCategorizer struct {
+	stop chan struct{}
+	done sync.WaitGroup
+}
+
+func startNetworkCategorizer(interfaze *conf.Interface, luid winipcfg.LUID) *networkCategorizer {
+	var category nlm.Category
+	switch interfaze.NetworkCategory {
+	case conf.NetworkCategoryPublic:
+		category = nlm.CategoryPublic
+	case conf.NetworkCategoryPrivate:
+		category = nlm.CategoryPrivate
+	default:
+		return nil
+	}
+	categorizer := &networkCategorizer{stop: make(chan struct{})}
+	categorizer.done.Add(1)
+	go func() {
+		defer categorizer.done.Done()
+		guid, err := luid.GUID()
+		if err == nil {
+			deadline := time.Now().Add(networkCategoryTimeout)
+			for {
+				err = nlm.SetCategory(*guid, category)
+				if !errors.Is(err, nlm.ErrNoNetwork) || time.Now().After(deadline) {
+					break
+				}
+				select {
+				case <-categorizer.stop:
+					return
+				case <-time.After(time.Second):
+				}
 			}
 		}
+		if err != nil {
+			log.Printf("Warning: unable to set network category to %s: %v", category, err)
+			return
+		}
+		log.Printf("Set network category to %s", category)
+	}()
+	return categorizer
+}
+
+func (categorizer *networkCategorizer) close() {
+	if categorizer == nil {
+		return
+	}
+	close(categorizer.stop)
+	categorizer.done.Wait()
+}
+
+func hostsEntries(interfaze *conf.Interface) []hosts.Entry {
+	entries := make([]hosts.Entry, len(interfaze.Hosts))
+	for i, entry := range interfaze.Hosts {
+		entries[i] = hosts.Entry{Address: entry.Address, Names: entry.Names}
+	}
+	return entries
+}
+
+func inboundPolicy(interfaze *conf.Interface) *firewall.InboundPolicy {
+	if interfaze.Inbound != conf.InboundBlock {
+		return nil
+	}
+	policy := &firewall.InboundPolicy{}
+	for _, rule := range interfaze.AllowInbound {
+		allowed := firewall.InboundRule{
+			Ports: firewall.PortRange{Low: rule.PortLow, High: rule.PortHigh},
+			From:  rule.From,
+		}
+		switch rule.Protocol {
+		case "tcp":
+			allowed.Protocol = windows.IPPROTO_TCP
+		case "udp":
+			allowed.Protocol = windows.IPPROTO_UDP
+		case "icmp":
+			allowed.Protocol = windows.IPPROTO_ICMP
+		}
+		policy.Allow = append(policy.Allow, allowed)
+	}
+	return policy
+}
+
+func virtualMachinePolicy(interfaze *conf.Interface, doNotRestrict bool) *firewall.VirtualMachinePolicy {
+	if interfaze.VirtualMachines == conf.VirtualMachinesBlock {
+		return nil
+	}
+	if doNotRestrict {
+		// Without the kill-switch, virtual machine traffic is not blocked to begin with.
+		if interfaze.VirtualMachines == conf.VirtualMachinesTunnel {
+			log.Println("Warning: VirtualMachines = tunnel only takes effect when the tunnel has a single peer with a default route, so virtual machine traffic will not be forced through the tunnel")
+		}
+		return nil
+	}
+	policy := &firewall.VirtualMachinePolicy{ForceTunnel: interfaze.VirtualMachines == conf.VirtualMachinesTunnel}
+	interfaces, err := winipcfg.GetAdaptersAddresses(windows.AF_UNSPEC, winipcfg.GAAFlagDefault)
+	if err != nil {
+		log.Printf("Unable to enumerate Hyper-V virtual adapters: %v", err)
+		return policy
+	}
+	for _, iface := range interfaces {
+		if !strings.HasPrefix(iface.Description(), "Hyper-V Virtual Ethernet Adapter") {
+			continue
+		}
+		log.Printf("Permitting virtual machine traffic on interface ‘%s’", iface.FriendlyName())
+		policy.Adapters = append(policy.Adapters, uint64(iface.LUID))
+	}
+	if len(policy.Adapters) == 0 {
+		log.Println("Warning: no Hyper-V virtual adapters were found, so the tunnel must be restarted after starting WSL2 or other virtual machines")
+	}
+	return policy
+}
+
+// restrictsAllTraffic reports whether the tunnel has a single peer with a default route, in which case the firewall
+// acts as a kill-switch.
+func restrictsAllTraffic(conf *conf.Config) bool {
+	if len(conf.Peers) != 1 || conf.Interface.TableOff {
+		return false
+	}
+	for _, allowedip := range conf.Peers[0].AllowedIPs {
+		if allowedip.Bits() == 0 && allowedip == allowedip.Masked() {
+			return true
+		}
+	}
+	return false
+}
+
+func enableSplitDNS(conf *conf.Config) error {
+	if len(conf.Interface.SplitDNS) == 0 {
+		// Remove a rule left behind by a previous version of the configuration.
+		return nrpt.Remove(conf.Name)
+	}
+	if restrictsAllTraffic(conf) {
+		log.Println("Warning: SplitDNS does not prevent other queries from using the tunnel's DNS servers when the tunnel has a single peer with a default route, because the firewall only permits DNS through the tunnel")
+	}
+	pitfallNRPTGroupPolicy()
+	rule, err := nrpt.NewRule(conf.Name, conf.Interface.SplitDNS, conf.Interface.DNS)
+	if err != nil {
+		return err
+	}
+	log.Printf("Adding NRPT rule for %v", conf.Interface.SplitDNS)
+	return nrpt.Add(rule)
+}
+
+func disableSplitDNS(conf *conf.Config) {
+	if len(conf.Interface.SplitDNS) == 0 {
+		return
+	}
+	err := nrpt.Remove(conf.Name)
+	if err != nil {
+		log.Printf("Unable to remove NRPT rule: %v", err)
+	}
+}
+
+func enableFirewall(conf *conf.Config, luid winipcfg.LUID) error {
+	doNotRestrict := !restrictsAllTraffic(conf)
+	pitfallLockdownUnapproved(conf.Name)
+	if doNotRestrict && conf.Interface.BlockEncryptedDNS {
+		log.Println("Warning: BlockEncryptedDNS only takes effect when the tunnel has a single peer with a default route, so encrypted DNS will not be blocked")
 	}
 	log.Println("Enabling firewall rules")
-	return firewall.EnableFirewall(uint64(luid), doNotRestrict, conf.Interface.DNS)
+	return firewall.EnableFirewall(uint64(luid), &firewall.Options{
+		DoNotRestrict:     doNotRestrict,
+		DNSServers:        conf.Interface.DNS,
+		BlockEncryptedDNS: conf.Interface.BlockEncryptedDNS,
+		Inbound:           inboundPolicy(&conf.Interface),
+		VirtualMachines:   virtualMachinePolicy(&conf.Interface, doNotRestrict),
+	})
 }
@@ -0,0 +1,152 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2026 WireGuard LLC. All Rights Reserved.
+ */
+
+package tunnel
+
+import (
+	"errors"
+	"log"
+	"net/netip"
+	"sync"
+	"time"
+
+	"golang.org/x/sys/windows"
+	"golang.zx2c4.com/wireguard/windows/conf"
+	"golang.zx2c4.com/wireguard/windows/driver"
+	"golang.zx2c4.com/wireguard/windows/tunnel/firewall"
+	"golang.zx2c4.com/wireguard/windows/tunnel/winipcfg"
+)
+
+const captivePortalPollInterval = time.Second * 2
+
+// captivePortalDuration returns how long captive portal mode lasts at most, which administrators may change from the
+// default of 5 minutes.
+func captivePortalDuration() time.Duration {
+	if minutes, ok := conf.AdminInt("CaptivePortalMinutes"); ok && minutes > 0 && minutes <= 60 {
+		return time.Minute * time.Duration(minutes)
+	}
+	return time.Minute * 5
+}
+
+type captivePortal struct {
+	adapter  *driver.Adapter
+	luid     winipcfg.LUID
+	finished chan string
+	stop     chan struct{}
+	done     sync.WaitGroup
+}
+
+func newCaptivePortal(adapter *driver.Adapter, luid winipcfg.LUID) *captivePortal {
+	return &captivePortal{
+		adapter:  adapter,
+		luid:     luid,
+		finished: make(chan string),
+	}
+}
+
+func lastHandshake(adapter *driver.Adapter) uint64 {
+	interfaze, err := adapter.Configuration()
+	if err != nil {
+		return 0
+	}
+	var latest uint64
+	peer := interfaze.FirstPeer()
+	for i := uint32(0); i < interfaze.PeerCount; i++ {
+		latest = max(latest, peer.LastHandshake)
+		peer = peer.NextPeer()
+	}
+	return latest
+}
+
+// localNetworks returns the gateways, DNS servers, and on-link prefixes of the interfaces other than our own that
+// have a gateway, which is where captive portals are usually found.
+func localNetworks(ourLUID winipcfg.LUID) []netip.Prefix {
+	interfaces, err := winipcfg.GetAdaptersAddresses(windows.AF_UNSPEC, winipcfg.GAAFlagIncludeGateways)
+	if err != nil {
+		return nil
+	}
+	var networks []netip.Prefix
+	for _, iface := range interfaces {
+		if iface.LUID == ourLUID || iface.OperStatus != winipcfg.IfOperStatusUp || iface.FirstGatewayAddress == nil {
+			continue
+		}
+		for gateway := iface.FirstGatewayAddress; gateway != nil; gateway = gateway.Next {
+			if ip, ok := netip.AddrFromSlice(gateway.Address.IP()); ok {
+				networks = append(networks, netip.PrefixFrom(ip.Unmap(), ip.Unmap().BitLen()))
+			}
+		}
+		for dns := iface.FirstDNSServerAddress; dns != nil; dns = dns.Next {
+			if ip, ok := netip.AddrFromSlice(dns.Address.IP()); ok {
+				networks = append(networks, netip.PrefixFrom(ip.Unmap(), ip.Unmap().BitLen()))
+			}
+		}
+		for address := iface.FirstUnicastAddress; address != nil; address = address.Next {
+			if ip, ok := netip.AddrFromSlice(address.Address.IP()); ok && !ip.IsLinkLocalUnicast() {
+				networks = append(networks, netip.PrefixFrom(ip.Unmap(), int(address.OnLinkPrefixLength)).Masked())
+			}
+		}
+	}
+	return networks
+}
+
+// enable temporarily permits signing into a captive portal, until either captivePortalDuration() has passed or
+// a handshake has succeeded, at which point the reason is sent to the finished channel.
+func (portal *captivePortal) enable() error {
+	if portal.stop != nil {
+		return errors.New("Captive portal mode has already been enabled")
+	}
+	networks := localNetworks(portal.luid)
+	browser := conf.AdminString("CaptivePortalBrowser")
+	err := firewall.EnableCaptivePortal(networks, browser)
+	if err != nil {
+		return err
+	}
+	log.Printf("Permitting DNS, HTTP, and HTTPS to %v for captive portal", networks)
+	if len(browser) > 0 {
+		log.Printf("Permitting DNS, HTTP, and HTTPS of ‘%s’ for captive portal", browser)
+	}
+
+	since := lastHandshake(portal.adapter)
+	portal.stop = make(chan struct{})
+	portal.done.Add(1)
+	go func(stop chan struct{}) {
+		defer portal.done.Done()
+		timeout := time.NewTimer(captivePortalDuration())
+		defer timeout.Stop()
+		poll := time.NewTicker(captivePortalPollInterval)
+		defer poll.Stop()
+		var reason string
+		for len(reason) == 0 {
+			select {
+			case <-stop:
+				return
+			case <-timeout.C:
+				reason = "time limit reached"
+			case <-poll.C:
+				if lastHandshake(portal.adapter) > since {
+					reason = "handshake succeeded"
+				}
+			}
+		}
+		select {
+		case portal.finished <- reason:
+		case <-stop:
+		}
+	}(portal.stop)
+	return nil
+}
+
+func (portal *captivePortal) disable() {
+	if portal.stop == nil {
+		return
+	}
+	close(portal.stop)
+	portal.done.Wait()
+	portal.stop = nil
+	err := firewall.DisableCaptivePortal()
+	if err != nil {
+		log.Printf("Unable to remove captive portal firewall rules: %v", err)
+	}
+}
@@ -8,6 +8,8 @@ package tunnel
 import (
 	"bytes"
 	"encoding/binary"
+	"fmt"
+	"log"
 	"slices"
 	"sort"
 	"unsafe"
@@ -17,6 +19,7 @@ import (
 	"golang.org/x/text/unicode/norm"
 
 	"golang.zx2c4.com/wireguard/windows/conf"
+	"golang.zx2c4.com/wireguard/windows/driver"
 )
 
 const (
@@ -98,3 +101,71 @@ func deterministicGUID(c *conf.Config) *windows.GUID {
 	}
 	return (*windows.GUID)(unsafe.Pointer(&b2.Sum(nil)[0]))
 }
+
+// adapterGUID returns the GUID pinned by the configuration, or otherwise the deterministic GUID, which changes along
+// with the peers and their allowed IPs, and with it, the network profile that Windows associates with the adapter.
+func adapterGUID(c *conf.Config) *windows.GUID {
+	if len(c.Interface.AdapterGUID) > 0 {
+		guid, err := windows.GUIDFromString(c.Interface.AdapterGUID)
+		if err == nil {
+			log.Printf("Using pinned adapter GUID %v", guid)
+			return &guid
+		}
+		log.Printf("Warning: unable to parse pinned adapter GUID, so deriving it from the configuration instead: %v", err)
+	}
+	return deterministicGUID(c)
+}
+
+// PinAdapterGUID adds the current GUID of a tunnel's adapter to its configuration, so that later changes to the
+// configuration keep it. The GUID is taken from the adapter if it exists, and is otherwise derived from the
+// configuration in the same way as when the tunnel starts.
+func PinAdapterGUID(tunnelName string) (string, error) {
+	config, err := conf.LoadFromName(tunnelName)
+	if err != nil {
+		return "", err
+	}
+	if len(config.Interface.AdapterGUID) > 0 {
+		return config.Interface.AdapterGUID, nil
+	}
+	guid := deterministicGUID(config)
+	if adapter, err := driver.OpenAdapter(tunnelName); err == nil {
+		actual, err := adapter.LUID().GUID()
+		adapter.Close()
+		if err != nil {
+			return "", err
+		}
+		guid = actual
+	}
+	if other := tunnelPinningAdapterGUID(tunnelName, guid); len(other) > 0 {
+		return "", fmt.Errorf("Adapter GUID %v is already pinned by tunnel %s", guid, other)
+	}
+	config.Interface.AdapterGUID = guid.String()
+	err = config.Save(true)
+	if err != nil {
+		return "", err
+	}
+	return config.Interface.AdapterGUID, nil
+}
+
+// tunnelPinningAdapterGUID returns the name of another stored tunnel whose configuration pins the given GUID, or an
+// empty string if there is none. Two adapters cannot have the same GUID, so only one of those tunnels could start.
+func tunnelPinningAdapterGUID(tunnelName string, guid *windows.GUID) string {
+	names, err := conf.ListConfigNames()
+	if err != nil {
+		return ""
+	}
+	for _, name := range names {
+		if name == tunnelName {
+			continue
+		}
+		config, err := conf.LoadFromName(name)
+		if err != nil || len(config.Interface.AdapterGUID) == 0 {
+			continue
+		}
+		pinned, err := windows.GUIDFromString(config.Interface.AdapterGUID)
+		if err == nil && pinned == *guid {
+			return name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,172 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2026 WireGuard LLC. All Rights Reserved.
+ */
+
+package tunnel
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"golang.org/x/sys/windows"
+	"golang.zx2c4.com/wireguard/windows/conf"
+	"golang.zx2c4.com/wireguard/windows/driver"
+)
+
+const (
+	// With a persistent keepalive, handshakes are repeated every two minutes, and after three, the session expires.
+	failoverStaleHandshake = time.Minute * 3
+	failoverPollInterval   = time.Second * 5
+)
+
+// failoverMonitor gives the allowed IPs of each failover group to the most preferred of its peers whose last
+// handshake is not stale, counting from when the tunnel started, so that traffic moves to a standby peer when the
+// active one stops responding, and back once it responds again.
+type failoverMonitor struct {
+	adapter *driver.Adapter
+	config  *conf.Config
+	groups  []conf.FailoverGroup
+	started time.Time
+	stop    chan struct{}
+	done    sync.WaitGroup
+}
+
+func startFailoverMonitor(adapter *driver.Adapter, config *conf.Config) *failoverMonitor {
+	groups := config.FailoverGroups()
+	if len(groups) == 0 {
+		return nil
+	}
+	monitor := &failoverMonitor{
+		adapter: adapter,
+		config:  config,
+		groups:  groups,
+		started: time.Now(),
+		stop:    make(chan struct{}),
+	}
+	for _, group := range groups {
+		log.Printf("Failover group %s: starting with peer %s", group.Name, config.Peers[group.Peers[0]].PublicKey.String())
+	}
+	monitor.done.Add(1)
+	go func() {
+		defer monitor.done.Done()
+		poll := time.NewTicker(failoverPollInterval)
+		defer poll.Stop()
+		for {
+			select {
+			case <-monitor.stop:
+				return
+			case <-poll.C:
+				monitor.check()
+			}
+		}
+	}()
+	return monitor
+}
+
+func (monitor *failoverMonitor) close() {
+	if monitor == nil {
+		return
+	}
+	close(monitor.stop)
+	monitor.done.Wait()
+}
+
+type failoverPeerState struct {
+	lastHandshake time.Time
+	hasAllowedIPs bool
+}
+
+func (monitor *failoverMonitor) check() {
+	interfaze, err := monitor.adapter.Configuration()
+	if err != nil {
+		return
+	}
+	states := make(map[conf.Key]failoverPeerState, interfaze.PeerCount)
+	p := interfaze.FirstPeer()
+	for i := uint32(0); i < interfaze.PeerCount; i++ {
+		var state failoverPeerState
+		if p.LastHandshake != 0 {
+			state.lastHandshake = time.Unix(0, int64(p.LastHandshake-116444736000000000)*100)
+		}
+		state.hasAllowedIPs = p.AllowedIPsCount > 0
+		states[p.PublicKey] = state
+		p = p.NextPeer()
+	}
+
+	for _, group := range monitor.groups {
+		current, preferred := -1, -1
+		for _, i := range group.Peers {
+			state := states[monitor.config.Peers[i].PublicKey]
+			if state.hasAllowedIPs && current < 0 {
+				current = i
+			}
+			if preferred < 0 && time.Since(maxTime(state.lastHandshake, monitor.started)) < failoverStaleHandshake {
+				preferred = i
+			}
+		}
+		if preferred < 0 {
+			// None of the peers respond, so leave the allowed IPs where they are, or give them back to the first.
+			if current >= 0 {
+				continue
+			}
+			preferred = group.Peers[0]
+		}
+		if preferred == current {
+			continue
+		}
+		if current >= 0 {
+			lastHandshake := states[monitor.config.Peers[current].PublicKey].lastHandshake
+			if lastHandshake.IsZero() {
+				log.Printf("Failover group %s: no handshake with peer %s", group.Name, monitor.config.Peers[current].PublicKey.String())
+			} else {
+				log.Printf("Failover group %s: last handshake with peer %s was %v ago", group.Name, monitor.config.Peers[current].PublicKey.String(), time.Since(lastHandshake).Round(time.Second))
+			}
+		}
+		log.Printf("Failover group %s: moving allowed IPs to peer %s", group.Name, monitor.config.Peers[preferred].PublicKey.String())
+		err = monitor.moveAllowedIPs(group, preferred)
+		if err != nil {
+			log.Printf("Failover group %s: unable to move allowed IPs: %v", group.Name, err)
+		}
+	}
+}
+
+// moveAllowedIPs removes the allowed IPs from all peers of the group, and then gives them to the one peer.
+func (monitor *failoverMonitor) moveAllowedIPs(group conf.FailoverGroup, to int) error {
+	var c driver.ConfigBuilder
+	c.AppendInterface(&driver.Interface{PeerCount: uint32(len(group.Peers))})
+	for _, i := range group.Peers {
+		if i == to {
+			continue
+		}
+		c.AppendPeer(&driver.Peer{
+			Flags:     driver.PeerHasPublicKey | driver.PeerReplaceAllowedIPs | driver.PeerUpdateOnly,
+			PublicKey: monitor.config.Peers[i].PublicKey,
+		})
+	}
+	allowedIPs := monitor.config.Peers[to].AllowedIPs
+	c.AppendPeer(&driver.Peer{
+		Flags:           driver.PeerHasPublicKey | driver.PeerReplaceAllowedIPs | driver.PeerUpdateOnly,
+		PublicKey:       monitor.config.Peers[to].PublicKey,
+		AllowedIPsCount: uint32(len(allowedIPs)),
+	})
+	for _, allowedIP := range allowedIPs {
+		a := &driver.AllowedIP{Cidr: uint8(allowedIP.Bits())}
+		copy(a.Address[:], allowedIP.Addr().AsSlice())
+		if allowedIP.Addr().Is4() {
+			a.AddressFamily = windows.AF_INET
+		} else if allowedIP.Addr().Is6() {
+			a.AddressFamily = windows.AF_INET6
+		}
+		c.AppendAllowedIP(a)
+	}
+	return monitor.adapter.SetConfiguration(c.Interface())
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
@@ -7,7 +7,6 @@ package firewall
 
 import (
 	"errors"
-	"net/netip"
 	"unsafe"
 
 	"golang.org/x/sys/windows"
@@ -17,21 +16,34 @@ type wfpObjectInstaller func(uintptr) error
 
 // Fundamental WireGuard specific WFP objects.
 type baseObjects struct {
-	provider windows.GUID
-	filters  windows.GUID
+	provider   windows.GUID
+	filters    windows.GUID
+	flags      wtFwpmFilterFlags
+	weightBase uint64
 }
 
-var wfpSession uintptr
+var (
+	wfpSession     uintptr
+	wfpBaseObjects *baseObjects
+	wfpRules       []Rule
+	wfpRestricted  bool
+)
 
-func createWfpSession() (uintptr, error) {
-	sessionDisplayData, err := createWtFwpmDisplayData0("WireGuard", "WireGuard dynamic session")
+func createWfpSession(dynamic bool) (uintptr, error) {
+	description := "WireGuard dynamic session"
+	flags := cFWPM_SESSION_FLAG_DYNAMIC
+	if !dynamic {
+		description = "WireGuard persistent session"
+		flags = 0
+	}
+	sessionDisplayData, err := createWtFwpmDisplayData0("WireGuard", description)
 	if err != nil {
 		return 0, wrapErr(err)
 	}
 
 	session := wtFwpmSession0{
 		displayData:          *sessionDisplayData,
-		flags:                cFWPM_SESSION_FLAG_DYNAMIC,
+		flags:                flags,
 		txnWaitTimeoutInMSec: windows.INFINITE,
 	}
 
@@ -46,7 +58,7 @@ func createWfpSession() (uintptr, error) {
 }
 
 func registerBaseObjects(session uintptr) (*baseObjects, error) {
-	bo := &baseObjects{}
+	bo := &baseObjects{weightBase: filterWeightBase()}
 	var err error
 	bo.provider, err = windows.GenerateGUID()
 	if err != nil {
@@ -88,7 +100,7 @@ func registerBaseObjects(session uintptr) (*baseObjects, error) {
 			subLayerKey: bo.filters,
 			displayData: *displayData,
 			providerKey: &bo.provider,
-			weight:      ^uint16(0),
+			weight:      sublayerWeight(),
 		}
 		err = fwpmSubLayerAdd0(session, &sublayer, 0)
 		if err != nil {
@@ -99,69 +111,47 @@ func registerBaseObjects(session uintptr) (*baseObjects, error) {
 	return bo, nil
 }
 
-func EnableFirewall(luid uint64, doNotRestrict bool, restrictToDNSServers []netip.Addr) error {
+func EnableFirewall(luid uint64, options *Options) error {
 	if wfpSession != 0 {
 		return errors.New("The firewall has already been enabled")
 	}
 
-	session, err := createWfpSession()
+	executable, err := getCurrentProcessAppID()
+	if err != nil {
+		return wrapErr(err)
+	}
+	rules, err := firewallRules(executable, luid, options)
+	if err != nil {
+		return wrapErr(err)
+	}
+
+	session, err := createWfpSession(true)
 	if err != nil {
 		return wrapErr(err)
 	}
 
+	var bo *baseObjects
 	objectInstaller := func(session uintptr) error {
-		baseObjects, err := registerBaseObjects(session)
+		bo, err = registerBaseObjects(session)
 		if err != nil {
 			return wrapErr(err)
 		}
 
-		err = permitWireGuardService(session, baseObjects, 15)
+		err = addRules(session, bo, rules)
 		if err != nil {
 			return wrapErr(err)
 		}
 
-		if !doNotRestrict {
-			if len(restrictToDNSServers) > 0 {
-				err = blockDNS(restrictToDNSServers, session, baseObjects, 15, 14)
-				if err != nil {
-					return wrapErr(err)
-				}
-			}
-
-			err = permitLoopback(session, baseObjects, 13)
-			if err != nil {
-				return wrapErr(err)
-			}
-
-			err = permitTunInterface(session, baseObjects, 12, luid)
-			if err != nil {
-				return wrapErr(err)
-			}
-
-			err = permitDHCPIPv4(session, baseObjects, 12)
-			if err != nil {
-				return wrapErr(err)
+		// Blocking filters in any sublayer win, so when lockdown mode is enabled, traffic on the tunnel
+		// interface, as well as that of permitted virtual machines, must also be permitted inside of the
+		// lockdown sublayer. These filters belong to this dynamic session and therefore disappear along
+		// with the tunnel.
+		if lockdownObjects := lockdownBaseObjects(session); lockdownObjects != nil {
+			tunnelRules := permitTunInterface(12, luid)
+			if options.VirtualMachines != nil {
+				tunnelRules = append(tunnelRules, permitVirtualMachines(12, options.VirtualMachines)...)
 			}
-
-			err = permitDHCPIPv6(session, baseObjects, 12)
-			if err != nil {
-				return wrapErr(err)
-			}
-
-			err = permitNdp(session, baseObjects, 12)
-			if err != nil {
-				return wrapErr(err)
-			}
-
-			/* TODO: actually evaluate if this does anything and if we need this. It's layer 2; our other rules are layer 3.
-			 *  In other words, if somebody complains, try enabling it. For now, keep it off.
-			err = permitHyperV(session, baseObjects, 12)
-			if err != nil {
-				return wrapErr(err)
-			}
-			*/
-
-			err = blockAll(session, baseObjects, 0)
+			err = addRules(session, lockdownObjects, tunnelRules)
 			if err != nil {
 				return wrapErr(err)
 			}
@@ -177,12 +167,20 @@ func EnableFirewall(luid uint64, doNotRestrict bool, restrictToDNSServers []neti
 	}
 
 	wfpSession = session
+	wfpBaseObjects = bo
+	wfpRules = rules
+	wfpRestricted = !options.DoNotRestrict
 	return nil
 }
 
 func DisableFirewall() {
 	if wfpSession != 0 {
+		disableDropLogging()
 		fwpmEngineClose0(wfpSession)
 		wfpSession = 0
+		wfpBaseObjects = nil
+		wfpRules = nil
+		wfpRestricted = false
+		captivePortalRules = nil
 	}
 }
@@ -0,0 +1,75 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2026 WireGuard LLC. All Rights Reserved.
+ */
+
+package firewall
+
+import (
+	"errors"
+	"net/netip"
+)
+
+var captivePortalRules []Rule
+
+// EnableCaptivePortal temporarily permits DNS, HTTP, and HTTPS traffic to the given networks, which should be
+// those of the physical network, including its gateways and DNS servers, and, if a browser executable is given,
+// all such traffic of that browser, so that a captive portal can be signed into despite the kill-switch. The
+// rules are removed by DisableCaptivePortal or DisableFirewall.
+func EnableCaptivePortal(networks []netip.Prefix, browser string) error {
+	if wfpSession == 0 {
+		return errors.New("The firewall has not been enabled")
+	}
+	if !wfpRestricted {
+		return errors.New("The firewall does not restrict traffic")
+	}
+	if captivePortalRules != nil {
+		return errors.New("Captive portal mode has already been enabled")
+	}
+
+	rules := permitCaptivePortal(15, networks, AppID(browser))
+	if len(rules) == 0 {
+		return errors.New("No networks or browser to permit")
+	}
+	var lockdownRules []Rule
+	err := runTransaction(wfpSession, func(session uintptr) error {
+		err := addRules(session, wfpBaseObjects, rules)
+		if err != nil {
+			return wrapErr(err)
+		}
+		if lockdownObjects := lockdownBaseObjects(session); lockdownObjects != nil {
+			lockdownRules = permitCaptivePortal(15, networks, AppID(browser))
+			err = addRules(session, lockdownObjects, lockdownRules)
+			if err != nil {
+				return wrapErr(err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return wrapErr(err)
+	}
+	captivePortalRules = append(rules, lockdownRules...)
+	return nil
+}
+
+// DisableCaptivePortal removes the rules added by EnableCaptivePortal.
+func DisableCaptivePortal() error {
+	if wfpSession == 0 || captivePortalRules == nil {
+		return nil
+	}
+	err := runTransaction(wfpSession, func(session uintptr) error {
+		for _, rule := range captivePortalRules {
+			err := fwpmFilterDeleteById0(session, rule.ID)
+			if err != nil {
+				return wrapErr(err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return wrapErr(err)
+	}
+	captivePortalRules = nil
+	return nil
+}
@@ -0,0 +1,61 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2026 WireGuard LLC. All Rights Reserved.
+ */
+
+package firewall
+
+import "net/netip"
+
+// knownDoHResolvers are the addresses of popular public resolvers that serve DNS over HTTPS, and which
+// browsers and operating system components may use on their own accord, bypassing the tunnel's DNS servers.
+// Since DoH is indistinguishable from other HTTPS traffic, all HTTPS traffic to these addresses is blocked.
+var knownDoHResolvers = []netip.Addr{
+	// AdGuard
+	netip.MustParseAddr("94.140.14.14"),
+	netip.MustParseAddr("94.140.15.15"),
+	netip.MustParseAddr("2a10:50c0::ad1:ff"),
+	netip.MustParseAddr("2a10:50c0::ad2:ff"),
+
+	// CleanBrowsing
+	netip.MustParseAddr("185.228.168.9"),
+	netip.MustParseAddr("185.228.169.9"),
+	netip.MustParseAddr("2a0d:2a00:1::2"),
+	netip.MustParseAddr("2a0d:2a00:2::2"),
+
+	// Cloudflare
+	netip.MustParseAddr("1.1.1.1"),
+	netip.MustParseAddr("1.0.0.1"),
+	netip.MustParseAddr("1.1.1.2"),
+	netip.MustParseAddr("1.0.0.2"),
+	netip.MustParseAddr("1.1.1.3"),
+	netip.MustParseAddr("1.0.0.3"),
+	netip.MustParseAddr("2606:4700:4700::1111"),
+	netip.MustParseAddr("2606:4700:4700::1001"),
+	netip.MustParseAddr("2606:4700:4700::1112"),
+	netip.MustParseAddr("2606:4700:4700::1002"),
+	netip.MustParseAddr("2606:4700:4700::1113"),
+	netip.MustParseAddr("2606:4700:4700::1003"),
+
+	// Google
+	netip.MustParseAddr("8.8.8.8"),
+	netip.MustParseAddr("8.8.4.4"),
+	netip.MustParseAddr("2001:4860:4860::8888"),
+	netip.MustParseAddr("2001:4860:4860::8844"),
+
+	// Mullvad
+	netip.MustParseAddr("194.242.2.2"),
+	netip.MustParseAddr("2a07:e340::2"),
+
+	// OpenDNS
+	netip.MustParseAddr("208.67.222.222"),
+	netip.MustParseAddr("208.67.220.220"),
+	netip.MustParseAddr("2620:119:35::35"),
+	netip.MustParseAddr("2620:119:53::53"),
+
+	// Quad9
+	netip.MustParseAddr("9.9.9.9"),
+	netip.MustParseAddr("149.112.112.112"),
+	netip.MustParseAddr("2620:fe::fe"),
+	netip.MustParseAddr("2620:fe::9"),
+}
@@ -0,0 +1,248 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2026 WireGuard LLC. All Rights Reserved.
+ */
+
+package firewall
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log"
+	"net/netip"
+	"sort"
+	"sync"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+const (
+	dropLogInterval   = time.Second * 10
+	dropLogMaxRecords = 10
+)
+
+type dropRecord struct {
+	outbound bool
+	protocol uint8
+	app      string
+	remote   netip.AddrPort
+	filter   string
+}
+
+type dropLogger struct {
+	session      uintptr
+	eventsHandle uintptr
+	filterNames  map[uint64]string
+	stop         chan struct{}
+	done         sync.WaitGroup
+
+	sync.Mutex
+	drops      map[dropRecord]uint
+	suppressed uint
+}
+
+var (
+	activeDropLogger     *dropLogger
+	activeDropLoggerLock sync.Mutex
+	netEventCallback     = windows.NewCallback(func(context uintptr, event *wtFwpmNetEvent1) uintptr {
+		activeDropLoggerLock.Lock()
+		defer activeDropLoggerLock.Unlock()
+		if activeDropLogger != nil {
+			activeDropLogger.record(event)
+		}
+		return 0
+	})
+)
+
+func protocolName(protocol uint8) string {
+	switch wtIPProto(protocol) {
+	case cIPPROTO_ICMP:
+		return "ICMP"
+	case cIPPROTO_ICMPV6:
+		return "ICMPv6"
+	case cIPPROTO_TCP:
+		return "TCP"
+	case cIPPROTO_UDP:
+		return "UDP"
+	}
+	return fmt.Sprintf("protocol %d", protocol)
+}
+
+func (record *dropRecord) String() string {
+	direction := "inbound"
+	if record.outbound {
+		direction = "outbound"
+	}
+	app := record.app
+	if len(app) == 0 {
+		app = "unknown application"
+	}
+	remote := "unknown address"
+	if record.remote.Port() != 0 {
+		remote = record.remote.String()
+	} else if record.remote.Addr().IsValid() {
+		remote = record.remote.Addr().String()
+	}
+	return fmt.Sprintf("%s %s traffic of %s with %s, by filter “%s”", direction, protocolName(record.protocol), app, remote, record.filter)
+}
+
+func (dl *dropLogger) record(event *wtFwpmNetEvent1) {
+	if event._type != cFWPM_NET_EVENT_TYPE_CLASSIFY_DROP || event.classifyDrop == nil {
+		return
+	}
+	filter, ok := dl.filterNames[event.classifyDrop.filterId]
+	if !ok {
+		return
+	}
+
+	header := &event.header
+	record := dropRecord{
+		outbound: event.classifyDrop.msFwpDirection == cFWP_DIRECTION_OUT,
+		filter:   filter,
+	}
+	if header.flags&cFWPM_NET_EVENT_FLAG_IP_PROTOCOL_SET != 0 {
+		record.protocol = header.ipProtocol
+	}
+	if header.flags&cFWPM_NET_EVENT_FLAG_APP_ID_SET != 0 && header.appId.size >= 2 {
+		record.app = windows.UTF16ToString(unsafe.Slice((*uint16)(unsafe.Pointer(header.appId.data)), header.appId.size/2))
+	}
+	var remoteAddr netip.Addr
+	if header.flags&cFWPM_NET_EVENT_FLAG_REMOTE_ADDR_SET != 0 && header.flags&cFWPM_NET_EVENT_FLAG_IP_VERSION_SET != 0 {
+		switch header.ipVersion {
+		case cFWP_IP_VERSION_V4:
+			var ip [4]byte
+			binary.BigEndian.PutUint32(ip[:], binary.LittleEndian.Uint32(header.remoteAddr[:]))
+			remoteAddr = netip.AddrFrom4(ip)
+		case cFWP_IP_VERSION_V6:
+			remoteAddr = netip.AddrFrom16(header.remoteAddr)
+		}
+	}
+	var remotePort uint16
+	if header.flags&cFWPM_NET_EVENT_FLAG_REMOTE_PORT_SET != 0 {
+		remotePort = header.remotePort
+	}
+	record.remote = netip.AddrPortFrom(remoteAddr, remotePort)
+
+	dl.Lock()
+	defer dl.Unlock()
+	if _, ok := dl.drops[record]; !ok && len(dl.drops) >= dropLogMaxRecords {
+		dl.suppressed++
+		return
+	}
+	dl.drops[record]++
+}
+
+func (dl *dropLogger) flush() {
+	dl.Lock()
+	drops, suppressed := dl.drops, dl.suppressed
+	dl.drops, dl.suppressed = make(map[dropRecord]uint), 0
+	dl.Unlock()
+
+	lines := make([]string, 0, len(drops))
+	for record, count := range drops {
+		lines = append(lines, fmt.Sprintf("Firewall dropped %s (%d times)", &record, count))
+	}
+	sort.Strings(lines)
+	for _, line := range lines {
+		log.Println(line)
+	}
+	if suppressed > 0 {
+		log.Printf("Firewall dropped %d more packets, which were not logged", suppressed)
+	}
+}
+
+// EnableDropLogging subscribes to the packets dropped by the filters installed by EnableFirewall, and
+// periodically writes a summary of them, including the application and remote address, to the log.
+// It is stopped by DisableFirewall.
+func EnableDropLogging() error {
+	if wfpSession == 0 {
+		return errors.New("The firewall has not been enabled")
+	}
+	if activeDropLogger != nil {
+		return errors.New("Drop logging has already been enabled")
+	}
+
+	var collect *wtFwpValue0
+	err := fwpmEngineGetOption0(wfpSession, cFWPM_ENGINE_COLLECT_NET_EVENTS, &collect)
+	if err != nil {
+		return wrapErr(err)
+	}
+	collecting := collect._type == cFWP_UINT32 && collect.value != 0
+	fwpmFreeMemory0(unsafe.Pointer(&collect))
+	if !collecting {
+		return errors.New("The collection of network events has been disabled on this system")
+	}
+
+	dl := &dropLogger{
+		session:     wfpSession,
+		filterNames: make(map[uint64]string),
+		stop:        make(chan struct{}),
+		drops:       make(map[dropRecord]uint),
+	}
+	for _, rule := range wfpRules {
+		if rule.Action == ActionBlock {
+			dl.filterNames[rule.ID] = rule.Name
+		}
+	}
+
+	condition := wtFwpmFilterCondition0{
+		fieldKey:  cFWPM_CONDITION_NET_EVENT_TYPE,
+		matchType: cFWP_MATCH_EQUAL,
+		conditionValue: wtFwpConditionValue0{
+			_type: cFWP_UINT32,
+			value: uintptr(cFWPM_NET_EVENT_TYPE_CLASSIFY_DROP),
+		},
+	}
+	enumTemplate := wtFwpmNetEventEnumTemplate0{
+		numFilterConditions: 1,
+		filterCondition:     &condition,
+	}
+	subscription := wtFwpmNetEventSubscription0{
+		enumTemplate: &enumTemplate,
+	}
+
+	activeDropLoggerLock.Lock()
+	activeDropLogger = dl
+	activeDropLoggerLock.Unlock()
+
+	err = fwpmNetEventSubscribe0(dl.session, &subscription, netEventCallback, 0, &dl.eventsHandle)
+	if err != nil {
+		activeDropLoggerLock.Lock()
+		activeDropLogger = nil
+		activeDropLoggerLock.Unlock()
+		return wrapErr(err)
+	}
+
+	dl.done.Add(1)
+	go func() {
+		defer dl.done.Done()
+		ticker := time.NewTicker(dropLogInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				dl.flush()
+			case <-dl.stop:
+				dl.flush()
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+func disableDropLogging() {
+	dl := activeDropLogger
+	if dl == nil {
+		return
+	}
+	fwpmNetEventUnsubscribe0(dl.session, dl.eventsHandle)
+	activeDropLoggerLock.Lock()
+	activeDropLogger = nil
+	activeDropLoggerLock.Unlock()
+	close(dl.stop)
+	dl.done.Wait()
+}
@@ -0,0 +1,50 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2026 WireGuard LLC. All Rights Reserved.
+ */
+
+package firewall
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestDropLoggerRecord(t *testing.T) {
+	dl := &dropLogger{
+		filterNames: map[uint64]string{42: "Block all traffic"},
+		drops:       make(map[dropRecord]uint),
+	}
+	for _, direction := range []uint32{cFWP_DIRECTION_OUT, cFWP_DIRECTION_IN} {
+		event := wtFwpmNetEvent1{
+			header: wtFwpmNetEventHeader1{
+				flags:      cFWPM_NET_EVENT_FLAG_IP_PROTOCOL_SET | cFWPM_NET_EVENT_FLAG_REMOTE_ADDR_SET | cFWPM_NET_EVENT_FLAG_REMOTE_PORT_SET | cFWPM_NET_EVENT_FLAG_IP_VERSION_SET,
+				ipVersion:  cFWP_IP_VERSION_V4,
+				ipProtocol: uint8(cIPPROTO_UDP),
+				remoteAddr: [16]byte{4, 3, 2, 1}, // Little endian UINT32 of 1.2.3.4
+				remotePort: 53,
+			},
+			_type: cFWPM_NET_EVENT_TYPE_CLASSIFY_DROP,
+			classifyDrop: &wtFwpmNetEventClassifyDrop1{
+				filterId:       42,
+				msFwpDirection: direction,
+			},
+		}
+		dl.record(&event)
+	}
+	outbound := dropRecord{
+		outbound: true,
+		protocol: uint8(cIPPROTO_UDP),
+		remote:   netip.MustParseAddrPort("1.2.3.4:53"),
+		filter:   "Block all traffic",
+	}
+	inbound := outbound
+	inbound.outbound = false
+	if dl.drops[outbound] != 1 || dl.drops[inbound] != 1 || len(dl.drops) != 2 {
+		t.Errorf("Drops were recorded as %v, although one outbound and one inbound drop are expected", dl.drops)
+	}
+	const expected = "outbound UDP traffic of unknown application with 1.2.3.4:53, by filter “Block all traffic”"
+	if s := outbound.String(); s != expected {
+		t.Errorf("Drop is described as %q, although %q is expected", s, expected)
+	}
+}
@@ -0,0 +1,417 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2026 WireGuard LLC. All Rights Reserved.
+ */
+
+package firewall
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net/netip"
+	"runtime"
+	"sort"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var layerKeys = map[Layer]windows.GUID{
+	LayerConnectV4:              cFWPM_LAYER_ALE_AUTH_CONNECT_V4,
+	LayerRecvAcceptV4:           cFWPM_LAYER_ALE_AUTH_RECV_ACCEPT_V4,
+	LayerConnectV6:              cFWPM_LAYER_ALE_AUTH_CONNECT_V6,
+	LayerRecvAcceptV6:           cFWPM_LAYER_ALE_AUTH_RECV_ACCEPT_V6,
+	LayerOutboundMACFrameNative: cFWPM_LAYER_OUTBOUND_MAC_FRAME_NATIVE,
+	LayerInboundMACFrameNative:  cFWPM_LAYER_INBOUND_MAC_FRAME_NATIVE,
+	LayerForwardV4:              cFWPM_LAYER_IPFORWARD_V4,
+	LayerForwardV6:              cFWPM_LAYER_IPFORWARD_V6,
+}
+
+var fieldKeys = map[Field]windows.GUID{
+	FieldLocalInterface:   cFWPM_CONDITION_IP_LOCAL_INTERFACE,
+	FieldForwardInterface: cFWPM_CONDITION_IP_FORWARD_INTERFACE,
+	FieldLocalAddress:     cFWPM_CONDITION_IP_LOCAL_ADDRESS,
+	FieldRemoteAddress:    cFWPM_CONDITION_IP_REMOTE_ADDRESS,
+	FieldProtocol:         cFWPM_CONDITION_IP_PROTOCOL,
+	FieldLocalPort:        cFWPM_CONDITION_IP_LOCAL_PORT,
+	FieldRemotePort:       cFWPM_CONDITION_IP_REMOTE_PORT,
+	FieldICMPType:         cFWPM_CONDITION_ICMP_TYPE,
+	FieldICMPCode:         cFWPM_CONDITION_ICMP_CODE,
+	FieldAppID:            cFWPM_CONDITION_ALE_APP_ID,
+	FieldUserID:           cFWPM_CONDITION_ALE_USER_ID,
+	FieldFlags:            cFWPM_CONDITION_FLAGS,
+	FieldL2Flags:          cFWPM_CONDITION_L2_FLAGS,
+}
+
+var matchTypes = map[Match]wtFwpMatchType{
+	MatchEqual:       cFWP_MATCH_EQUAL,
+	MatchNotEqual:    cFWP_MATCH_NOT_EQUAL,
+	MatchFlagsAllSet: cFWP_MATCH_FLAGS_ALL_SET,
+	MatchRange:       cFWP_MATCH_RANGE,
+}
+
+func layerOfKey(key windows.GUID) Layer {
+	for layer, layerKey := range layerKeys {
+		if layerKey == key {
+			return layer
+		}
+	}
+	return Layer(key.String())
+}
+
+func fieldOfKey(key windows.GUID) Field {
+	// The ICMP type and code share their keys with the local and remote port, so prefer those.
+	switch key {
+	case cFWPM_CONDITION_IP_LOCAL_PORT:
+		return FieldLocalPort
+	case cFWPM_CONDITION_IP_REMOTE_PORT:
+		return FieldRemotePort
+	}
+	for field, fieldKey := range fieldKeys {
+		if fieldKey == key {
+			return field
+		}
+	}
+	return Field(key.String())
+}
+
+func matchOfType(matchType wtFwpMatchType) Match {
+	for match, t := range matchTypes {
+		if t == matchType {
+			return match
+		}
+	}
+	return Match(fmt.Sprintf("match type %d", matchType))
+}
+
+// filterBuilder holds on to the memory referenced by the conditions of a filter until it has been added.
+type filterBuilder struct {
+	storedPointers []any
+	wfpAllocations []unsafe.Pointer
+}
+
+func (fb *filterBuilder) free() {
+	for i := range fb.wfpAllocations {
+		fwpmFreeMemory0(unsafe.Pointer(&fb.wfpAllocations[i]))
+	}
+	runtime.KeepAlive(fb.storedPointers)
+}
+
+func (fb *filterBuilder) conditionValue(value any) (wtFwpConditionValue0, error) {
+	switch v := value.(type) {
+	case uint8:
+		return wtFwpConditionValue0{_type: cFWP_UINT8, value: uintptr(v)}, nil
+	case uint16:
+		return wtFwpConditionValue0{_type: cFWP_UINT16, value: uintptr(v)}, nil
+	case uint32:
+		return wtFwpConditionValue0{_type: cFWP_UINT32, value: uintptr(v)}, nil
+	case uint64:
+		fb.storedPointers = append(fb.storedPointers, &v)
+		return wtFwpConditionValue0{_type: cFWP_UINT64, value: uintptr(unsafe.Pointer(&v))}, nil
+	case netip.Addr:
+		if v.Is4() {
+			return wtFwpConditionValue0{_type: cFWP_UINT32, value: uintptr(binary.BigEndian.Uint32(v.AsSlice()))}, nil
+		}
+		address := &wtFwpByteArray16{byteArray16: v.As16()}
+		fb.storedPointers = append(fb.storedPointers, address)
+		return wtFwpConditionValue0{_type: cFWP_BYTE_ARRAY16_TYPE, value: uintptr(unsafe.Pointer(address))}, nil
+	case netip.Prefix:
+		if v.Addr().Is4() {
+			addrAndMask := &wtFwpV4AddrAndMask{
+				addr: binary.BigEndian.Uint32(v.Addr().AsSlice()),
+				mask: ^uint32(0) << (32 - v.Bits()),
+			}
+			if v.Bits() == 0 {
+				addrAndMask.mask = 0
+			}
+			fb.storedPointers = append(fb.storedPointers, addrAndMask)
+			return wtFwpConditionValue0{_type: cFWP_V4_ADDR_MASK, value: uintptr(unsafe.Pointer(addrAndMask))}, nil
+		}
+		addrAndMask := &wtFwpV6AddrAndMask{addr: v.Addr().As16(), prefixLength: uint8(v.Bits())}
+		fb.storedPointers = append(fb.storedPointers, addrAndMask)
+		return wtFwpConditionValue0{_type: cFWP_V6_ADDR_MASK, value: uintptr(unsafe.Pointer(addrAndMask))}, nil
+	case PortRange:
+		portRange := &wtFwpRange0{
+			valueLow:  wtFwpValue0{_type: cFWP_UINT16, value: uintptr(v.Low)},
+			valueHigh: wtFwpValue0{_type: cFWP_UINT16, value: uintptr(v.High)},
+		}
+		fb.storedPointers = append(fb.storedPointers, portRange)
+		return wtFwpConditionValue0{_type: cFWP_RANGE_TYPE, value: uintptr(unsafe.Pointer(portRange))}, nil
+	case AppID:
+		fileName, err := windows.UTF16PtrFromString(string(v))
+		if err != nil {
+			return wtFwpConditionValue0{}, wrapErr(err)
+		}
+		var appID *wtFwpByteBlob
+		err = fwpmGetAppIdFromFileName0(fileName, unsafe.Pointer(&appID))
+		if err != nil {
+			return wtFwpConditionValue0{}, wrapErr(err)
+		}
+		fb.wfpAllocations = append(fb.wfpAllocations, unsafe.Pointer(appID))
+		return wtFwpConditionValue0{_type: cFWP_BYTE_BLOB_TYPE, value: uintptr(unsafe.Pointer(appID))}, nil
+	case ServiceSIDs:
+		var sd *windows.SECURITY_DESCRIPTOR
+		var err error
+		if len(v) == 0 {
+			sd, err = getCurrentProcessSecurityDescriptor()
+		} else {
+			sd, err = getServicesSecurityDescriptor(v)
+		}
+		if err != nil {
+			return wtFwpConditionValue0{}, wrapErr(err)
+		}
+		sdBlob := &wtFwpByteBlob{sd.Length(), (*byte)(unsafe.Pointer(sd))}
+		fb.storedPointers = append(fb.storedPointers, sd, sdBlob)
+		return wtFwpConditionValue0{_type: cFWP_SECURITY_DESCRIPTOR_TYPE, value: uintptr(unsafe.Pointer(sdBlob))}, nil
+	}
+	return wtFwpConditionValue0{}, fmt.Errorf("Unsupported condition value type %T", value)
+}
+
+func (fb *filterBuilder) filterWeight(base uint64, weight uint8) wtFwpValue0 {
+	if base == 0 {
+		return wtFwpValue0{
+			_type: cFWP_UINT8,
+			value: uintptr(weight),
+		}
+	}
+	exact := base + uint64(weight)
+	fb.storedPointers = append(fb.storedPointers, &exact)
+	return wtFwpValue0{
+		_type: cFWP_UINT64,
+		value: uintptr(unsafe.Pointer(&exact)),
+	}
+}
+
+func addRule(session uintptr, baseObjects *baseObjects, rule *Rule) error {
+	fb := filterBuilder{}
+	defer fb.free()
+
+	conditions := make([]wtFwpmFilterCondition0, len(rule.Conditions))
+	for i, condition := range rule.Conditions {
+		fieldKey, ok := fieldKeys[condition.Field]
+		if !ok {
+			return fmt.Errorf("Unknown condition field %q", condition.Field)
+		}
+		matchType, ok := matchTypes[condition.Match]
+		if !ok {
+			return fmt.Errorf("Unknown condition match %q", condition.Match)
+		}
+		value, err := fb.conditionValue(condition.Value)
+		if err != nil {
+			return wrapErr(err)
+		}
+		conditions[i] = wtFwpmFilterCondition0{
+			fieldKey:       fieldKey,
+			matchType:      matchType,
+			conditionValue: value,
+		}
+	}
+
+	layerKey, ok := layerKeys[rule.Layer]
+	if !ok {
+		return fmt.Errorf("Unknown layer %q", rule.Layer)
+	}
+
+	displayData, err := createWtFwpmDisplayData0(rule.Name, "")
+	if err != nil {
+		return wrapErr(err)
+	}
+
+	filter := wtFwpmFilter0{
+		displayData:         *displayData,
+		providerKey:         &baseObjects.provider,
+		layerKey:            layerKey,
+		subLayerKey:         baseObjects.filters,
+		flags:               baseObjects.flags,
+		weight:              fb.filterWeight(baseObjects.weightBase, rule.Weight),
+		numFilterConditions: uint32(len(conditions)),
+		action: wtFwpmAction0{
+			_type: cFWP_ACTION_PERMIT,
+		},
+	}
+	if len(conditions) > 0 {
+		filter.filterCondition = &conditions[0]
+	}
+	if rule.Action == ActionBlock {
+		filter.action._type = cFWP_ACTION_BLOCK
+	}
+	if rule.ClearActionRight {
+		filter.flags |= cFWPM_FILTER_FLAG_CLEAR_ACTION_RIGHT
+	}
+	if len(rule.providerData) > 0 {
+		filter.providerData = wtFwpByteBlob{uint32(len(rule.providerData)), &rule.providerData[0]}
+	}
+
+	err = fwpmFilterAdd0(session, &filter, 0, &rule.ID)
+	if err != nil {
+		return wrapErr(err)
+	}
+	return nil
+}
+
+func addRules(session uintptr, baseObjects *baseObjects, rules []Rule) error {
+	for i := range rules {
+		err := addRule(session, baseObjects, &rules[i])
+		if err != nil {
+			return wrapErr(err)
+		}
+	}
+	return nil
+}
+
+func conditionFromWfp(condition *wtFwpmFilterCondition0) Condition {
+	c := Condition{
+		Field: fieldOfKey(condition.fieldKey),
+		Match: matchOfType(condition.matchType),
+	}
+	v := &condition.conditionValue
+	switch v._type {
+	case cFWP_UINT8:
+		c.Value = uint8(v.value)
+	case cFWP_UINT16:
+		c.Value = uint16(v.value)
+	case cFWP_UINT32:
+		if c.Field == FieldLocalAddress || c.Field == FieldRemoteAddress {
+			var ip [4]byte
+			binary.BigEndian.PutUint32(ip[:], uint32(v.value))
+			c.Value = netip.AddrFrom4(ip)
+		} else {
+			c.Value = uint32(v.value)
+		}
+	case cFWP_UINT64:
+		c.Value = **(**uint64)(unsafe.Pointer(&v.value))
+	case cFWP_BYTE_ARRAY16_TYPE:
+		c.Value = netip.AddrFrom16((*(**wtFwpByteArray16)(unsafe.Pointer(&v.value))).byteArray16)
+	case cFWP_V4_ADDR_MASK:
+		addrAndMask := *(**wtFwpV4AddrAndMask)(unsafe.Pointer(&v.value))
+		var ip [4]byte
+		binary.BigEndian.PutUint32(ip[:], addrAndMask.addr)
+		bits := 0
+		for mask := addrAndMask.mask; mask != 0; mask <<= 1 {
+			bits++
+		}
+		c.Value = netip.PrefixFrom(netip.AddrFrom4(ip), bits)
+	case cFWP_V6_ADDR_MASK:
+		addrAndMask := *(**wtFwpV6AddrAndMask)(unsafe.Pointer(&v.value))
+		c.Value = netip.PrefixFrom(netip.AddrFrom16(addrAndMask.addr), int(addrAndMask.prefixLength))
+	case cFWP_BYTE_BLOB_TYPE:
+		blob := *(**wtFwpByteBlob)(unsafe.Pointer(&v.value))
+		if c.Field == FieldAppID && blob.size >= 2 {
+			c.Value = AppID(windows.UTF16ToString(unsafe.Slice((*uint16)(unsafe.Pointer(blob.data)), blob.size/2)))
+		} else {
+			c.Value = fmt.Sprintf("%x", unsafe.Slice(blob.data, blob.size))
+		}
+	case cFWP_SECURITY_DESCRIPTOR_TYPE:
+		blob := *(**wtFwpByteBlob)(unsafe.Pointer(&v.value))
+		c.Value = SecurityDescriptor((*windows.SECURITY_DESCRIPTOR)(unsafe.Pointer(blob.data)).String())
+	case cFWP_RANGE_TYPE:
+		r := *(**wtFwpRange0)(unsafe.Pointer(&v.value))
+		if r.valueLow._type == cFWP_UINT16 && r.valueHigh._type == cFWP_UINT16 {
+			c.Value = PortRange{uint16(r.valueLow.value), uint16(r.valueHigh.value)}
+		} else {
+			c.Value = fmt.Sprintf("range of type %d", r.valueLow._type)
+		}
+	case cFWP_SID:
+		c.Value = (*(**windows.SID)(unsafe.Pointer(&v.value))).String()
+	default:
+		c.Value = fmt.Sprintf("value of type %d", v._type)
+	}
+	return c
+}
+
+func ruleFromWfp(filter *wtFwpmFilter0) Rule {
+	rule := Rule{
+		Name:             windows.UTF16PtrToString(filter.displayData.name),
+		Layer:            layerOfKey(filter.layerKey),
+		ClearActionRight: filter.flags&cFWPM_FILTER_FLAG_CLEAR_ACTION_RIGHT != 0,
+		ID:               filter.filterID,
+		Persistent:       filter.flags&cFWPM_FILTER_FLAG_PERSISTENT != 0,
+	}
+	switch filter.action._type {
+	case cFWP_ACTION_PERMIT:
+		rule.Action = ActionPermit
+	case cFWP_ACTION_BLOCK:
+		rule.Action = ActionBlock
+	default:
+		rule.Action = Action(fmt.Sprintf("action %#x", filter.action._type))
+	}
+	switch filter.weight._type {
+	case cFWP_UINT8:
+		rule.Weight = uint8(filter.weight.value)
+	case cFWP_UINT64:
+		if exact, base := **(**uint64)(unsafe.Pointer(&filter.weight.value)), filterWeightBase(); base != 0 && exact >= base && exact-base <= 15 {
+			rule.Weight = uint8(exact - base)
+		}
+	}
+	if filter.effectiveWeight._type == cFWP_UINT64 {
+		rule.EffectiveWeight = **(**uint64)(unsafe.Pointer(&filter.effectiveWeight.value))
+	}
+	if filter.numFilterConditions > 0 {
+		for _, condition := range unsafe.Slice(filter.filterCondition, filter.numFilterConditions) {
+			rule.Conditions = append(rule.Conditions, conditionFromWfp(&condition))
+		}
+	}
+	if filter.providerData.size > 0 {
+		rule.providerData = append([]byte{}, unsafe.Slice(filter.providerData.data, filter.providerData.size)...)
+	}
+	return rule
+}
+
+// InstalledRules returns the filters that are currently installed under WireGuard's providers, both
+// by running tunnels and by lockdown mode, in the order in which they are evaluated within each layer.
+func InstalledRules() ([]Rule, error) {
+	session, err := createWfpSession(true)
+	if err != nil {
+		return nil, wrapErr(err)
+	}
+	defer fwpmEngineClose0(session)
+
+	providers := make(map[windows.GUID]string)
+	sublayers := make(map[windows.GUID]string)
+	var rules []Rule
+	err = forEachFilter(session, func(filter *wtFwpmFilter0) {
+		if filter.providerKey == nil {
+			return
+		}
+		providerKey := *filter.providerKey
+		provider, ok := providers[providerKey]
+		if !ok {
+			var p *wtFwpmProvider0
+			if fwpmProviderGetByKey0(session, &providerKey, &p) == nil {
+				if windows.UTF16PtrToString(p.displayData.name) == "WireGuard" {
+					provider = fmt.Sprintf("%s %v", windows.UTF16PtrToString(p.displayData.description), providerKey)
+				}
+				fwpmFreeMemory0(unsafe.Pointer(&p))
+			}
+			providers[providerKey] = provider
+		}
+		if len(provider) == 0 {
+			return
+		}
+		sublayerKey := filter.subLayerKey
+		sublayer, ok := sublayers[sublayerKey]
+		if !ok {
+			sublayer = sublayerKey.String()
+			var s *wtFwpmSublayer0
+			if fwpmSubLayerGetByKey0(session, &sublayerKey, &s) == nil {
+				sublayer = fmt.Sprintf("%s %v, weight %d", windows.UTF16PtrToString(s.displayData.name), sublayerKey, s.weight)
+				fwpmFreeMemory0(unsafe.Pointer(&s))
+			}
+			sublayers[sublayerKey] = sublayer
+		}
+		rule := ruleFromWfp(filter)
+		rule.Provider = provider
+		rule.Sublayer = sublayer
+		rules = append(rules, rule)
+	})
+	if err != nil {
+		return nil, wrapErr(err)
+	}
+	sort.SliceStable(rules, func(i, j int) bool {
+		if rules[i].Layer != rules[j].Layer {
+			return rules[i].Layer < rules[j].Layer
+		}
+		return rules[i].EffectiveWeight > rules[j].EffectiveWeight
+	})
+	return rules, nil
+}
@@ -6,10 +6,14 @@
 package firewall
 
 import (
+	"crypto/sha1"
+	"encoding/binary"
 	"fmt"
 	"os"
 	"runtime"
+	"strings"
 	"syscall"
+	"unicode/utf16"
 	"unsafe"
 
 	"golang.org/x/sys/windows"
@@ -53,13 +57,6 @@ func createWtFwpmDisplayData0(name, description string) (*wtFwpmDisplayData0, er
 	}, nil
 }
 
-func filterWeight(weight uint8) wtFwpValue0 {
-	return wtFwpValue0{
-		_type: cFWP_UINT8,
-		value: uintptr(weight),
-	}
-}
-
 func wrapErr(err error) error {
 	if _, ok := err.(syscall.Errno); !ok {
 		return err
@@ -128,21 +125,112 @@ func getCurrentProcessSecurityDescriptor() (*windows.SECURITY_DESCRIPTOR, error)
 	return sd, nil
 }
 
-func getCurrentProcessAppID() (*wtFwpByteBlob, error) {
+func getCurrentProcessAppID() (AppID, error) {
 	currentFile, err := os.Executable()
 	if err != nil {
-		return nil, wrapErr(err)
+		return "", wrapErr(err)
 	}
+	return AppID(currentFile), nil
+}
 
-	curFilePtr, err := windows.UTF16PtrFromString(currentFile)
+// serviceSid computes the SID of a service, as RtlCreateServiceSid does: S-1-5-80- followed by the SHA-1
+// of the upper-cased UTF-16 service name, which means that it does not depend on the service existing.
+func serviceSid(serviceName string) (*windows.SID, error) {
+	name := utf16.Encode([]rune(strings.ToUpper(serviceName)))
+	nameBytes := make([]byte, len(name)*2)
+	for i, c := range name {
+		binary.LittleEndian.PutUint16(nameBytes[i*2:], c)
+	}
+	hash := sha1.Sum(nameBytes)
+	sid := "S-1-5-80"
+	for i := 0; i < len(hash); i += 4 {
+		sid += fmt.Sprintf("-%d", binary.LittleEndian.Uint32(hash[i:]))
+	}
+	return windows.StringToSid(sid)
+}
+
+func getServicesSecurityDescriptor(serviceNames []string) (*windows.SECURITY_DESCRIPTOR, error) {
+	access := make([]windows.EXPLICIT_ACCESS, 0, len(serviceNames))
+	for _, serviceName := range serviceNames {
+		sid, err := serviceSid(serviceName)
+		if err != nil {
+			return nil, wrapErr(err)
+		}
+		access = append(access, windows.EXPLICIT_ACCESS{
+			AccessPermissions: cFWP_ACTRL_MATCH_FILTER,
+			AccessMode:        windows.GRANT_ACCESS,
+			Trustee: windows.TRUSTEE{
+				TrusteeForm:  windows.TRUSTEE_IS_SID,
+				TrusteeType:  windows.TRUSTEE_IS_WELL_KNOWN_GROUP,
+				TrusteeValue: windows.TrusteeValueFromSID(sid),
+			},
+		})
+	}
+	dacl, err := windows.ACLFromEntries(access, nil)
 	if err != nil {
 		return nil, wrapErr(err)
 	}
-
-	var appID *wtFwpByteBlob
-	err = fwpmGetAppIdFromFileName0(curFilePtr, unsafe.Pointer(&appID))
+	sd, err := windows.NewSecurityDescriptor()
+	if err != nil {
+		return nil, wrapErr(err)
+	}
+	err = sd.SetDACL(dacl, true, false)
+	if err != nil {
+		return nil, wrapErr(err)
+	}
+	sd, err = sd.ToSelfRelative()
 	if err != nil {
 		return nil, wrapErr(err)
 	}
-	return appID, nil
+	return sd, nil
+}
+
+func forEachFilter(session uintptr, fn func(filter *wtFwpmFilter0)) error {
+	enumHandle := uintptr(0)
+	err := fwpmFilterCreateEnumHandle0(session, nil, &enumHandle)
+	if err != nil {
+		return wrapErr(err)
+	}
+	defer fwpmFilterDestroyEnumHandle0(session, enumHandle)
+
+	for {
+		var entries **wtFwpmFilter0
+		numEntries := uint32(0)
+		err = fwpmFilterEnum0(session, enumHandle, 128, &entries, &numEntries)
+		if err != nil {
+			return wrapErr(err)
+		}
+		if numEntries == 0 {
+			return nil
+		}
+		for _, filter := range unsafe.Slice(entries, numEntries) {
+			fn(filter)
+		}
+		fwpmFreeMemory0(unsafe.Pointer(&entries))
+	}
+}
+
+func forEachSublayer(session uintptr, fn func(sublayer *wtFwpmSublayer0)) error {
+	enumHandle := uintptr(0)
+	err := fwpmSubLayerCreateEnumHandle0(session, nil, &enumHandle)
+	if err != nil {
+		return wrapErr(err)
+	}
+	defer fwpmSubLayerDestroyEnumHandle0(session, enumHandle)
+
+	for {
+		var entries **wtFwpmSublayer0
+		numEntries := uint32(0)
+		err = fwpmSubLayerEnum0(session, enumHandle, 128, &entries, &numEntries)
+		if err != nil {
+			return wrapErr(err)
+		}
+		if numEntries == 0 {
+			return nil
+		}
+		for _, sublayer := range unsafe.Slice(entries, numEntries) {
+			fn(sublayer)
+		}
+		fwpmFreeMemory0(unsafe.Pointer(&entries))
+	}
 }
@@ -0,0 +1,238 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2026 WireGuard LLC. All Rights Reserved.
+ */
+
+package firewall
+
+import (
+	"strings"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// Unlike the objects of the dynamic session, which are generated anew each time, the lockdown objects are
+// persistent, so they must have well-known keys in order to be found again after a reboot.
+var (
+	// f8c6b51f-4962-484e-9b60-084c02a62977
+	lockdownProviderKey = windows.GUID{
+		Data1: 0xf8c6b51f,
+		Data2: 0x4962,
+		Data3: 0x484e,
+		Data4: [8]byte{0x9b, 0x60, 0x08, 0x4c, 0x02, 0xa6, 0x29, 0x77},
+	}
+
+	// 1838d21e-fad8-4122-bd93-d36b104889ad
+	lockdownSublayerKey = windows.GUID{
+		Data1: 0x1838d21e,
+		Data2: 0xfad8,
+		Data3: 0x4122,
+		Data4: [8]byte{0xbd, 0x93, 0xd3, 0x6b, 0x10, 0x48, 0x89, 0xad},
+	}
+)
+
+// lockdownBaseObjects returns the lockdown provider and sublayer if lockdown mode is enabled, or nil otherwise.
+func lockdownBaseObjects(session uintptr) *baseObjects {
+	var sublayer *wtFwpmSublayer0
+	err := fwpmSubLayerGetByKey0(session, &lockdownSublayerKey, &sublayer)
+	if err != nil {
+		return nil
+	}
+	fwpmFreeMemory0(unsafe.Pointer(&sublayer))
+	return &baseObjects{
+		provider:   lockdownProviderKey,
+		filters:    lockdownSublayerKey,
+		weightBase: filterWeightBase(),
+	}
+}
+
+func registerLockdownObjects(session uintptr) (*baseObjects, error) {
+	if bo := lockdownBaseObjects(session); bo != nil {
+		bo.flags = cFWPM_FILTER_FLAG_PERSISTENT
+		return bo, nil
+	}
+
+	//
+	// Register provider.
+	//
+	{
+		displayData, err := createWtFwpmDisplayData0("WireGuard", "WireGuard lockdown provider")
+		if err != nil {
+			return nil, wrapErr(err)
+		}
+		provider := wtFwpmProvider0{
+			providerKey: lockdownProviderKey,
+			displayData: *displayData,
+			flags:       cFWPM_PROVIDER_FLAG_PERSISTENT,
+		}
+		err = fwpmProviderAdd0(session, &provider, 0)
+		if err != nil {
+			return nil, wrapErr(err)
+		}
+	}
+
+	//
+	// Register filters sublayer.
+	//
+	{
+		displayData, err := createWtFwpmDisplayData0("WireGuard lockdown filters", "Persistent filters blocking traffic outside of approved tunnels")
+		if err != nil {
+			return nil, wrapErr(err)
+		}
+		sublayer := wtFwpmSublayer0{
+			subLayerKey: lockdownSublayerKey,
+			displayData: *displayData,
+			flags:       cFWPM_SUBLAYER_FLAG_PERSISTENT,
+			providerKey: &lockdownProviderKey,
+			weight:      sublayerWeight(),
+		}
+		err = fwpmSubLayerAdd0(session, &sublayer, 0)
+		if err != nil {
+			return nil, wrapErr(err)
+		}
+	}
+
+	return &baseObjects{
+		provider:   lockdownProviderKey,
+		filters:    lockdownSublayerKey,
+		flags:      cFWPM_FILTER_FLAG_PERSISTENT,
+		weightBase: filterWeightBase(),
+	}, nil
+}
+
+// removeLockdownFilters removes all filters of the lockdown provider, including those that running tunnels
+// added to the lockdown sublayer.
+func removeLockdownFilters(session uintptr) error {
+	var filterIDs []uint64
+	err := forEachFilter(session, func(filter *wtFwpmFilter0) {
+		if filter.providerKey != nil && *filter.providerKey == lockdownProviderKey {
+			filterIDs = append(filterIDs, filter.filterID)
+		}
+	})
+	if err != nil {
+		return wrapErr(err)
+	}
+	for _, filterID := range filterIDs {
+		err = fwpmFilterDeleteById0(session, filterID)
+		if err != nil {
+			return wrapErr(err)
+		}
+	}
+	return nil
+}
+
+// EnableLockdown installs persistent filters that block all traffic, except for loopback, DHCP, and NDP,
+// as well as the traffic of the given tunnel services and traffic on the interfaces of tunnels that they bring up.
+// These filters remain in effect across tunnel stops, service crashes, and reboots, until DisableLockdown
+// is called. Calling it while lockdown mode is already enabled replaces the list of approved services.
+func EnableLockdown(serviceNames []string) error {
+	executable, err := getCurrentProcessAppID()
+	if err != nil {
+		return wrapErr(err)
+	}
+	rules := lockdownRules(executable, serviceNames)
+
+	session, err := createWfpSession(false)
+	if err != nil {
+		return wrapErr(err)
+	}
+	defer fwpmEngineClose0(session)
+
+	objectInstaller := func(session uintptr) error {
+		err := removeLockdownFilters(session)
+		if err != nil {
+			return wrapErr(err)
+		}
+
+		baseObjects, err := registerLockdownObjects(session)
+		if err != nil {
+			return wrapErr(err)
+		}
+
+		return addRules(session, baseObjects, rules)
+	}
+
+	return runTransaction(session, objectInstaller)
+}
+
+// PermitLockdownResolution permits the DNS queries of the DNS Client service despite lockdown mode, if it is enabled,
+// so that an approved tunnel can resolve the hostnames of its endpoints. The returned function removes the rules
+// again, which also happens should the process exit first, since they belong to a dynamic session.
+func PermitLockdownResolution() (func(), error) {
+	session, err := createWfpSession(true)
+	if err != nil {
+		return nil, wrapErr(err)
+	}
+	lockdownObjects := lockdownBaseObjects(session)
+	if lockdownObjects == nil {
+		fwpmEngineClose0(session)
+		return func() {}, nil
+	}
+	err = runTransaction(session, func(session uintptr) error {
+		return addRules(session, lockdownObjects, permitDNSClient(12))
+	})
+	if err != nil {
+		fwpmEngineClose0(session)
+		return nil, wrapErr(err)
+	}
+	return func() { fwpmEngineClose0(session) }, nil
+}
+
+// DisableLockdown removes all persistent filters and objects installed by EnableLockdown.
+func DisableLockdown() error {
+	session, err := createWfpSession(false)
+	if err != nil {
+		return wrapErr(err)
+	}
+	defer fwpmEngineClose0(session)
+
+	objectRemover := func(session uintptr) error {
+		if lockdownBaseObjects(session) == nil {
+			return nil
+		}
+
+		err := removeLockdownFilters(session)
+		if err != nil {
+			return wrapErr(err)
+		}
+
+		err = fwpmSubLayerDeleteByKey0(session, &lockdownSublayerKey)
+		if err != nil {
+			return wrapErr(err)
+		}
+
+		err = fwpmProviderDeleteByKey0(session, &lockdownProviderKey)
+		if err != nil {
+			return wrapErr(err)
+		}
+
+		return nil
+	}
+
+	return runTransaction(session, objectRemover)
+}
+
+// LockdownStatus reports whether lockdown mode is enabled, and if so, which tunnel services are approved.
+func LockdownStatus() (enabled bool, serviceNames []string, err error) {
+	session, err := createWfpSession(true)
+	if err != nil {
+		return false, nil, wrapErr(err)
+	}
+	defer fwpmEngineClose0(session)
+
+	if lockdownBaseObjects(session) == nil {
+		return false, nil, nil
+	}
+
+	err = forEachFilter(session, func(filter *wtFwpmFilter0) {
+		if serviceNames != nil || filter.providerKey == nil || *filter.providerKey != lockdownProviderKey || filter.providerData.size == 0 {
+			return
+		}
+		serviceNames = strings.Split(string(unsafe.Slice(filter.providerData.data, filter.providerData.size)), "\n")
+	})
+	if err != nil {
+		return true, nil, wrapErr(err)
+	}
+	return true, serviceNames, nil
+}
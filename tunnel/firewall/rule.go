@@ -0,0 +1,211 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2026 WireGuard LLC. All Rights Reserved.
+ */
+
+package firewall
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// Action is what happens to traffic matching a rule.
+type Action string
+
+const (
+	ActionPermit Action = "permit"
+	ActionBlock  Action = "block"
+)
+
+// Layer is the WFP layer at which a rule is evaluated, named after its FWPM_LAYER_* identifier.
+type Layer string
+
+const (
+	LayerConnectV4              Layer = "ALE_AUTH_CONNECT_V4"
+	LayerRecvAcceptV4           Layer = "ALE_AUTH_RECV_ACCEPT_V4"
+	LayerConnectV6              Layer = "ALE_AUTH_CONNECT_V6"
+	LayerRecvAcceptV6           Layer = "ALE_AUTH_RECV_ACCEPT_V6"
+	LayerOutboundMACFrameNative Layer = "OUTBOUND_MAC_FRAME_NATIVE"
+	LayerInboundMACFrameNative  Layer = "INBOUND_MAC_FRAME_NATIVE"
+	LayerForwardV4              Layer = "IPFORWARD_V4"
+	LayerForwardV6              Layer = "IPFORWARD_V6"
+)
+
+// Field is the part of the traffic that a condition examines, named after its FWPM_CONDITION_* identifier.
+type Field string
+
+const (
+	FieldLocalInterface   Field = "IP_LOCAL_INTERFACE"
+	FieldForwardInterface Field = "IP_FORWARD_INTERFACE"
+	FieldLocalAddress     Field = "IP_LOCAL_ADDRESS"
+	FieldRemoteAddress    Field = "IP_REMOTE_ADDRESS"
+	FieldProtocol         Field = "IP_PROTOCOL"
+	FieldLocalPort        Field = "IP_LOCAL_PORT"
+	FieldRemotePort       Field = "IP_REMOTE_PORT"
+	FieldICMPType         Field = "ICMP_TYPE"
+	FieldICMPCode         Field = "ICMP_CODE"
+	FieldAppID            Field = "ALE_APP_ID"
+	FieldUserID           Field = "ALE_USER_ID"
+	FieldFlags            Field = "FLAGS"
+	FieldL2Flags          Field = "L2_FLAGS"
+)
+
+// Match is how a condition compares a field to its value.
+type Match string
+
+const (
+	MatchEqual       Match = "=="
+	MatchNotEqual    Match = "!="
+	MatchFlagsAllSet Match = "has all of"
+	MatchRange       Match = "in"
+)
+
+// AppID matches the traffic of an executable, given by its path.
+type AppID string
+
+// ServiceSIDs matches the traffic of the named services, or, if no names are given, that of the current service.
+type ServiceSIDs []string
+
+func (s ServiceSIDs) String() string {
+	if len(s) == 0 {
+		return "current service"
+	}
+	return strings.Join(s, ", ")
+}
+
+func (s ServiceSIDs) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// PortRange matches the ports from Low to High, inclusive.
+type PortRange struct {
+	Low  uint16
+	High uint16
+}
+
+func (r PortRange) String() string {
+	return fmt.Sprintf("%d-%d", r.Low, r.High)
+}
+
+func (r PortRange) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// SecurityDescriptor is a security descriptor in SDDL form, as read back from an installed filter.
+type SecurityDescriptor string
+
+// Condition restricts the traffic matched by a rule. Conditions of a rule that examine different
+// fields must all match, while conditions that examine the same field match if any of them do.
+type Condition struct {
+	Field Field `json:"field"`
+	Match Match `json:"match"`
+	Value any   `json:"value"`
+}
+
+func (c Condition) String() string {
+	return fmt.Sprintf("%s %s %v", c.Field, c.Match, c.Value)
+}
+
+// Rule is a declarative description of a single WFP filter. Rules are built by this package before
+// being installed, and can be read back from the filters that are currently installed.
+type Rule struct {
+	Name             string      `json:"name"`
+	Layer            Layer       `json:"layer"`
+	Action           Action      `json:"action"`
+	Weight           uint8       `json:"weight"`
+	ClearActionRight bool        `json:"clearActionRight,omitempty"`
+	Conditions       []Condition `json:"conditions,omitempty"`
+
+	// The ID is known once a rule has been installed, and the rest only for rules that have been read back
+	// from installed filters.
+	ID              uint64 `json:"id,omitempty"`
+	EffectiveWeight uint64 `json:"effectiveWeight,omitempty"`
+	Persistent      bool   `json:"persistent,omitempty"`
+	Provider        string `json:"provider,omitempty"`
+	Sublayer        string `json:"sublayer,omitempty"`
+
+	providerData []byte
+}
+
+func (rule *Rule) String() string {
+	var b strings.Builder
+	b.WriteString(rule.Name)
+	if rule.ID != 0 {
+		fmt.Fprintf(&b, " (filter %d)", rule.ID)
+	}
+	fmt.Fprintf(&b, "\n    %s at %s, weight %d", rule.Action, rule.Layer, rule.Weight)
+	if rule.EffectiveWeight != 0 {
+		fmt.Fprintf(&b, " (effective %#016x)", rule.EffectiveWeight)
+	}
+	if rule.ClearActionRight {
+		b.WriteString(", clears action right")
+	}
+	if rule.Persistent {
+		b.WriteString(", persistent")
+	}
+	if len(rule.Provider) > 0 {
+		fmt.Fprintf(&b, "\n    provider %s", rule.Provider)
+	}
+	if len(rule.Sublayer) > 0 {
+		fmt.Fprintf(&b, "\n    sublayer %s", rule.Sublayer)
+	}
+	for _, condition := range rule.Conditions {
+		fmt.Fprintf(&b, "\n    if %s", condition)
+	}
+	return b.String()
+}
+
+// InboundRule describes inbound traffic on the tunnel interface that remains permitted under an InboundPolicy.
+// A zero protocol matches all protocols, a zero port range matches all ports, and an empty list of prefixes
+// matches all remote addresses. ICMP, given as protocol 1, also matches ICMPv6.
+type InboundRule struct {
+	Protocol uint8
+	Ports    PortRange
+	From     []netip.Prefix
+}
+
+// Options are what the kill-switch of a tunnel, as installed by EnableFirewall, restricts and permits besides the
+// traffic of the tunnel itself.
+type Options struct {
+	DoNotRestrict     bool                  // Only permit the tunnel service and apply Inbound, leaving other traffic alone
+	DNSServers        []netip.Addr          // If set, DNS is only permitted to these servers
+	BlockEncryptedDNS bool                  // Block DNS over TLS, and DNS over HTTPS to known resolvers
+	Inbound           *InboundPolicy        // If set, inbound traffic on the tunnel interface is restricted
+	VirtualMachines   *VirtualMachinePolicy // If set, the traffic of virtual machines is permitted
+}
+
+// InboundPolicy blocks all inbound traffic on the tunnel interface, except for what its rules permit.
+type InboundPolicy struct {
+	Allow []InboundRule
+}
+
+// VirtualMachinePolicy permits the traffic of Hyper-V virtual machines and containers, such as those of WSL2
+// and Docker Desktop, which reach the network through the host's virtual adapters and its NAT.
+type VirtualMachinePolicy struct {
+	Adapters    []uint64 // LUIDs of the host's Hyper-V virtual adapters
+	ForceTunnel bool     // Only forward the traffic of virtual machines to the tunnel interface
+}
+
+// ipLayers returns a copy of the rule for each of the outbound and inbound IPv4 and IPv6 layers, in that
+// order. The name is a format string, which is passed the direction and the IP version.
+func ipLayers(name string, rule Rule) []Rule {
+	layers := [...]struct {
+		layer     Layer
+		direction string
+		version   int
+	}{
+		{LayerConnectV4, "outbound", 4},
+		{LayerRecvAcceptV4, "inbound", 4},
+		{LayerConnectV6, "outbound", 6},
+		{LayerRecvAcceptV6, "inbound", 6},
+	}
+	rules := make([]Rule, 0, len(layers))
+	for _, l := range layers {
+		rule.Name = fmt.Sprintf(name, l.direction, l.version)
+		rule.Layer = l.layer
+		rules = append(rules, rule)
+	}
+	return rules
+}
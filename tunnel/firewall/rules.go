@@ -6,584 +6,152 @@
 package firewall
 
 import (
-	"encoding/binary"
 	"errors"
+	"fmt"
 	"net/netip"
-	"runtime"
-	"unsafe"
-
-	"golang.org/x/sys/windows"
+	"slices"
+	"strconv"
+	"strings"
 )
 
 // Known addresses.
 var (
-	linkLocal = wtFwpV6AddrAndMask{[16]uint8{0xfe, 0x80}, 10}
+	linkLocal = netip.MustParsePrefix("fe80::/10")
 
-	linkLocalDHCPMulticast = wtFwpByteArray16{[16]uint8{0xFF, 0x02, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x1, 0x0, 0x2}}
-	siteLocalDHCPMulticast = wtFwpByteArray16{[16]uint8{0xFF, 0x05, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x1, 0x0, 0x3}}
+	linkLocalDHCPMulticast = netip.MustParseAddr("ff02::1:2")
+	siteLocalDHCPMulticast = netip.MustParseAddr("ff05::1:3")
 
-	linkLocalRouterMulticast = wtFwpByteArray16{[16]uint8{0xFF, 0x02, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x2}}
-)
+	linkLocalRouterMulticast = netip.MustParseAddr("ff02::2")
 
-func permitTunInterface(session uintptr, baseObjects *baseObjects, weight uint8, ifLUID uint64) error {
-	ifaceCondition := wtFwpmFilterCondition0{
-		fieldKey:  cFWPM_CONDITION_IP_LOCAL_INTERFACE,
-		matchType: cFWP_MATCH_EQUAL,
-		conditionValue: wtFwpConditionValue0{
-			_type: cFWP_UINT64,
-			value: (uintptr)(unsafe.Pointer(&ifLUID)),
-		},
-	}
+	limitedBroadcast = netip.MustParseAddr("255.255.255.255")
+)
 
-	filter := wtFwpmFilter0{
-		providerKey:         &baseObjects.provider,
-		subLayerKey:         baseObjects.filters,
-		weight:              filterWeight(weight),
-		numFilterConditions: 1,
-		filterCondition:     (*wtFwpmFilterCondition0)(unsafe.Pointer(&ifaceCondition)),
-		action: wtFwpmAction0{
-			_type: cFWP_ACTION_PERMIT,
+func permitTunInterface(weight uint8, ifLUID uint64) []Rule {
+	return ipLayers("Permit %s IPv%d traffic on TUN", Rule{
+		Action: ActionPermit,
+		Weight: weight,
+		Conditions: []Condition{
+			{FieldLocalInterface, MatchEqual, ifLUID},
 		},
-	}
-
-	filterID := uint64(0)
-
-	//
-	// #1 Permit outbound IPv4 traffic.
-	//
-	{
-		displayData, err := createWtFwpmDisplayData0("Permit outbound IPv4 traffic on TUN", "")
-		if err != nil {
-			return wrapErr(err)
-		}
-
-		filter.displayData = *displayData
-		filter.layerKey = cFWPM_LAYER_ALE_AUTH_CONNECT_V4
-
-		err = fwpmFilterAdd0(session, &filter, 0, &filterID)
-		if err != nil {
-			return wrapErr(err)
-		}
-	}
-
-	//
-	// #2 Permit inbound IPv4 traffic.
-	//
-	{
-		displayData, err := createWtFwpmDisplayData0("Permit inbound IPv4 traffic on TUN", "")
-		if err != nil {
-			return wrapErr(err)
-		}
-
-		filter.displayData = *displayData
-		filter.layerKey = cFWPM_LAYER_ALE_AUTH_RECV_ACCEPT_V4
-
-		err = fwpmFilterAdd0(session, &filter, 0, &filterID)
-		if err != nil {
-			return wrapErr(err)
-		}
-	}
-
-	//
-	// #3 Permit outbound IPv6 traffic.
-	//
-	{
-		displayData, err := createWtFwpmDisplayData0("Permit outbound IPv6 traffic on TUN", "")
-		if err != nil {
-			return wrapErr(err)
-		}
-
-		filter.displayData = *displayData
-		filter.layerKey = cFWPM_LAYER_ALE_AUTH_CONNECT_V6
-
-		err = fwpmFilterAdd0(session, &filter, 0, &filterID)
-		if err != nil {
-			return wrapErr(err)
-		}
-	}
-
-	//
-	// #4 Permit inbound IPv6 traffic.
-	//
-	{
-		displayData, err := createWtFwpmDisplayData0("Permit inbound IPv6 traffic on TUN", "")
-		if err != nil {
-			return wrapErr(err)
-		}
-
-		filter.displayData = *displayData
-		filter.layerKey = cFWPM_LAYER_ALE_AUTH_RECV_ACCEPT_V6
-
-		err = fwpmFilterAdd0(session, &filter, 0, &filterID)
-		if err != nil {
-			return wrapErr(err)
-		}
-	}
-
-	runtime.KeepAlive(ifLUID)
-	return nil
+	})
 }
 
-func permitWireGuardService(session uintptr, baseObjects *baseObjects, weight uint8) error {
-	var conditions [2]wtFwpmFilterCondition0
-
-	//
-	// First condition is the exe path of the current process.
-	//
-	appID, err := getCurrentProcessAppID()
-	if err != nil {
-		return wrapErr(err)
-	}
-	defer fwpmFreeMemory0(unsafe.Pointer(&appID))
-
-	conditions[0] = wtFwpmFilterCondition0{
-		fieldKey:  cFWPM_CONDITION_ALE_APP_ID,
-		matchType: cFWP_MATCH_EQUAL,
-		conditionValue: wtFwpConditionValue0{
-			_type: cFWP_BYTE_BLOB_TYPE,
-			value: uintptr(unsafe.Pointer(appID)),
-		},
-	}
-
-	//
-	// Second condition is the SECURITY_DESCRIPTOR of the current process.
-	// This prevents other processes hosted in the same exe from matching this filter.
-	//
-	sd, err := getCurrentProcessSecurityDescriptor()
-	if err != nil {
-		return wrapErr(err)
-	}
-
-	sdBlob := wtFwpByteBlob{sd.Length(), (*byte)(unsafe.Pointer(sd))}
-	conditions[1] = wtFwpmFilterCondition0{
-		fieldKey:  cFWPM_CONDITION_ALE_USER_ID,
-		matchType: cFWP_MATCH_EQUAL,
-		conditionValue: wtFwpConditionValue0{
-			_type: cFWP_SECURITY_DESCRIPTOR_TYPE,
-			value: uintptr(unsafe.Pointer(&sdBlob)),
-		},
-	}
-
-	//
-	// Assemble the filter.
-	//
-	filter := wtFwpmFilter0{
-		providerKey:         &baseObjects.provider,
-		subLayerKey:         baseObjects.filters,
-		weight:              filterWeight(weight),
-		flags:               cFWPM_FILTER_FLAG_CLEAR_ACTION_RIGHT,
-		numFilterConditions: uint32(len(conditions)),
-		filterCondition:     (*wtFwpmFilterCondition0)(unsafe.Pointer(&conditions)),
-		action: wtFwpmAction0{
-			_type: cFWP_ACTION_PERMIT,
+func permitWireGuardService(weight uint8, executable AppID) []Rule {
+	return ipLayers("Permit unrestricted %s traffic for WireGuard service (IPv%d)", Rule{
+		Action:           ActionPermit,
+		Weight:           weight,
+		ClearActionRight: true,
+		Conditions: []Condition{
+			// First condition is the exe path of the current process.
+			{FieldAppID, MatchEqual, executable},
+			// Second condition is the SECURITY_DESCRIPTOR of the current process.
+			// This prevents other processes hosted in the same exe from matching this filter.
+			{FieldUserID, MatchEqual, ServiceSIDs(nil)},
 		},
-	}
-
-	filterID := uint64(0)
-
-	//
-	// #1 Permit outbound IPv4 traffic.
-	//
-	{
-		displayData, err := createWtFwpmDisplayData0("Permit unrestricted outbound traffic for WireGuard service (IPv4)", "")
-		if err != nil {
-			return wrapErr(err)
-		}
-
-		filter.displayData = *displayData
-		filter.layerKey = cFWPM_LAYER_ALE_AUTH_CONNECT_V4
-
-		err = fwpmFilterAdd0(session, &filter, 0, &filterID)
-		if err != nil {
-			return wrapErr(err)
-		}
-	}
-
-	//
-	// #2 Permit inbound IPv4 traffic.
-	//
-	{
-		displayData, err := createWtFwpmDisplayData0("Permit unrestricted inbound traffic for WireGuard service (IPv4)", "")
-		if err != nil {
-			return wrapErr(err)
-		}
-
-		filter.displayData = *displayData
-		filter.layerKey = cFWPM_LAYER_ALE_AUTH_RECV_ACCEPT_V4
-
-		err = fwpmFilterAdd0(session, &filter, 0, &filterID)
-		if err != nil {
-			return wrapErr(err)
-		}
-	}
-
-	//
-	// #3 Permit outbound IPv6 traffic.
-	//
-	{
-		displayData, err := createWtFwpmDisplayData0("Permit unrestricted outbound traffic for WireGuard service (IPv6)", "")
-		if err != nil {
-			return wrapErr(err)
-		}
-
-		filter.displayData = *displayData
-		filter.layerKey = cFWPM_LAYER_ALE_AUTH_CONNECT_V6
-
-		err = fwpmFilterAdd0(session, &filter, 0, &filterID)
-		if err != nil {
-			return wrapErr(err)
-		}
-	}
-
-	//
-	// #4 Permit inbound IPv6 traffic.
-	//
-	{
-		displayData, err := createWtFwpmDisplayData0("Permit unrestricted inbound traffic for WireGuard service (IPv6)", "")
-		if err != nil {
-			return wrapErr(err)
-		}
-
-		filter.displayData = *displayData
-		filter.layerKey = cFWPM_LAYER_ALE_AUTH_RECV_ACCEPT_V6
-
-		err = fwpmFilterAdd0(session, &filter, 0, &filterID)
-		if err != nil {
-			return wrapErr(err)
-		}
-	}
-
-	runtime.KeepAlive(sdBlob)
-	runtime.KeepAlive(sd)
-	return nil
+	})
 }
 
-func permitLoopback(session uintptr, baseObjects *baseObjects, weight uint8) error {
-	condition := wtFwpmFilterCondition0{
-		fieldKey:  cFWPM_CONDITION_FLAGS,
-		matchType: cFWP_MATCH_FLAGS_ALL_SET,
-		conditionValue: wtFwpConditionValue0{
-			_type: cFWP_UINT32,
-			value: uintptr(cFWP_CONDITION_FLAG_IS_LOOPBACK),
+// Permit the traffic of the given tunnel services, which is to say, the encrypted traffic of approved tunnels.
+// The service names are stored as provider data, so that they can be listed later.
+func permitTunnelServices(weight uint8, executable AppID, serviceNames []string) []Rule {
+	return ipLayers("Permit unrestricted %s traffic for approved WireGuard tunnels (IPv%d)", Rule{
+		Action:           ActionPermit,
+		Weight:           weight,
+		ClearActionRight: true,
+		Conditions: []Condition{
+			{FieldAppID, MatchEqual, executable},
+			{FieldUserID, MatchEqual, ServiceSIDs(serviceNames)},
 		},
-	}
+		providerData: []byte(strings.Join(serviceNames, "\n")),
+	})
+}
 
-	filter := wtFwpmFilter0{
-		providerKey:         &baseObjects.provider,
-		subLayerKey:         baseObjects.filters,
-		weight:              filterWeight(weight),
-		numFilterConditions: 1,
-		filterCondition:     (*wtFwpmFilterCondition0)(unsafe.Pointer(&condition)),
-		action: wtFwpmAction0{
-			_type: cFWP_ACTION_PERMIT,
+func permitLoopback(weight uint8) []Rule {
+	return ipLayers("Permit %s on loopback (IPv%d)", Rule{
+		Action: ActionPermit,
+		Weight: weight,
+		Conditions: []Condition{
+			{FieldFlags, MatchFlagsAllSet, uint32(cFWP_CONDITION_FLAG_IS_LOOPBACK)},
 		},
-	}
-
-	filterID := uint64(0)
-
-	//
-	// #1 Permit outbound IPv4 on loopback.
-	//
-	{
-		displayData, err := createWtFwpmDisplayData0("Permit outbound on loopback (IPv4)", "")
-		if err != nil {
-			return wrapErr(err)
-		}
-
-		filter.displayData = *displayData
-		filter.layerKey = cFWPM_LAYER_ALE_AUTH_CONNECT_V4
-
-		err = fwpmFilterAdd0(session, &filter, 0, &filterID)
-		if err != nil {
-			return wrapErr(err)
-		}
-	}
-
-	//
-	// #2 Permit inbound IPv4 on loopback.
-	//
-	{
-		displayData, err := createWtFwpmDisplayData0("Permit inbound on loopback (IPv4)", "")
-		if err != nil {
-			return wrapErr(err)
-		}
-
-		filter.displayData = *displayData
-		filter.layerKey = cFWPM_LAYER_ALE_AUTH_RECV_ACCEPT_V4
-
-		err = fwpmFilterAdd0(session, &filter, 0, &filterID)
-		if err != nil {
-			return wrapErr(err)
-		}
-	}
-
-	//
-	// #3 Permit outbound IPv6 on loopback.
-	//
-	{
-		displayData, err := createWtFwpmDisplayData0("Permit outbound on loopback (IPv6)", "")
-		if err != nil {
-			return wrapErr(err)
-		}
-
-		filter.displayData = *displayData
-		filter.layerKey = cFWPM_LAYER_ALE_AUTH_CONNECT_V6
-
-		err = fwpmFilterAdd0(session, &filter, 0, &filterID)
-		if err != nil {
-			return wrapErr(err)
-		}
-	}
-
-	//
-	// #4 Permit inbound IPv6 on loopback.
-	//
-	{
-		displayData, err := createWtFwpmDisplayData0("Permit inbound on loopback (IPv6)", "")
-		if err != nil {
-			return wrapErr(err)
-		}
-
-		filter.displayData = *displayData
-		filter.layerKey = cFWPM_LAYER_ALE_AUTH_RECV_ACCEPT_V6
-
-		err = fwpmFilterAdd0(session, &filter, 0, &filterID)
-		if err != nil {
-			return wrapErr(err)
-		}
-	}
-
-	return nil
+	})
 }
 
-func permitDHCPIPv4(session uintptr, baseObjects *baseObjects, weight uint8) error {
-	//
-	// #1 Outbound DHCP request on IPv4.
-	//
-	{
-		var conditions [4]wtFwpmFilterCondition0
-
-		conditions[0].fieldKey = cFWPM_CONDITION_IP_PROTOCOL
-		conditions[0].matchType = cFWP_MATCH_EQUAL
-		conditions[0].conditionValue._type = cFWP_UINT8
-		conditions[0].conditionValue.value = uintptr(cIPPROTO_UDP)
-
-		conditions[1].fieldKey = cFWPM_CONDITION_IP_LOCAL_PORT
-		conditions[1].matchType = cFWP_MATCH_EQUAL
-		conditions[1].conditionValue._type = cFWP_UINT16
-		conditions[1].conditionValue.value = uintptr(68)
-
-		conditions[2].fieldKey = cFWPM_CONDITION_IP_REMOTE_PORT
-		conditions[2].matchType = cFWP_MATCH_EQUAL
-		conditions[2].conditionValue._type = cFWP_UINT16
-		conditions[2].conditionValue.value = uintptr(67)
-
-		conditions[3].fieldKey = cFWPM_CONDITION_IP_REMOTE_ADDRESS
-		conditions[3].matchType = cFWP_MATCH_EQUAL
-		conditions[3].conditionValue._type = cFWP_UINT32
-		conditions[3].conditionValue.value = uintptr(0xffffffff)
-
-		displayData, err := createWtFwpmDisplayData0("Permit outbound DHCP request (IPv4)", "")
-		if err != nil {
-			return wrapErr(err)
-		}
-
-		filter := wtFwpmFilter0{
-			displayData:         *displayData,
-			providerKey:         &baseObjects.provider,
-			layerKey:            cFWPM_LAYER_ALE_AUTH_CONNECT_V4,
-			subLayerKey:         baseObjects.filters,
-			weight:              filterWeight(weight),
-			numFilterConditions: uint32(len(conditions)),
-			filterCondition:     (*wtFwpmFilterCondition0)(unsafe.Pointer(&conditions)),
-			action: wtFwpmAction0{
-				_type: cFWP_ACTION_PERMIT,
+func permitDHCPIPv4(weight uint8) []Rule {
+	return []Rule{
+		//
+		// #1 Outbound DHCP request on IPv4.
+		//
+		{
+			Name:   "Permit outbound DHCP request (IPv4)",
+			Layer:  LayerConnectV4,
+			Action: ActionPermit,
+			Weight: weight,
+			Conditions: []Condition{
+				{FieldProtocol, MatchEqual, uint8(cIPPROTO_UDP)},
+				{FieldLocalPort, MatchEqual, uint16(68)},
+				{FieldRemotePort, MatchEqual, uint16(67)},
+				{FieldRemoteAddress, MatchEqual, limitedBroadcast},
 			},
-		}
-
-		filterID := uint64(0)
-
-		err = fwpmFilterAdd0(session, &filter, 0, &filterID)
-		if err != nil {
-			return wrapErr(err)
-		}
-	}
-
-	//
-	// #2 Inbound DHCP response on IPv4.
-	//
-	{
-		var conditions [3]wtFwpmFilterCondition0
-
-		conditions[0].fieldKey = cFWPM_CONDITION_IP_PROTOCOL
-		conditions[0].matchType = cFWP_MATCH_EQUAL
-		conditions[0].conditionValue._type = cFWP_UINT8
-		conditions[0].conditionValue.value = uintptr(cIPPROTO_UDP)
-
-		conditions[1].fieldKey = cFWPM_CONDITION_IP_LOCAL_PORT
-		conditions[1].matchType = cFWP_MATCH_EQUAL
-		conditions[1].conditionValue._type = cFWP_UINT16
-		conditions[1].conditionValue.value = uintptr(68)
-
-		conditions[2].fieldKey = cFWPM_CONDITION_IP_REMOTE_PORT
-		conditions[2].matchType = cFWP_MATCH_EQUAL
-		conditions[2].conditionValue._type = cFWP_UINT16
-		conditions[2].conditionValue.value = uintptr(67)
-
-		displayData, err := createWtFwpmDisplayData0("Permit inbound DHCP response (IPv4)", "")
-		if err != nil {
-			return wrapErr(err)
-		}
+		},
 
-		filter := wtFwpmFilter0{
-			displayData:         *displayData,
-			providerKey:         &baseObjects.provider,
-			layerKey:            cFWPM_LAYER_ALE_AUTH_RECV_ACCEPT_V4,
-			subLayerKey:         baseObjects.filters,
-			weight:              filterWeight(weight),
-			numFilterConditions: uint32(len(conditions)),
-			filterCondition:     (*wtFwpmFilterCondition0)(unsafe.Pointer(&conditions)),
-			action: wtFwpmAction0{
-				_type: cFWP_ACTION_PERMIT,
+		//
+		// #2 Inbound DHCP response on IPv4.
+		//
+		{
+			Name:   "Permit inbound DHCP response (IPv4)",
+			Layer:  LayerRecvAcceptV4,
+			Action: ActionPermit,
+			Weight: weight,
+			Conditions: []Condition{
+				{FieldProtocol, MatchEqual, uint8(cIPPROTO_UDP)},
+				{FieldLocalPort, MatchEqual, uint16(68)},
+				{FieldRemotePort, MatchEqual, uint16(67)},
 			},
-		}
-
-		filterID := uint64(0)
-
-		err = fwpmFilterAdd0(session, &filter, 0, &filterID)
-		if err != nil {
-			return wrapErr(err)
-		}
+		},
 	}
-
-	return nil
 }
 
-func permitDHCPIPv6(session uintptr, baseObjects *baseObjects, weight uint8) error {
-	//
-	// #1 Outbound DHCP request on IPv6.
-	//
-	{
-		var conditions [6]wtFwpmFilterCondition0
-
-		conditions[0].fieldKey = cFWPM_CONDITION_IP_PROTOCOL
-		conditions[0].matchType = cFWP_MATCH_EQUAL
-		conditions[0].conditionValue._type = cFWP_UINT8
-		conditions[0].conditionValue.value = uintptr(cIPPROTO_UDP)
-
-		conditions[1].fieldKey = cFWPM_CONDITION_IP_REMOTE_ADDRESS
-		conditions[1].matchType = cFWP_MATCH_EQUAL
-		conditions[1].conditionValue._type = cFWP_BYTE_ARRAY16_TYPE
-		conditions[1].conditionValue.value = uintptr(unsafe.Pointer(&linkLocalDHCPMulticast))
-
-		// Repeat the condition type for logical OR.
-		conditions[2].fieldKey = cFWPM_CONDITION_IP_REMOTE_ADDRESS
-		conditions[2].matchType = cFWP_MATCH_EQUAL
-		conditions[2].conditionValue._type = cFWP_BYTE_ARRAY16_TYPE
-		conditions[2].conditionValue.value = uintptr(unsafe.Pointer(&siteLocalDHCPMulticast))
-
-		conditions[3].fieldKey = cFWPM_CONDITION_IP_REMOTE_PORT
-		conditions[3].matchType = cFWP_MATCH_EQUAL
-		conditions[3].conditionValue._type = cFWP_UINT16
-		conditions[3].conditionValue.value = uintptr(547)
-
-		conditions[4].fieldKey = cFWPM_CONDITION_IP_LOCAL_ADDRESS
-		conditions[4].matchType = cFWP_MATCH_EQUAL
-		conditions[4].conditionValue._type = cFWP_V6_ADDR_MASK
-		conditions[4].conditionValue.value = uintptr(unsafe.Pointer(&linkLocal))
-
-		conditions[5].fieldKey = cFWPM_CONDITION_IP_LOCAL_PORT
-		conditions[5].matchType = cFWP_MATCH_EQUAL
-		conditions[5].conditionValue._type = cFWP_UINT16
-		conditions[5].conditionValue.value = uintptr(546)
-
-		displayData, err := createWtFwpmDisplayData0("Permit outbound DHCP request (IPv6)", "")
-		if err != nil {
-			return wrapErr(err)
-		}
-
-		filter := wtFwpmFilter0{
-			displayData:         *displayData,
-			providerKey:         &baseObjects.provider,
-			layerKey:            cFWPM_LAYER_ALE_AUTH_CONNECT_V6,
-			subLayerKey:         baseObjects.filters,
-			weight:              filterWeight(weight),
-			numFilterConditions: uint32(len(conditions)),
-			filterCondition:     (*wtFwpmFilterCondition0)(unsafe.Pointer(&conditions)),
-			action: wtFwpmAction0{
-				_type: cFWP_ACTION_PERMIT,
+func permitDHCPIPv6(weight uint8) []Rule {
+	return []Rule{
+		//
+		// #1 Outbound DHCP request on IPv6.
+		//
+		{
+			Name:   "Permit outbound DHCP request (IPv6)",
+			Layer:  LayerConnectV6,
+			Action: ActionPermit,
+			Weight: weight,
+			Conditions: []Condition{
+				{FieldProtocol, MatchEqual, uint8(cIPPROTO_UDP)},
+				{FieldRemoteAddress, MatchEqual, linkLocalDHCPMulticast},
+				// Repeat the condition type for logical OR.
+				{FieldRemoteAddress, MatchEqual, siteLocalDHCPMulticast},
+				{FieldRemotePort, MatchEqual, uint16(547)},
+				{FieldLocalAddress, MatchEqual, linkLocal},
+				{FieldLocalPort, MatchEqual, uint16(546)},
 			},
-		}
-
-		filterID := uint64(0)
-
-		err = fwpmFilterAdd0(session, &filter, 0, &filterID)
-		if err != nil {
-			return wrapErr(err)
-		}
-	}
-
-	//
-	// #2 Inbound DHCP response on IPv6.
-	//
-	{
-		var conditions [5]wtFwpmFilterCondition0
-
-		conditions[0].fieldKey = cFWPM_CONDITION_IP_PROTOCOL
-		conditions[0].matchType = cFWP_MATCH_EQUAL
-		conditions[0].conditionValue._type = cFWP_UINT8
-		conditions[0].conditionValue.value = uintptr(cIPPROTO_UDP)
-
-		conditions[1].fieldKey = cFWPM_CONDITION_IP_REMOTE_ADDRESS
-		conditions[1].matchType = cFWP_MATCH_EQUAL
-		conditions[1].conditionValue._type = cFWP_V6_ADDR_MASK
-		conditions[1].conditionValue.value = uintptr(unsafe.Pointer(&linkLocal))
-
-		conditions[2].fieldKey = cFWPM_CONDITION_IP_REMOTE_PORT
-		conditions[2].matchType = cFWP_MATCH_EQUAL
-		conditions[2].conditionValue._type = cFWP_UINT16
-		conditions[2].conditionValue.value = uintptr(547)
-
-		conditions[3].fieldKey = cFWPM_CONDITION_IP_LOCAL_ADDRESS
-		conditions[3].matchType = cFWP_MATCH_EQUAL
-		conditions[3].conditionValue._type = cFWP_V6_ADDR_MASK
-		conditions[3].conditionValue.value = uintptr(unsafe.Pointer(&linkLocal))
-
-		conditions[4].fieldKey = cFWPM_CONDITION_IP_LOCAL_PORT
-		conditions[4].matchType = cFWP_MATCH_EQUAL
-		conditions[4].conditionValue._type = cFWP_UINT16
-		conditions[4].conditionValue.value = uintptr(546)
-
-		displayData, err := createWtFwpmDisplayData0("Permit inbound DHCP response (IPv6)", "")
-		if err != nil {
-			return wrapErr(err)
-		}
+		},
 
-		filter := wtFwpmFilter0{
-			displayData:         *displayData,
-			providerKey:         &baseObjects.provider,
-			layerKey:            cFWPM_LAYER_ALE_AUTH_RECV_ACCEPT_V6,
-			subLayerKey:         baseObjects.filters,
-			weight:              filterWeight(weight),
-			numFilterConditions: uint32(len(conditions)),
-			filterCondition:     (*wtFwpmFilterCondition0)(unsafe.Pointer(&conditions)),
-			action: wtFwpmAction0{
-				_type: cFWP_ACTION_PERMIT,
+		//
+		// #2 Inbound DHCP response on IPv6.
+		//
+		{
+			Name:   "Permit inbound DHCP response (IPv6)",
+			Layer:  LayerRecvAcceptV6,
+			Action: ActionPermit,
+			Weight: weight,
+			Conditions: []Condition{
+				{FieldProtocol, MatchEqual, uint8(cIPPROTO_UDP)},
+				{FieldRemoteAddress, MatchEqual, linkLocal},
+				{FieldRemotePort, MatchEqual, uint16(547)},
+				{FieldLocalAddress, MatchEqual, linkLocal},
+				{FieldLocalPort, MatchEqual, uint16(546)},
 			},
-		}
-
-		filterID := uint64(0)
-
-		err = fwpmFilterAdd0(session, &filter, 0, &filterID)
-		if err != nil {
-			return wrapErr(err)
-		}
+		},
 	}
-
-	return nil
 }
 
-func permitNdp(session uintptr, baseObjects *baseObjects, weight uint8) error {
+func permitNdp(weight uint8) []Rule {
 	/* TODO: actually handle the hop limit somehow! The rules should vaguely be:
 	 *  - icmpv6 133: must be outgoing, dst must be FF02::2/128, hop limit must be 255
 	 *  - icmpv6 134: must be incoming, src must be FE80::/10, hop limit must be 255
@@ -592,630 +160,458 @@ func permitNdp(session uintptr, baseObjects *baseObjects, weight uint8) error {
 	 *  - icmpv6 137: must be incoming, src must be FE80::/10, hop limit must be 255
 	 */
 
-	type filterDefinition struct {
-		displayData *wtFwpmDisplayData0
-		conditions  []wtFwpmFilterCondition0
-		layer       windows.GUID
+	icmpRule := func(icmpType uint16, layer Layer, extra ...Condition) Rule {
+		return Rule{
+			Name:   "Permit NDP type " + strconv.Itoa(int(icmpType)),
+			Layer:  layer,
+			Action: ActionPermit,
+			Weight: weight,
+			Conditions: append([]Condition{
+				{FieldProtocol, MatchEqual, uint8(cIPPROTO_ICMPV6)},
+				{FieldICMPType, MatchEqual, icmpType},
+				{FieldICMPCode, MatchEqual, uint16(0)},
+			}, extra...),
+		}
+	}
+
+	return []Rule{
+		//
+		// Router Solicitation Message
+		// ICMP type 133, code 0. Outgoing.
+		//
+		icmpRule(133, LayerConnectV6, Condition{FieldRemoteAddress, MatchEqual, linkLocalRouterMulticast}),
+
+		//
+		// Router Advertisement Message
+		// ICMP type 134, code 0. Incoming.
+		//
+		icmpRule(134, LayerRecvAcceptV6, Condition{FieldRemoteAddress, MatchEqual, linkLocal}),
+
+		//
+		// Neighbor Solicitation Message
+		// ICMP type 135, code 0. Bi-directional.
+		//
+		icmpRule(135, LayerConnectV6),
+		icmpRule(135, LayerRecvAcceptV6),
+
+		//
+		// Neighbor Advertisement Message
+		// ICMP type 136, code 0. Bi-directional.
+		//
+		icmpRule(136, LayerConnectV6),
+		icmpRule(136, LayerRecvAcceptV6),
+
+		//
+		// Redirect Message
+		// ICMP type 137, code 0. Incoming.
+		//
+		icmpRule(137, LayerRecvAcceptV6, Condition{FieldRemoteAddress, MatchEqual, linkLocal}),
 	}
+}
 
-	var defs []filterDefinition
-
-	//
-	// Router Solicitation Message
-	// ICMP type 133, code 0. Outgoing.
-	//
-	{
-		conditions := make([]wtFwpmFilterCondition0, 4)
-
-		conditions[0].fieldKey = cFWPM_CONDITION_IP_PROTOCOL
-		conditions[0].matchType = cFWP_MATCH_EQUAL
-		conditions[0].conditionValue._type = cFWP_UINT8
-		conditions[0].conditionValue.value = uintptr(cIPPROTO_ICMPV6)
-
-		conditions[1].fieldKey = cFWPM_CONDITION_ICMP_TYPE
-		conditions[1].matchType = cFWP_MATCH_EQUAL
-		conditions[1].conditionValue._type = cFWP_UINT16
-		conditions[1].conditionValue.value = uintptr(133)
-
-		conditions[2].fieldKey = cFWPM_CONDITION_ICMP_CODE
-		conditions[2].matchType = cFWP_MATCH_EQUAL
-		conditions[2].conditionValue._type = cFWP_UINT16
-		conditions[2].conditionValue.value = uintptr(0)
-
-		conditions[3].fieldKey = cFWPM_CONDITION_IP_REMOTE_ADDRESS
-		conditions[3].matchType = cFWP_MATCH_EQUAL
-		conditions[3].conditionValue._type = cFWP_BYTE_ARRAY16_TYPE
-		conditions[3].conditionValue.value = uintptr(unsafe.Pointer(&linkLocalRouterMulticast))
-
-		displayData, err := createWtFwpmDisplayData0("Permit NDP type 133", "")
-		if err != nil {
-			return wrapErr(err)
-		}
-
-		defs = append(defs, filterDefinition{
-			displayData: displayData,
-			conditions:  conditions,
-			layer:       cFWPM_LAYER_ALE_AUTH_CONNECT_V6,
-		})
-	}
-
-	//
-	// Router Advertisement Message
-	// ICMP type 134, code 0. Incoming.
-	//
-	{
-		conditions := make([]wtFwpmFilterCondition0, 4)
-
-		conditions[0].fieldKey = cFWPM_CONDITION_IP_PROTOCOL
-		conditions[0].matchType = cFWP_MATCH_EQUAL
-		conditions[0].conditionValue._type = cFWP_UINT8
-		conditions[0].conditionValue.value = uintptr(cIPPROTO_ICMPV6)
-
-		conditions[1].fieldKey = cFWPM_CONDITION_ICMP_TYPE
-		conditions[1].matchType = cFWP_MATCH_EQUAL
-		conditions[1].conditionValue._type = cFWP_UINT16
-		conditions[1].conditionValue.value = uintptr(134)
-
-		conditions[2].fieldKey = cFWPM_CONDITION_ICMP_CODE
-		conditions[2].matchType = cFWP_MATCH_EQUAL
-		conditions[2].conditionValue._type = cFWP_UINT16
-		conditions[2].conditionValue.value = uintptr(0)
-
-		conditions[3].fieldKey = cFWPM_CONDITION_IP_REMOTE_ADDRESS
-		conditions[3].matchType = cFWP_MATCH_EQUAL
-		conditions[3].conditionValue._type = cFWP_V6_ADDR_MASK
-		conditions[3].conditionValue.value = uintptr(unsafe.Pointer(&linkLocal))
-
-		displayData, err := createWtFwpmDisplayData0("Permit NDP type 134", "")
-		if err != nil {
-			return wrapErr(err)
-		}
-
-		defs = append(defs, filterDefinition{
-			displayData: displayData,
-			conditions:  conditions,
-			layer:       cFWPM_LAYER_ALE_AUTH_RECV_ACCEPT_V6,
-		})
-	}
-
-	//
-	// Neighbor Solicitation Message
-	// ICMP type 135, code 0. Bi-directional.
-	//
-	{
-		conditions := make([]wtFwpmFilterCondition0, 3)
-
-		conditions[0].fieldKey = cFWPM_CONDITION_IP_PROTOCOL
-		conditions[0].matchType = cFWP_MATCH_EQUAL
-		conditions[0].conditionValue._type = cFWP_UINT8
-		conditions[0].conditionValue.value = uintptr(cIPPROTO_ICMPV6)
-
-		conditions[1].fieldKey = cFWPM_CONDITION_ICMP_TYPE
-		conditions[1].matchType = cFWP_MATCH_EQUAL
-		conditions[1].conditionValue._type = cFWP_UINT16
-		conditions[1].conditionValue.value = uintptr(135)
-
-		conditions[2].fieldKey = cFWPM_CONDITION_ICMP_CODE
-		conditions[2].matchType = cFWP_MATCH_EQUAL
-		conditions[2].conditionValue._type = cFWP_UINT16
-		conditions[2].conditionValue.value = uintptr(0)
-
-		displayData, err := createWtFwpmDisplayData0("Permit NDP type 135", "")
-		if err != nil {
-			return wrapErr(err)
-		}
-
-		defs = append(defs, filterDefinition{
-			displayData: displayData,
-			conditions:  conditions,
-			layer:       cFWPM_LAYER_ALE_AUTH_CONNECT_V6,
-		})
-
-		defs = append(defs, filterDefinition{
-			displayData: displayData,
-			conditions:  conditions,
-			layer:       cFWPM_LAYER_ALE_AUTH_RECV_ACCEPT_V6,
-		})
-	}
-
-	//
-	// Neighbor Advertisement Message
-	// ICMP type 136, code 0. Bi-directional.
-	//
-	{
-		conditions := make([]wtFwpmFilterCondition0, 3)
-
-		conditions[0].fieldKey = cFWPM_CONDITION_IP_PROTOCOL
-		conditions[0].matchType = cFWP_MATCH_EQUAL
-		conditions[0].conditionValue._type = cFWP_UINT8
-		conditions[0].conditionValue.value = uintptr(cIPPROTO_ICMPV6)
-
-		conditions[1].fieldKey = cFWPM_CONDITION_ICMP_TYPE
-		conditions[1].matchType = cFWP_MATCH_EQUAL
-		conditions[1].conditionValue._type = cFWP_UINT16
-		conditions[1].conditionValue.value = uintptr(136)
-
-		conditions[2].fieldKey = cFWPM_CONDITION_ICMP_CODE
-		conditions[2].matchType = cFWP_MATCH_EQUAL
-		conditions[2].conditionValue._type = cFWP_UINT16
-		conditions[2].conditionValue.value = uintptr(0)
-
-		displayData, err := createWtFwpmDisplayData0("Permit NDP type 136", "")
-		if err != nil {
-			return wrapErr(err)
-		}
-
-		defs = append(defs, filterDefinition{
-			displayData: displayData,
-			conditions:  conditions,
-			layer:       cFWPM_LAYER_ALE_AUTH_CONNECT_V6,
-		})
-
-		defs = append(defs, filterDefinition{
-			displayData: displayData,
-			conditions:  conditions,
-			layer:       cFWPM_LAYER_ALE_AUTH_RECV_ACCEPT_V6,
-		})
-	}
-
-	//
-	// Redirect Message
-	// ICMP type 137, code 0. Incoming.
-	//
-	{
-		conditions := make([]wtFwpmFilterCondition0, 4)
-
-		conditions[0].fieldKey = cFWPM_CONDITION_IP_PROTOCOL
-		conditions[0].matchType = cFWP_MATCH_EQUAL
-		conditions[0].conditionValue._type = cFWP_UINT8
-		conditions[0].conditionValue.value = uintptr(cIPPROTO_ICMPV6)
-
-		conditions[1].fieldKey = cFWPM_CONDITION_ICMP_TYPE
-		conditions[1].matchType = cFWP_MATCH_EQUAL
-		conditions[1].conditionValue._type = cFWP_UINT16
-		conditions[1].conditionValue.value = uintptr(137)
-
-		conditions[2].fieldKey = cFWPM_CONDITION_ICMP_CODE
-		conditions[2].matchType = cFWP_MATCH_EQUAL
-		conditions[2].conditionValue._type = cFWP_UINT16
-		conditions[2].conditionValue.value = uintptr(0)
-
-		conditions[3].fieldKey = cFWPM_CONDITION_IP_REMOTE_ADDRESS
-		conditions[3].matchType = cFWP_MATCH_EQUAL
-		conditions[3].conditionValue._type = cFWP_V6_ADDR_MASK
-		conditions[3].conditionValue.value = uintptr(unsafe.Pointer(&linkLocal))
-
-		displayData, err := createWtFwpmDisplayData0("Permit NDP type 137", "")
-		if err != nil {
-			return wrapErr(err)
-		}
-
-		defs = append(defs, filterDefinition{
-			displayData: displayData,
-			conditions:  conditions,
-			layer:       cFWPM_LAYER_ALE_AUTH_RECV_ACCEPT_V6,
-		})
-	}
+func permitHyperV(weight uint8) []Rule {
+	condition := Condition{FieldL2Flags, MatchEqual, uint32(cFWP_CONDITION_L2_IS_VM2VM)}
+	return []Rule{
+		//
+		// #1 Outbound.
+		//
+		{
+			Name:       "Permit Hyper-V => Hyper-V outbound",
+			Layer:      LayerOutboundMACFrameNative,
+			Action:     ActionPermit,
+			Weight:     weight,
+			Conditions: []Condition{condition},
+		},
 
-	filter := wtFwpmFilter0{
-		providerKey: &baseObjects.provider,
-		subLayerKey: baseObjects.filters,
-		weight:      filterWeight(weight),
-		action: wtFwpmAction0{
-			_type: cFWP_ACTION_PERMIT,
+		//
+		// #2 Inbound.
+		//
+		{
+			Name:       "Permit Hyper-V => Hyper-V inbound",
+			Layer:      LayerInboundMACFrameNative,
+			Action:     ActionPermit,
+			Weight:     weight,
+			Conditions: []Condition{condition},
 		},
 	}
+}
 
-	filterID := uint64(0)
-
-	for _, definition := range defs {
-		filter.displayData = *definition.displayData
-		filter.layerKey = definition.layer
-		filter.numFilterConditions = uint32(len(definition.conditions))
-		filter.filterCondition = (*wtFwpmFilterCondition0)(unsafe.Pointer(&definition.conditions[0]))
+// Permit the traffic between virtual machines, and between the host and its Hyper-V virtual adapters, which
+// includes the DNS queries that WSL2 sends to the host's DNS proxy.
+func permitVirtualMachines(weight uint8, policy *VirtualMachinePolicy) []Rule {
+	rules := permitHyperV(weight)
+	if len(policy.Adapters) == 0 {
+		return rules
+	}
+	conditions := make([]Condition, 0, len(policy.Adapters))
+	for _, luid := range policy.Adapters {
+		conditions = append(conditions, Condition{FieldLocalInterface, MatchEqual, luid})
+	}
+	return append(rules, ipLayers("Permit %s IPv%d traffic on Hyper-V virtual adapters", Rule{
+		Action:     ActionPermit,
+		Weight:     weight,
+		Conditions: conditions,
+	})...)
+}
 
-		err := fwpmFilterAdd0(session, &filter, 0, &filterID)
-		if err != nil {
-			return wrapErr(err)
-		}
+// Block the traffic that arrives on the Hyper-V virtual adapters from being forwarded by the host's NAT,
+// except to the tunnel interface. The kill-switch's other rules only apply to the host's own traffic.
+func forwardVirtualMachinesToTunnel(weightAllow, weightDeny uint8, ifLUID uint64, policy *VirtualMachinePolicy) ([]Rule, error) {
+	if weightDeny >= weightAllow {
+		return nil, errors.New("The allow weight must be greater than the deny weight")
+	}
+	if len(policy.Adapters) == 0 {
+		return nil, nil
+	}
+
+	conditions := make([]Condition, 0, len(policy.Adapters))
+	for _, luid := range policy.Adapters {
+		conditions = append(conditions, Condition{FieldLocalInterface, MatchEqual, luid})
+	}
+	var rules []Rule
+	for _, layer := range [...]struct {
+		layer   Layer
+		version int
+	}{
+		{LayerForwardV4, 4},
+		{LayerForwardV6, 6},
+	} {
+		rules = append(rules, Rule{
+			Name:       fmt.Sprintf("Permit forwarded IPv%d traffic from Hyper-V virtual adapters to TUN", layer.version),
+			Layer:      layer.layer,
+			Action:     ActionPermit,
+			Weight:     weightAllow,
+			Conditions: append(slices.Clip(conditions), Condition{FieldForwardInterface, MatchEqual, ifLUID}),
+		}, Rule{
+			Name:       fmt.Sprintf("Block forwarded IPv%d traffic from Hyper-V virtual adapters", layer.version),
+			Layer:      layer.layer,
+			Action:     ActionBlock,
+			Weight:     weightDeny,
+			Conditions: conditions,
+		})
 	}
+	return rules, nil
+}
 
-	return nil
+// Permit the DNS, HTTP, and HTTPS traffic needed to sign into a captive portal, both to the given networks,
+// which are those of the physical network, and, if a browser is given, of that browser to any address.
+func permitCaptivePortal(weight uint8, networks []netip.Prefix, browser AppID) []Rule {
+	portConditions := []Condition{
+		{FieldProtocol, MatchEqual, uint8(cIPPROTO_TCP)},
+		{FieldProtocol, MatchEqual, uint8(cIPPROTO_UDP)},
+		{FieldRemotePort, MatchEqual, uint16(53)},
+		{FieldRemotePort, MatchEqual, uint16(80)},
+		{FieldRemotePort, MatchEqual, uint16(443)},
+	}
+
+	var rules []Rule
+	for _, layer := range [...]struct {
+		layer   Layer
+		version int
+	}{
+		{LayerConnectV4, 4},
+		{LayerConnectV6, 6},
+	} {
+		networkConditions := slices.Clip(portConditions)
+		for _, network := range networks {
+			if network.Addr().Is4() == (layer.version == 4) {
+				networkConditions = append(networkConditions, Condition{FieldRemoteAddress, MatchEqual, network})
+			}
+		}
+		if len(networkConditions) > len(portConditions) {
+			rules = append(rules, Rule{
+				Name:       fmt.Sprintf("Permit captive portal traffic to local network (IPv%d)", layer.version),
+				Layer:      layer.layer,
+				Action:     ActionPermit,
+				Weight:     weight,
+				Conditions: networkConditions,
+			})
+		}
+		if len(browser) > 0 {
+			rules = append(rules, Rule{
+				Name:       fmt.Sprintf("Permit captive portal traffic for browser (IPv%d)", layer.version),
+				Layer:      layer.layer,
+				Action:     ActionPermit,
+				Weight:     weight,
+				Conditions: append([]Condition{{FieldAppID, MatchEqual, browser}}, portConditions...),
+			})
+		}
+	}
+	return rules
 }
 
-func permitHyperV(session uintptr, baseObjects *baseObjects, weight uint8) error {
-	condition := wtFwpmFilterCondition0{
-		fieldKey:  cFWPM_CONDITION_L2_FLAGS,
-		matchType: cFWP_MATCH_EQUAL,
-		conditionValue: wtFwpConditionValue0{
-			_type: cFWP_UINT32,
-			value: uintptr(cFWP_CONDITION_L2_IS_VM2VM),
+// Block inbound traffic on the tunnel interface, except what is permitted by the policy's rules.
+func blockInbound(weightAllow, weightDeny uint8, ifLUID uint64, policy *InboundPolicy) ([]Rule, error) {
+	if weightDeny >= weightAllow {
+		return nil, errors.New("The allow weight must be greater than the deny weight")
+	}
+
+	var rules []Rule
+	for i, allowed := range policy.Allow {
+		for _, layer := range [...]struct {
+			layer   Layer
+			version int
+			icmp    wtIPProto
+		}{
+			{LayerRecvAcceptV4, 4, cIPPROTO_ICMP},
+			{LayerRecvAcceptV6, 6, cIPPROTO_ICMPV6},
+		} {
+			rule := Rule{
+				Name:   fmt.Sprintf("Permit inbound traffic on TUN by rule %d (IPv%d)", i+1, layer.version),
+				Layer:  layer.layer,
+				Action: ActionPermit,
+				Weight: weightAllow,
+				Conditions: []Condition{
+					{FieldLocalInterface, MatchEqual, ifLUID},
+				},
+			}
+			if allowed.Protocol == uint8(cIPPROTO_ICMP) {
+				rule.Conditions = append(rule.Conditions, Condition{FieldProtocol, MatchEqual, uint8(layer.icmp)})
+			} else if allowed.Protocol != 0 {
+				rule.Conditions = append(rule.Conditions, Condition{FieldProtocol, MatchEqual, allowed.Protocol})
+			}
+			if allowed.Ports.Low == allowed.Ports.High && allowed.Ports.Low != 0 {
+				rule.Conditions = append(rule.Conditions, Condition{FieldLocalPort, MatchEqual, allowed.Ports.Low})
+			} else if allowed.Ports.Low != allowed.Ports.High {
+				rule.Conditions = append(rule.Conditions, Condition{FieldLocalPort, MatchRange, allowed.Ports})
+			}
+			if len(allowed.From) > 0 {
+				sameFamily := false
+				for _, prefix := range allowed.From {
+					if (layer.version == 4) == prefix.Addr().Is4() {
+						// Repeat the condition type for logical OR.
+						rule.Conditions = append(rule.Conditions, Condition{FieldRemoteAddress, MatchEqual, prefix})
+						sameFamily = true
+					}
+				}
+				if !sameFamily {
+					continue
+				}
+			}
+			rules = append(rules, rule)
+		}
+	}
+
+	rules = append(rules, Rule{
+		Name:   "Block inbound traffic on TUN (IPv4)",
+		Layer:  LayerRecvAcceptV4,
+		Action: ActionBlock,
+		Weight: weightDeny,
+		Conditions: []Condition{
+			{FieldLocalInterface, MatchEqual, ifLUID},
 		},
-	}
-
-	filter := wtFwpmFilter0{
-		providerKey:         &baseObjects.provider,
-		subLayerKey:         baseObjects.filters,
-		weight:              filterWeight(weight),
-		numFilterConditions: 1,
-		filterCondition:     (*wtFwpmFilterCondition0)(unsafe.Pointer(&condition)),
-		action: wtFwpmAction0{
-			_type: cFWP_ACTION_PERMIT,
+	}, Rule{
+		Name:   "Block inbound traffic on TUN (IPv6)",
+		Layer:  LayerRecvAcceptV6,
+		Action: ActionBlock,
+		Weight: weightDeny,
+		Conditions: []Condition{
+			{FieldLocalInterface, MatchEqual, ifLUID},
 		},
-	}
-
-	filterID := uint64(0)
-
-	//
-	// #1 Outbound.
-	//
-	{
-		displayData, err := createWtFwpmDisplayData0("Permit Hyper-V => Hyper-V outbound", "")
-		if err != nil {
-			return wrapErr(err)
-		}
-
-		filter.displayData = *displayData
-		filter.layerKey = cFWPM_LAYER_OUTBOUND_MAC_FRAME_NATIVE
-
-		err = fwpmFilterAdd0(session, &filter, 0, &filterID)
-		if err != nil {
-			return wrapErr(err)
-		}
-	}
-
-	//
-	// #2 Inbound.
-	//
-	{
-		displayData, err := createWtFwpmDisplayData0("Permit Hyper-V => Hyper-V inbound", "")
-		if err != nil {
-			return wrapErr(err)
-		}
-
-		filter.displayData = *displayData
-		filter.layerKey = cFWPM_LAYER_INBOUND_MAC_FRAME_NATIVE
-
-		err = fwpmFilterAdd0(session, &filter, 0, &filterID)
-		if err != nil {
-			return wrapErr(err)
-		}
-	}
-
-	return nil
+	})
+	return rules, nil
 }
 
 // Block all traffic except what is explicitly permitted by other rules.
-func blockAll(session uintptr, baseObjects *baseObjects, weight uint8) error {
-	filter := wtFwpmFilter0{
-		providerKey: &baseObjects.provider,
-		subLayerKey: baseObjects.filters,
-		weight:      filterWeight(weight),
-		action: wtFwpmAction0{
-			_type: cFWP_ACTION_BLOCK,
-		},
-	}
-
-	filterID := uint64(0)
-
-	//
-	// #1 Block outbound traffic on IPv4.
-	//
-	{
-		displayData, err := createWtFwpmDisplayData0("Block all outbound (IPv4)", "")
-		if err != nil {
-			return wrapErr(err)
-		}
-
-		filter.displayData = *displayData
-		filter.layerKey = cFWPM_LAYER_ALE_AUTH_CONNECT_V4
-
-		err = fwpmFilterAdd0(session, &filter, 0, &filterID)
-		if err != nil {
-			return wrapErr(err)
-		}
-	}
-
-	//
-	// #2 Block inbound traffic on IPv4.
-	//
-	{
-		displayData, err := createWtFwpmDisplayData0("Block all inbound (IPv4)", "")
-		if err != nil {
-			return wrapErr(err)
-		}
-
-		filter.displayData = *displayData
-		filter.layerKey = cFWPM_LAYER_ALE_AUTH_RECV_ACCEPT_V4
-
-		err = fwpmFilterAdd0(session, &filter, 0, &filterID)
-		if err != nil {
-			return wrapErr(err)
-		}
-	}
-
-	//
-	// #3 Block outbound traffic on IPv6.
-	//
-	{
-		displayData, err := createWtFwpmDisplayData0("Block all outbound (IPv6)", "")
-		if err != nil {
-			return wrapErr(err)
-		}
-
-		filter.displayData = *displayData
-		filter.layerKey = cFWPM_LAYER_ALE_AUTH_CONNECT_V6
-
-		err = fwpmFilterAdd0(session, &filter, 0, &filterID)
-		if err != nil {
-			return wrapErr(err)
-		}
-	}
-
-	//
-	// #4 Block inbound traffic on IPv6.
-	//
-	{
-		displayData, err := createWtFwpmDisplayData0("Block all inbound (IPv6)", "")
-		if err != nil {
-			return wrapErr(err)
-		}
-
-		filter.displayData = *displayData
-		filter.layerKey = cFWPM_LAYER_ALE_AUTH_RECV_ACCEPT_V6
-
-		err = fwpmFilterAdd0(session, &filter, 0, &filterID)
-		if err != nil {
-			return wrapErr(err)
-		}
-	}
-
-	return nil
+func blockAll(weight uint8) []Rule {
+	return ipLayers("Block all %s (IPv%d)", Rule{
+		Action: ActionBlock,
+		Weight: weight,
+	})
 }
 
-// Block all DNS traffic except towards specified DNS servers.
-func blockDNS(except []netip.Addr, session uintptr, baseObjects *baseObjects, weightAllow, weightDeny uint8) error {
+// Block all traffic to the given port except towards specified servers.
+func blockPort(blockName, allowName string, port uint16, except []netip.Addr, weightAllow, weightDeny uint8) ([]Rule, error) {
 	if weightDeny >= weightAllow {
-		return errors.New("The allow weight must be greater than the deny weight")
+		return nil, errors.New("The allow weight must be greater than the deny weight")
 	}
 
-	denyConditions := []wtFwpmFilterCondition0{
-		{
-			fieldKey:  cFWPM_CONDITION_IP_REMOTE_PORT,
-			matchType: cFWP_MATCH_EQUAL,
-			conditionValue: wtFwpConditionValue0{
-				_type: cFWP_UINT16,
-				value: uintptr(53),
-			},
-		},
-		{
-			fieldKey:  cFWPM_CONDITION_IP_PROTOCOL,
-			matchType: cFWP_MATCH_EQUAL,
-			conditionValue: wtFwpConditionValue0{
-				_type: cFWP_UINT8,
-				value: uintptr(cIPPROTO_UDP),
-			},
-		},
+	denyConditions := []Condition{
+		{FieldRemotePort, MatchEqual, port},
+		{FieldProtocol, MatchEqual, uint8(cIPPROTO_UDP)},
 		// Repeat the condition type for logical OR.
-		{
-			fieldKey:  cFWPM_CONDITION_IP_PROTOCOL,
-			matchType: cFWP_MATCH_EQUAL,
-			conditionValue: wtFwpConditionValue0{
-				_type: cFWP_UINT8,
-				value: uintptr(cIPPROTO_TCP),
-			},
-		},
-	}
-
-	filter := wtFwpmFilter0{
-		providerKey:         &baseObjects.provider,
-		subLayerKey:         baseObjects.filters,
-		weight:              filterWeight(weightDeny),
-		numFilterConditions: uint32(len(denyConditions)),
-		filterCondition:     (*wtFwpmFilterCondition0)(unsafe.Pointer(&denyConditions[0])),
-		action: wtFwpmAction0{
-			_type: cFWP_ACTION_BLOCK,
-		},
+		{FieldProtocol, MatchEqual, uint8(cIPPROTO_TCP)},
 	}
 
-	filterID := uint64(0)
-
-	//
-	// #1 Block IPv4 outbound DNS.
-	//
-	{
-		displayData, err := createWtFwpmDisplayData0("Block DNS outbound (IPv4)", "")
-		if err != nil {
-			return wrapErr(err)
-		}
-
-		filter.displayData = *displayData
-		filter.layerKey = cFWPM_LAYER_ALE_AUTH_CONNECT_V4
+	rules := ipLayers(blockName, Rule{
+		Action:     ActionBlock,
+		Weight:     weightDeny,
+		Conditions: denyConditions,
+	})
 
-		err = fwpmFilterAdd0(session, &filter, 0, &filterID)
-		if err != nil {
-			return wrapErr(err)
+	var allowConditionsV4, allowConditionsV6 []Condition
+	for _, ip := range except {
+		if ip.Is4() {
+			allowConditionsV4 = append(allowConditionsV4, Condition{FieldRemoteAddress, MatchEqual, ip})
+		} else if ip.Is6() {
+			allowConditionsV6 = append(allowConditionsV6, Condition{FieldRemoteAddress, MatchEqual, ip})
 		}
 	}
 
-	//
-	// #2 Block IPv4 inbound DNS.
-	//
-	{
-		displayData, err := createWtFwpmDisplayData0("Block DNS inbound (IPv4)", "")
-		if err != nil {
-			return wrapErr(err)
+	for _, rule := range ipLayers(allowName, Rule{
+		Action: ActionPermit,
+		Weight: weightAllow,
+	}) {
+		allowConditions := allowConditionsV4
+		if rule.Layer == LayerConnectV6 || rule.Layer == LayerRecvAcceptV6 {
+			allowConditions = allowConditionsV6
 		}
-
-		filter.displayData = *displayData
-		filter.layerKey = cFWPM_LAYER_ALE_AUTH_RECV_ACCEPT_V4
-
-		err = fwpmFilterAdd0(session, &filter, 0, &filterID)
-		if err != nil {
-			return wrapErr(err)
+		if len(allowConditions) == 0 {
+			continue
 		}
+		rule.Conditions = append(append([]Condition{}, denyConditions...), allowConditions...)
+		rules = append(rules, rule)
 	}
 
-	//
-	// #3 Block IPv6 outbound DNS.
-	//
-	{
-		displayData, err := createWtFwpmDisplayData0("Block DNS outbound (IPv6)", "")
-		if err != nil {
-			return wrapErr(err)
-		}
+	return rules, nil
+}
 
-		filter.displayData = *displayData
-		filter.layerKey = cFWPM_LAYER_ALE_AUTH_CONNECT_V6
+// Block all DNS traffic except towards specified DNS servers.
+func blockDNS(except []netip.Addr, weightAllow, weightDeny uint8) ([]Rule, error) {
+	return blockPort("Block DNS %s (IPv%d)", "Allow DNS %s (IPv%d)", 53, except, weightAllow, weightDeny)
+}
 
-		err = fwpmFilterAdd0(session, &filter, 0, &filterID)
-		if err != nil {
-			return wrapErr(err)
-		}
-	}
+// Block all DNS over TLS traffic except towards specified DNS servers.
+func blockDNSOverTLS(except []netip.Addr, weightAllow, weightDeny uint8) ([]Rule, error) {
+	return blockPort("Block DNS over TLS %s (IPv%d)", "Allow DNS over TLS %s (IPv%d)", 853, except, weightAllow, weightDeny)
+}
 
-	//
-	// #4 Block IPv6 inbound DNS.
-	//
-	{
-		displayData, err := createWtFwpmDisplayData0("Block DNS inbound (IPv6)", "")
-		if err != nil {
-			return wrapErr(err)
+// Block HTTPS traffic to known DNS over HTTPS resolvers, other than the specified DNS servers.
+func blockDoHResolvers(except []netip.Addr, weight uint8) []Rule {
+	var resolverConditionsV4, resolverConditionsV6 []Condition
+	for _, ip := range knownDoHResolvers {
+		if slices.Contains(except, ip) {
+			continue
 		}
-
-		filter.displayData = *displayData
-		filter.layerKey = cFWPM_LAYER_ALE_AUTH_RECV_ACCEPT_V6
-
-		err = fwpmFilterAdd0(session, &filter, 0, &filterID)
-		if err != nil {
-			return wrapErr(err)
+		if ip.Is4() {
+			resolverConditionsV4 = append(resolverConditionsV4, Condition{FieldRemoteAddress, MatchEqual, ip})
+		} else {
+			resolverConditionsV6 = append(resolverConditionsV6, Condition{FieldRemoteAddress, MatchEqual, ip})
 		}
 	}
 
-	allowConditionsV4 := make([]wtFwpmFilterCondition0, 0, len(denyConditions)+len(except))
-	allowConditionsV4 = append(allowConditionsV4, denyConditions...)
-	for _, ip := range except {
-		if !ip.Is4() {
+	var rules []Rule
+	for _, rule := range ipLayers("Block DNS over HTTPS resolvers %s (IPv%d)", Rule{
+		Action: ActionBlock,
+		Weight: weight,
+		Conditions: []Condition{
+			{FieldRemotePort, MatchEqual, uint16(443)},
+			{FieldProtocol, MatchEqual, uint8(cIPPROTO_TCP)},
+			// Repeat the condition type for logical OR, as HTTP/3 uses UDP.
+			{FieldProtocol, MatchEqual, uint8(cIPPROTO_UDP)},
+		},
+	}) {
+		resolverConditions := resolverConditionsV4
+		switch rule.Layer {
+		case LayerConnectV4:
+		case LayerConnectV6:
+			resolverConditions = resolverConditionsV6
+		default:
 			continue
 		}
-		allowConditionsV4 = append(allowConditionsV4, wtFwpmFilterCondition0{
-			fieldKey:  cFWPM_CONDITION_IP_REMOTE_ADDRESS,
-			matchType: cFWP_MATCH_EQUAL,
-			conditionValue: wtFwpConditionValue0{
-				_type: cFWP_UINT32,
-				value: uintptr(binary.BigEndian.Uint32(ip.AsSlice())),
-			},
-		})
-	}
-
-	storedPointers := make([]*wtFwpByteArray16, 0, len(except))
-	allowConditionsV6 := make([]wtFwpmFilterCondition0, 0, len(denyConditions)+len(except))
-	allowConditionsV6 = append(allowConditionsV6, denyConditions...)
-	for _, ip := range except {
-		if !ip.Is6() {
+		if len(resolverConditions) == 0 {
 			continue
 		}
-		address := wtFwpByteArray16{byteArray16: ip.As16()}
-		allowConditionsV6 = append(allowConditionsV6, wtFwpmFilterCondition0{
-			fieldKey:  cFWPM_CONDITION_IP_REMOTE_ADDRESS,
-			matchType: cFWP_MATCH_EQUAL,
-			conditionValue: wtFwpConditionValue0{
-				_type: cFWP_BYTE_ARRAY16_TYPE,
-				value: uintptr(unsafe.Pointer(&address)),
-			},
-		})
-		storedPointers = append(storedPointers, &address)
-	}
-
-	filter = wtFwpmFilter0{
-		providerKey:         &baseObjects.provider,
-		subLayerKey:         baseObjects.filters,
-		weight:              filterWeight(weightAllow),
-		numFilterConditions: uint32(len(allowConditionsV4)),
-		filterCondition:     (*wtFwpmFilterCondition0)(unsafe.Pointer(&allowConditionsV4[0])),
-		action: wtFwpmAction0{
-			_type: cFWP_ACTION_PERMIT,
-		},
+		rule.Conditions = append(rule.Conditions, resolverConditions...)
+		rules = append(rules, rule)
 	}
+	return rules
+}
 
-	filterID = uint64(0)
-
-	//
-	// #5 Allow IPv4 outbound DNS.
-	//
-	if len(allowConditionsV4) > len(denyConditions) {
-		displayData, err := createWtFwpmDisplayData0("Allow DNS outbound (IPv4)", "")
-		if err != nil {
-			return wrapErr(err)
-		}
-
-		filter.displayData = *displayData
-		filter.layerKey = cFWPM_LAYER_ALE_AUTH_CONNECT_V4
+// firewallRules returns the rules installed by EnableFirewall into the sublayer of the tunnel's dynamic session.
+func firewallRules(executable AppID, luid uint64, options *Options) ([]Rule, error) {
+	rules := permitWireGuardService(15, executable)
 
-		err = fwpmFilterAdd0(session, &filter, 0, &filterID)
+	// The inbound policy outranks the permission of all traffic on the tunnel interface, and applies
+	// whether or not the remaining traffic is restricted. Its weights are distinct from those of the DNS rules,
+	// which also apply to inbound traffic, since WFP evaluates filters of equal weight in no particular order.
+	if options.Inbound != nil {
+		inboundRules, err := blockInbound(12, 11, luid, options.Inbound)
 		if err != nil {
-			return wrapErr(err)
+			return nil, err
 		}
+		rules = append(rules, inboundRules...)
 	}
 
-	//
-	// #6 Allow IPv4 inbound DNS.
-	//
-	if len(allowConditionsV4) > len(denyConditions) {
-		displayData, err := createWtFwpmDisplayData0("Allow DNS inbound (IPv4)", "")
-		if err != nil {
-			return wrapErr(err)
-		}
-
-		filter.displayData = *displayData
-		filter.layerKey = cFWPM_LAYER_ALE_AUTH_RECV_ACCEPT_V4
+	if options.DoNotRestrict {
+		return rules, nil
+	}
 
-		err = fwpmFilterAdd0(session, &filter, 0, &filterID)
+	if len(options.DNSServers) > 0 {
+		dnsRules, err := blockDNS(options.DNSServers, 15, 14)
 		if err != nil {
-			return wrapErr(err)
+			return nil, err
 		}
+		rules = append(rules, dnsRules...)
 	}
 
-	filter.filterCondition = (*wtFwpmFilterCondition0)(unsafe.Pointer(&allowConditionsV6[0]))
-	filter.numFilterConditions = uint32(len(allowConditionsV6))
-
-	//
-	// #7 Allow IPv6 outbound DNS.
-	//
-	if len(allowConditionsV6) > len(denyConditions) {
-		displayData, err := createWtFwpmDisplayData0("Allow DNS outbound (IPv6)", "")
+	if options.BlockEncryptedDNS {
+		dotRules, err := blockDNSOverTLS(options.DNSServers, 15, 14)
 		if err != nil {
-			return wrapErr(err)
+			return nil, err
 		}
+		rules = append(rules, dotRules...)
+		rules = append(rules, blockDoHResolvers(options.DNSServers, 14)...)
+	}
 
-		filter.displayData = *displayData
-		filter.layerKey = cFWPM_LAYER_ALE_AUTH_CONNECT_V6
+	rules = append(rules, permitLoopback(13)...)
+	rules = append(rules, permitTunInterface(10, luid)...)
+	rules = append(rules, permitDHCPIPv4(10)...)
+	rules = append(rules, permitDHCPIPv6(10)...)
+	rules = append(rules, permitNdp(10)...)
 
-		err = fwpmFilterAdd0(session, &filter, 0, &filterID)
-		if err != nil {
-			return wrapErr(err)
+	if options.VirtualMachines != nil {
+		rules = append(rules, permitVirtualMachines(10, options.VirtualMachines)...)
+		if options.VirtualMachines.ForceTunnel {
+			forwardRules, err := forwardVirtualMachinesToTunnel(10, 0, luid, options.VirtualMachines)
+			if err != nil {
+				return nil, err
+			}
+			rules = append(rules, forwardRules...)
 		}
 	}
 
-	//
-	// #8 Allow IPv6 inbound DNS.
-	//
-	if len(allowConditionsV6) > len(denyConditions) {
-		displayData, err := createWtFwpmDisplayData0("Allow DNS inbound (IPv6)", "")
-		if err != nil {
-			return wrapErr(err)
-		}
-
-		filter.displayData = *displayData
-		filter.layerKey = cFWPM_LAYER_ALE_AUTH_RECV_ACCEPT_V6
+	rules = append(rules, blockAll(0)...)
+	return rules, nil
+}
 
-		err = fwpmFilterAdd0(session, &filter, 0, &filterID)
-		if err != nil {
-			return wrapErr(err)
-		}
+// permitDNSClient permits the DNS queries of the DNS Client service, through which the hostnames of endpoints are
+// resolved, for approved tunnels that start or recover while lockdown mode is enabled.
+func permitDNSClient(weight uint8) []Rule {
+	var rules []Rule
+	for _, layer := range [...]struct {
+		layer   Layer
+		version int
+	}{
+		{LayerConnectV4, 4},
+		{LayerConnectV6, 6},
+	} {
+		rules = append(rules, Rule{
+			Name:   fmt.Sprintf("Permit DNS of DNS Client service for resolving endpoints (IPv%d)", layer.version),
+			Layer:  layer.layer,
+			Action: ActionPermit,
+			Weight: weight,
+			Conditions: []Condition{
+				{FieldUserID, MatchEqual, ServiceSIDs{"Dnscache"}},
+				{FieldProtocol, MatchEqual, uint8(cIPPROTO_UDP)},
+				{FieldProtocol, MatchEqual, uint8(cIPPROTO_TCP)},
+				{FieldRemotePort, MatchEqual, uint16(53)},
+			},
+		})
 	}
+	return rules
+}
 
-	runtime.KeepAlive(storedPointers)
-
-	return nil
+// lockdownRules returns the persistent rules installed by EnableLockdown into the lockdown sublayer.
+func lockdownRules(executable AppID, serviceNames []string) []Rule {
+	var rules []Rule
+	if len(serviceNames) > 0 {
+		rules = append(rules, permitTunnelServices(15, executable, serviceNames)...)
+	}
+	rules = append(rules, permitLoopback(13)...)
+	rules = append(rules, permitDHCPIPv4(12)...)
+	rules = append(rules, permitDHCPIPv6(12)...)
+	rules = append(rules, permitNdp(12)...)
+	rules = append(rules, blockAll(0)...)
+	return rules
 }
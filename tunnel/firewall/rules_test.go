@@ -0,0 +1,314 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2026 WireGuard LLC. All Rights Reserved.
+ */
+
+package firewall
+
+import (
+	"encoding/json"
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+const testExecutable = AppID(`C:\Program Files\WireGuard\wireguard.exe`)
+
+func TestFirewallRulesUnrestricted(t *testing.T) {
+	rules, err := firewallRules(testExecutable, 1234, &Options{DoNotRestrict: true, BlockEncryptedDNS: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != 4 {
+		t.Fatalf("Expected 4 rules, got %d", len(rules))
+	}
+	for _, rule := range rules {
+		if rule.Action != ActionPermit || rule.Weight != 15 || !rule.ClearActionRight {
+			t.Errorf("Unexpected rule %q: %s", rule.Name, &rule)
+		}
+		if len(rule.Conditions) != 2 || rule.Conditions[0].Value != testExecutable {
+			t.Errorf("Rule %q does not match the executable", rule.Name)
+		}
+	}
+}
+
+func TestFirewallRulesRestricted(t *testing.T) {
+	dnsServers := []netip.Addr{netip.MustParseAddr("10.0.0.1"), netip.MustParseAddr("fd00::1")}
+	rules, err := firewallRules(testExecutable, 1234, &Options{DNSServers: dnsServers})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	last := rules[len(rules)-1]
+	if last.Action != ActionBlock || last.Weight != 0 || len(last.Conditions) != 0 {
+		t.Errorf("Last rule is not a catch-all block: %s", &last)
+	}
+
+	found := make(map[string]Rule)
+	for _, rule := range rules {
+		found[rule.Name] = rule
+	}
+	for _, name := range []string{
+		"Block DNS outbound (IPv4)",
+		"Allow DNS outbound (IPv4)",
+		"Allow DNS inbound (IPv6)",
+		"Permit outbound IPv6 traffic on TUN",
+		"Permit NDP type 137",
+		"Block all inbound (IPv6)",
+	} {
+		if _, ok := found[name]; !ok {
+			t.Errorf("Missing rule %q", name)
+		}
+	}
+
+	allowV4 := found["Allow DNS outbound (IPv4)"]
+	if allowV4.Weight != 15 || allowV4.Conditions[len(allowV4.Conditions)-1].Value != dnsServers[0] {
+		t.Errorf("Unexpected IPv4 DNS exception: %s", &allowV4)
+	}
+	allowV6 := found["Allow DNS outbound (IPv6)"]
+	if allowV6.Conditions[len(allowV6.Conditions)-1].Value != dnsServers[1] {
+		t.Errorf("Unexpected IPv6 DNS exception: %s", &allowV6)
+	}
+	tun := found["Permit inbound IPv4 traffic on TUN"]
+	if tun.Layer != LayerRecvAcceptV4 || tun.Conditions[0].Value != uint64(1234) {
+		t.Errorf("Unexpected TUN rule: %s", &tun)
+	}
+}
+
+func TestRuleWeightsDoNotCollide(t *testing.T) {
+	rules, err := firewallRules(testExecutable, 1234, &Options{
+		DNSServers:        []netip.Addr{netip.MustParseAddr("1.1.1.1")},
+		BlockEncryptedDNS: true,
+		Inbound:           &InboundPolicy{Allow: []InboundRule{{Protocol: 6, Ports: PortRange{22, 22}}}},
+		VirtualMachines:   &VirtualMachinePolicy{Adapters: []uint64{5678}, ForceTunnel: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// WFP evaluates filters of equal weight in no particular order, so permitting and blocking filters of the same
+	// layer must not share a weight.
+	type layerWeight struct {
+		layer  Layer
+		weight uint8
+	}
+	actions := make(map[layerWeight]Rule)
+	for _, rule := range rules {
+		key := layerWeight{rule.Layer, rule.Weight}
+		if other, ok := actions[key]; ok && other.Action != rule.Action {
+			t.Errorf("Rules %q and %q have the same weight %d but different actions", other.Name, rule.Name, rule.Weight)
+		}
+		actions[key] = rule
+	}
+}
+
+func TestBlockDNSWeights(t *testing.T) {
+	_, err := blockDNS(nil, 14, 14)
+	if err == nil {
+		t.Error("Expected an error when the allow weight does not exceed the deny weight")
+	}
+	rules, err := blockDNS([]netip.Addr{netip.MustParseAddr("10.0.0.1")}, 15, 14)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != 6 {
+		t.Errorf("Expected 4 blocking and 2 allowing rules, got %d", len(rules))
+	}
+}
+
+func TestBlockEncryptedDNS(t *testing.T) {
+	dnsServers := []netip.Addr{netip.MustParseAddr("1.1.1.1")}
+	rules, err := firewallRules(testExecutable, 1234, &Options{DNSServers: dnsServers, BlockEncryptedDNS: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found := make(map[string]Rule)
+	for _, rule := range rules {
+		found[rule.Name] = rule
+	}
+	for _, name := range []string{
+		"Block DNS over TLS outbound (IPv4)",
+		"Block DNS over TLS inbound (IPv6)",
+		"Allow DNS over TLS outbound (IPv4)",
+		"Block DNS over HTTPS resolvers outbound (IPv4)",
+		"Block DNS over HTTPS resolvers outbound (IPv6)",
+	} {
+		if _, ok := found[name]; !ok {
+			t.Errorf("Missing rule %q", name)
+		}
+	}
+	for _, name := range []string{
+		"Allow DNS over TLS outbound (IPv6)",
+		"Block DNS over HTTPS resolvers inbound (IPv4)",
+	} {
+		if _, ok := found[name]; ok {
+			t.Errorf("Unexpected rule %q", name)
+		}
+	}
+
+	doh := found["Block DNS over HTTPS resolvers outbound (IPv4)"]
+	for _, condition := range doh.Conditions {
+		if condition.Field != FieldRemoteAddress {
+			continue
+		}
+		if condition.Value == dnsServers[0] {
+			t.Error("Configured DNS server should not be blocked as a DNS over HTTPS resolver")
+		}
+		if !condition.Value.(netip.Addr).Is4() {
+			t.Errorf("IPv4 rule matches non-IPv4 address %v", condition.Value)
+		}
+	}
+}
+
+func TestBlockInbound(t *testing.T) {
+	policy := &InboundPolicy{Allow: []InboundRule{
+		{Protocol: 6, Ports: PortRange{22, 22}, From: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}},
+		{Protocol: 17, Ports: PortRange{60000, 61000}},
+		{Protocol: 1},
+	}}
+	rules, err := firewallRules(testExecutable, 1234, &Options{DoNotRestrict: true, Inbound: policy})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found := make(map[string]Rule)
+	for _, rule := range rules {
+		found[rule.Name] = rule
+	}
+	if _, ok := found["Permit inbound traffic on TUN by rule 1 (IPv6)"]; ok {
+		t.Error("Rule restricted to IPv4 prefixes should not apply to IPv6")
+	}
+	ssh := found["Permit inbound traffic on TUN by rule 1 (IPv4)"]
+	if ssh.Weight != 12 || len(ssh.Conditions) != 4 || ssh.Conditions[2] != (Condition{FieldLocalPort, MatchEqual, uint16(22)}) {
+		t.Errorf("Unexpected SSH rule: %s", &ssh)
+	}
+	mosh := found["Permit inbound traffic on TUN by rule 2 (IPv6)"]
+	if len(mosh.Conditions) != 3 || mosh.Conditions[2] != (Condition{FieldLocalPort, MatchRange, PortRange{60000, 61000}}) {
+		t.Errorf("Unexpected port range rule: %s", &mosh)
+	}
+	icmp := found["Permit inbound traffic on TUN by rule 3 (IPv6)"]
+	if len(icmp.Conditions) != 2 || icmp.Conditions[1].Value != uint8(cIPPROTO_ICMPV6) {
+		t.Errorf("Unexpected ICMP rule: %s", &icmp)
+	}
+	block := found["Block inbound traffic on TUN (IPv4)"]
+	if block.Action != ActionBlock || block.Weight != 11 || block.Conditions[0].Value != uint64(1234) {
+		t.Errorf("Unexpected blocking rule: %s", &block)
+	}
+}
+
+func TestLockdownRules(t *testing.T) {
+	rules := lockdownRules(testExecutable, []string{"WireGuardTunnel$a", "WireGuardTunnel$b"})
+	first := rules[0]
+	if first.Name != "Permit unrestricted outbound traffic for approved WireGuard tunnels (IPv4)" {
+		t.Errorf("Unexpected first rule %q", first.Name)
+	}
+	if string(first.providerData) != "WireGuardTunnel$a\nWireGuardTunnel$b" {
+		t.Errorf("Unexpected provider data %q", first.providerData)
+	}
+	if len(lockdownRules(testExecutable, nil)) != len(rules)-4 {
+		t.Error("Approved tunnel rules should only be present when tunnels are approved")
+	}
+}
+
+func TestRuleString(t *testing.T) {
+	rule := Rule{
+		Name:             "Permit test",
+		Layer:            LayerConnectV4,
+		Action:           ActionPermit,
+		Weight:           12,
+		ClearActionRight: true,
+		Conditions: []Condition{
+			{FieldRemoteAddress, MatchEqual, netip.MustParseAddr("192.168.1.1")},
+			{FieldUserID, MatchEqual, ServiceSIDs(nil)},
+		},
+		ID:         42,
+		Persistent: true,
+	}
+	expected := strings.Join([]string{
+		"Permit test (filter 42)",
+		"    permit at ALE_AUTH_CONNECT_V4, weight 12, clears action right, persistent",
+		"    if IP_REMOTE_ADDRESS == 192.168.1.1",
+		"    if ALE_USER_ID == current service",
+	}, "\n")
+	if s := rule.String(); s != expected {
+		t.Errorf("Unexpected rule string:\n%s\nexpected:\n%s", s, expected)
+	}
+
+	j, err := json.Marshal(rule)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedJSON := `{"name":"Permit test","layer":"ALE_AUTH_CONNECT_V4","action":"permit","weight":12,"clearActionRight":true,` +
+		`"conditions":[{"field":"IP_REMOTE_ADDRESS","match":"==","value":"192.168.1.1"},{"field":"ALE_USER_ID","match":"==","value":"current service"}],` +
+		`"id":42,"persistent":true}`
+	if string(j) != expectedJSON {
+		t.Errorf("Unexpected JSON:\n%s\nexpected:\n%s", j, expectedJSON)
+	}
+}
+
+func TestVirtualMachines(t *testing.T) {
+	policy := &VirtualMachinePolicy{Adapters: []uint64{5678, 9012}}
+	rules, err := firewallRules(testExecutable, 1234, &Options{VirtualMachines: policy})
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := make(map[string]Rule)
+	for _, rule := range rules {
+		found[rule.Name] = rule
+	}
+	for _, name := range []string{
+		"Permit Hyper-V => Hyper-V outbound",
+		"Permit inbound IPv4 traffic on Hyper-V virtual adapters",
+	} {
+		if _, ok := found[name]; !ok {
+			t.Errorf("Missing rule %q", name)
+		}
+	}
+	adapters := found["Permit outbound IPv6 traffic on Hyper-V virtual adapters"]
+	if len(adapters.Conditions) != 2 || adapters.Conditions[1].Value != uint64(9012) {
+		t.Errorf("Unexpected adapter rule: %s", &adapters)
+	}
+	if _, ok := found["Block forwarded IPv4 traffic from Hyper-V virtual adapters"]; ok {
+		t.Error("Forwarded traffic should only be restricted when forced through the tunnel")
+	}
+
+	policy.ForceTunnel = true
+	rules, err = firewallRules(testExecutable, 1234, &Options{VirtualMachines: policy})
+	if err != nil {
+		t.Fatal(err)
+	}
+	found = make(map[string]Rule)
+	for _, rule := range rules {
+		found[rule.Name] = rule
+	}
+	permit := found["Permit forwarded IPv4 traffic from Hyper-V virtual adapters to TUN"]
+	if permit.Layer != LayerForwardV4 || len(permit.Conditions) != 3 || permit.Conditions[2] != (Condition{FieldForwardInterface, MatchEqual, uint64(1234)}) {
+		t.Errorf("Unexpected forwarding rule: %s", &permit)
+	}
+	block := found["Block forwarded IPv6 traffic from Hyper-V virtual adapters"]
+	if block.Action != ActionBlock || block.Weight >= permit.Weight || len(block.Conditions) != 2 {
+		t.Errorf("Unexpected forwarding block: %s", &block)
+	}
+}
+
+func TestCaptivePortal(t *testing.T) {
+	networks := []netip.Prefix{netip.MustParsePrefix("192.168.1.0/24"), netip.MustParsePrefix("10.0.0.53/32")}
+	rules := permitCaptivePortal(15, networks, "")
+	if len(rules) != 1 {
+		t.Fatalf("Expected only an IPv4 network rule, got %d rules", len(rules))
+	}
+	network := rules[0]
+	if network.Layer != LayerConnectV4 || network.Weight != 15 || len(network.Conditions) != 7 || network.Conditions[6].Value != networks[1] {
+		t.Errorf("Unexpected network rule: %s", &network)
+	}
+
+	rules = permitCaptivePortal(15, nil, testExecutable)
+	if len(rules) != 2 {
+		t.Fatalf("Expected a browser rule for each IP version, got %d rules", len(rules))
+	}
+	for _, rule := range rules {
+		if rule.Conditions[0] != (Condition{FieldAppID, MatchEqual, testExecutable}) {
+			t.Errorf("Rule %q does not match the browser", rule.Name)
+		}
+	}
+}
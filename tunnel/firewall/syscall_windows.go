@@ -34,3 +34,45 @@ package firewall
 
 // https://docs.microsoft.com/en-us/windows/desktop/api/fwpmu/nf-fwpmu-fwpmprovideradd0
 //sys	fwpmProviderAdd0(engineHandle uintptr, provider *wtFwpmProvider0, sd uintptr) (err error) [failretval!=0] = fwpuclnt.FwpmProviderAdd0
+
+// https://docs.microsoft.com/en-us/windows/desktop/api/fwpmu/nf-fwpmu-fwpmprovidergetbykey0
+//sys	fwpmProviderGetByKey0(engineHandle uintptr, key *windows.GUID, provider **wtFwpmProvider0) (err error) [failretval!=0] = fwpuclnt.FwpmProviderGetByKey0
+
+// https://docs.microsoft.com/en-us/windows/desktop/api/fwpmu/nf-fwpmu-fwpmproviderdeletebykey0
+//sys	fwpmProviderDeleteByKey0(engineHandle uintptr, key *windows.GUID) (err error) [failretval!=0] = fwpuclnt.FwpmProviderDeleteByKey0
+
+// https://docs.microsoft.com/en-us/windows/desktop/api/fwpmu/nf-fwpmu-fwpmsublayergetbykey0
+//sys	fwpmSubLayerGetByKey0(engineHandle uintptr, key *windows.GUID, subLayer **wtFwpmSublayer0) (err error) [failretval!=0] = fwpuclnt.FwpmSubLayerGetByKey0
+
+// https://docs.microsoft.com/en-us/windows/desktop/api/fwpmu/nf-fwpmu-fwpmsublayerdeletebykey0
+//sys	fwpmSubLayerDeleteByKey0(engineHandle uintptr, key *windows.GUID) (err error) [failretval!=0] = fwpuclnt.FwpmSubLayerDeleteByKey0
+
+// https://docs.microsoft.com/en-us/windows/desktop/api/fwpmu/nf-fwpmu-fwpmfilterdeletebyid0
+//sys	fwpmFilterDeleteById0(engineHandle uintptr, id uint64) (err error) [failretval!=0] = fwpuclnt.FwpmFilterDeleteById0
+
+// https://docs.microsoft.com/en-us/windows/desktop/api/fwpmu/nf-fwpmu-fwpmfiltercreateenumhandle0
+//sys	fwpmFilterCreateEnumHandle0(engineHandle uintptr, enumTemplate *wtFwpmFilterEnumTemplate0, enumHandle *uintptr) (err error) [failretval!=0] = fwpuclnt.FwpmFilterCreateEnumHandle0
+
+// https://docs.microsoft.com/en-us/windows/desktop/api/fwpmu/nf-fwpmu-fwpmfilterenum0
+//sys	fwpmFilterEnum0(engineHandle uintptr, enumHandle uintptr, numEntriesRequested uint32, entries ***wtFwpmFilter0, numEntriesReturned *uint32) (err error) [failretval!=0] = fwpuclnt.FwpmFilterEnum0
+
+// https://docs.microsoft.com/en-us/windows/desktop/api/fwpmu/nf-fwpmu-fwpmfilterdestroyenumhandle0
+//sys	fwpmFilterDestroyEnumHandle0(engineHandle uintptr, enumHandle uintptr) (err error) [failretval!=0] = fwpuclnt.FwpmFilterDestroyEnumHandle0
+
+// https://docs.microsoft.com/en-us/windows/desktop/api/fwpmu/nf-fwpmu-fwpmsublayercreateenumhandle0
+//sys	fwpmSubLayerCreateEnumHandle0(engineHandle uintptr, enumTemplate *wtFwpmSublayerEnumTemplate0, enumHandle *uintptr) (err error) [failretval!=0] = fwpuclnt.FwpmSubLayerCreateEnumHandle0
+
+// https://docs.microsoft.com/en-us/windows/desktop/api/fwpmu/nf-fwpmu-fwpmsublayerenum0
+//sys	fwpmSubLayerEnum0(engineHandle uintptr, enumHandle uintptr, numEntriesRequested uint32, entries ***wtFwpmSublayer0, numEntriesReturned *uint32) (err error) [failretval!=0] = fwpuclnt.FwpmSubLayerEnum0
+
+// https://docs.microsoft.com/en-us/windows/desktop/api/fwpmu/nf-fwpmu-fwpmsublayerdestroyenumhandle0
+//sys	fwpmSubLayerDestroyEnumHandle0(engineHandle uintptr, enumHandle uintptr) (err error) [failretval!=0] = fwpuclnt.FwpmSubLayerDestroyEnumHandle0
+
+// https://docs.microsoft.com/en-us/windows/desktop/api/fwpmu/nf-fwpmu-fwpmenginegetoption0
+//sys	fwpmEngineGetOption0(engineHandle uintptr, option wtFwpmEngineOption, value **wtFwpValue0) (err error) [failretval!=0] = fwpuclnt.FwpmEngineGetOption0
+
+// https://docs.microsoft.com/en-us/windows/desktop/api/fwpmu/nf-fwpmu-fwpmneteventsubscribe0
+//sys	fwpmNetEventSubscribe0(engineHandle uintptr, subscription *wtFwpmNetEventSubscription0, callback uintptr, context uintptr, eventsHandle *uintptr) (err error) [failretval!=0] = fwpuclnt.FwpmNetEventSubscribe0
+
+// https://docs.microsoft.com/en-us/windows/desktop/api/fwpmu/nf-fwpmu-fwpmneteventunsubscribe0
+//sys	fwpmNetEventUnsubscribe0(engineHandle uintptr, eventsHandle uintptr) (err error) [failretval!=0] = fwpuclnt.FwpmNetEventUnsubscribe0
@@ -148,6 +148,14 @@ var cFWPM_CONDITION_IP_LOCAL_ADDRESS = windows.GUID{
 	Data4: [8]byte{0xbf, 0xe3, 0xff, 0xd8, 0xf5, 0xa0, 0x89, 0x57},
 }
 
+// 1076b8a5-6323-4c5e-9810-e8d3fc9e6136
+var cFWPM_CONDITION_IP_FORWARD_INTERFACE = windows.GUID{
+	Data1: 0x1076b8a5,
+	Data2: 0x6323,
+	Data3: 0x4c5e,
+	Data4: [8]byte{0x98, 0x10, 0xe8, 0xd3, 0xfc, 0x9e, 0x61, 0x36},
+}
+
 var (
 	cFWPM_CONDITION_ICMP_TYPE = cFWPM_CONDITION_IP_LOCAL_PORT
 	cFWPM_CONDITION_ICMP_CODE = cFWPM_CONDITION_IP_REMOTE_PORT
@@ -243,6 +251,22 @@ var cFWPM_LAYER_INBOUND_MAC_FRAME_NATIVE = windows.GUID{
 	Data4: [8]byte{0xae, 0x88, 0xb5, 0x6e, 0x85, 0x26, 0xdf, 0x50},
 }
 
+// FWPM_LAYER_IPFORWARD_V4 (a82acc24-4ee1-4ee1-b465-fd1d25cb10a4) defined in fwpmu.h
+var cFWPM_LAYER_IPFORWARD_V4 = windows.GUID{
+	Data1: 0xa82acc24,
+	Data2: 0x4ee1,
+	Data3: 0x4ee1,
+	Data4: [8]byte{0xb4, 0x65, 0xfd, 0x1d, 0x25, 0xcb, 0x10, 0xa4},
+}
+
+// FWPM_LAYER_IPFORWARD_V6 (7b964818-19c7-493a-b71f-832c3684d28c) defined in fwpmu.h
+var cFWPM_LAYER_IPFORWARD_V6 = windows.GUID{
+	Data1: 0x7b964818,
+	Data2: 0x19c7,
+	Data3: 0x493a,
+	Data4: [8]byte{0xb7, 0x1f, 0x83, 0x2c, 0x36, 0x84, 0xd2, 0x8c},
+}
+
 // FWP_BITMAP_ARRAY64 defined in fwtypes.h
 type wtFwpBitmapArray64 struct {
 	bitmapArray64 [8]uint8 // Windows type: [8]UINT8
@@ -318,6 +342,13 @@ type wtFwpValue0 struct {
 	value uintptr
 }
 
+// FWP_RANGE0 defined in fwptypes.h
+// (https://docs.microsoft.com/en-us/windows/desktop/api/fwptypes/ns-fwptypes-fwp_range0).
+type wtFwpRange0 struct {
+	valueLow  wtFwpValue0
+	valueHigh wtFwpValue0
+}
+
 // FWPM_DISPLAY_DATA0 defined in fwptypes.h
 // (https://docs.microsoft.com/en-us/windows/desktop/api/fwptypes/ns-fwptypes-fwpm_display_data0).
 type wtFwpmDisplayData0 struct {
@@ -379,6 +410,26 @@ type wtFwpmSublayer0 struct {
 	weight       uint16
 }
 
+// FWPM_SUBLAYER_ENUM_TEMPLATE0 defined in fwpmtypes.h
+// (https://docs.microsoft.com/en-us/windows/desktop/api/fwpmtypes/ns-fwpmtypes-fwpm_sublayer_enum_template0)
+type wtFwpmSublayerEnumTemplate0 struct {
+	providerKey *windows.GUID // Windows type: *GUID
+}
+
+// FWPM_FILTER_ENUM_TEMPLATE0 defined in fwpmtypes.h
+// (https://docs.microsoft.com/en-us/windows/desktop/api/fwpmtypes/ns-fwpmtypes-fwpm_filter_enum_template0)
+type wtFwpmFilterEnumTemplate0 struct {
+	providerKey             *windows.GUID // Windows type: *GUID
+	layerKey                windows.GUID  // Windows type: GUID
+	enumType                uint32        // Windows type: FWP_FILTER_ENUM_TYPE
+	flags                   uint32
+	providerContextTemplate uintptr // Windows type: *FWPM_PROVIDER_CONTEXT_ENUM_TEMPLATE0
+	numFilterConditions     uint32
+	filterCondition         *wtFwpmFilterCondition0
+	actionMask              uint32
+	calloutKey              *windows.GUID // Windows type: *GUID
+}
+
 // Defined in rpcdce.h
 type wtRpcCAuthN uint32
 
@@ -388,6 +439,10 @@ const (
 	cRPC_C_AUTHN_DEFAULT wtRpcCAuthN = 0xFFFFFFFF
 )
 
+const (
+	cFWPM_PROVIDER_FLAG_PERSISTENT = 0x00000001 // FWPM_PROVIDER_FLAG_PERSISTENT defined in fwpmtypes.h
+)
+
 // FWPM_PROVIDER0 defined in fwpmtypes.h
 // (https://docs.microsoft.com/sv-se/windows/desktop/api/fwpmtypes/ns-fwpmtypes-fwpm_provider0).
 type wtFwpmProvider0 struct {
@@ -410,3 +465,104 @@ const (
 const (
 	cFWP_ACTRL_MATCH_FILTER = 1
 )
+
+// 206e9996-490e-40cf-b831-b38641eb6fcb
+var cFWPM_CONDITION_NET_EVENT_TYPE = windows.GUID{
+	Data1: 0x206e9996,
+	Data2: 0x490e,
+	Data3: 0x40cf,
+	Data4: [8]byte{0xb8, 0x31, 0xb3, 0x86, 0x41, 0xeb, 0x6f, 0xcb},
+}
+
+// FWPM_ENGINE_OPTION defined in fwpmtypes.h
+type wtFwpmEngineOption uint32
+
+const (
+	cFWPM_ENGINE_COLLECT_NET_EVENTS wtFwpmEngineOption = 0
+)
+
+type wtFwpmNetEventType uint32
+
+const (
+	cFWPM_NET_EVENT_TYPE_CLASSIFY_DROP wtFwpmNetEventType = 3 // FWPM_NET_EVENT_TYPE_CLASSIFY_DROP defined in fwpmtypes.h
+)
+
+type wtFwpmNetEventFlags uint32
+
+// Defined in fwpmtypes.h
+const (
+	cFWPM_NET_EVENT_FLAG_IP_PROTOCOL_SET wtFwpmNetEventFlags = 0x00000001
+	cFWPM_NET_EVENT_FLAG_REMOTE_ADDR_SET wtFwpmNetEventFlags = 0x00000004
+	cFWPM_NET_EVENT_FLAG_REMOTE_PORT_SET wtFwpmNetEventFlags = 0x00000010
+	cFWPM_NET_EVENT_FLAG_APP_ID_SET      wtFwpmNetEventFlags = 0x00000020
+	cFWPM_NET_EVENT_FLAG_IP_VERSION_SET  wtFwpmNetEventFlags = 0x00000100
+)
+
+type wtFwpIPVersion uint32
+
+const (
+	cFWP_IP_VERSION_V4 wtFwpIPVersion = 0
+	cFWP_IP_VERSION_V6 wtFwpIPVersion = 1
+)
+
+// FWP_DIRECTION_IN and FWP_DIRECTION_OUT, as given in the msFwpDirection field of FWPM_NET_EVENT_CLASSIFY_DROP1,
+// defined in fwptypes.h
+const (
+	cFWP_DIRECTION_IN  = 0x00003900
+	cFWP_DIRECTION_OUT = 0x00003901
+)
+
+// FWPM_NET_EVENT_HEADER1 defined in fwpmtypes.h
+// (https://docs.microsoft.com/en-us/windows/desktop/api/fwpmtypes/ns-fwpmtypes-fwpm_net_event_header1).
+type wtFwpmNetEventHeader1 struct {
+	timeStamp  windows.Filetime
+	flags      wtFwpmNetEventFlags
+	ipVersion  wtFwpIPVersion
+	ipProtocol uint8
+	offset1    [3]byte  // Layout correction field
+	localAddr  [16]byte // Windows type: union of UINT32 and FWP_BYTE_ARRAY16
+	remoteAddr [16]byte // Windows type: union of UINT32 and FWP_BYTE_ARRAY16
+	localPort  uint16
+	remotePort uint16
+	scopeId    uint32
+	appId      wtFwpByteBlob
+	userId     *windows.SID
+	reserved   [56]byte // Windows type: union of reserved Ethernet fields
+}
+
+// FWPM_NET_EVENT_CLASSIFY_DROP1 defined in fwpmtypes.h
+// (https://docs.microsoft.com/en-us/windows/desktop/api/fwpmtypes/ns-fwpmtypes-fwpm_net_event_classify_drop1).
+type wtFwpmNetEventClassifyDrop1 struct {
+	filterId        uint64
+	layerId         uint16
+	reauthReason    uint32
+	originalProfile uint32
+	currentProfile  uint32
+	msFwpDirection  uint32
+	isLoopback      uint32 // Windows type: BOOL
+}
+
+// FWPM_NET_EVENT1 defined in fwpmtypes.h
+// (https://docs.microsoft.com/en-us/windows/desktop/api/fwpmtypes/ns-fwpmtypes-fwpm_net_event1).
+type wtFwpmNetEvent1 struct {
+	header       wtFwpmNetEventHeader1
+	_type        wtFwpmNetEventType
+	classifyDrop *wtFwpmNetEventClassifyDrop1 // Windows type: union of pointers, of which only this one is used
+}
+
+// FWPM_NET_EVENT_ENUM_TEMPLATE0 defined in fwpmtypes.h
+// (https://docs.microsoft.com/en-us/windows/desktop/api/fwpmtypes/ns-fwpmtypes-fwpm_net_event_enum_template0).
+type wtFwpmNetEventEnumTemplate0 struct {
+	startTime           windows.Filetime
+	endTime             windows.Filetime
+	numFilterConditions uint32
+	filterCondition     *wtFwpmFilterCondition0
+}
+
+// FWPM_NET_EVENT_SUBSCRIPTION0 defined in fwpmtypes.h
+// (https://docs.microsoft.com/en-us/windows/desktop/api/fwpmtypes/ns-fwpmtypes-fwpm_net_event_subscription0).
+type wtFwpmNetEventSubscription0 struct {
+	enumTemplate *wtFwpmNetEventEnumTemplate0
+	flags        uint32
+	sessionKey   windows.GUID
+}
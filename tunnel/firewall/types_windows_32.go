@@ -39,6 +39,43 @@ const (
 	wtFwpmFilterCondition0_matchType_Offset      = 16
 	wtFwpmFilterCondition0_conditionValue_Offset = 20
 
+	wtFwpmSublayerEnumTemplate0_Size = 4
+
+	wtFwpmFilterEnumTemplate0_Size                           = 48
+	wtFwpmFilterEnumTemplate0_layerKey_Offset                = 4
+	wtFwpmFilterEnumTemplate0_enumType_Offset                = 20
+	wtFwpmFilterEnumTemplate0_flags_Offset                   = 24
+	wtFwpmFilterEnumTemplate0_providerContextTemplate_Offset = 28
+	wtFwpmFilterEnumTemplate0_numFilterConditions_Offset     = 32
+	wtFwpmFilterEnumTemplate0_filterCondition_Offset         = 36
+	wtFwpmFilterEnumTemplate0_actionMask_Offset              = 40
+	wtFwpmFilterEnumTemplate0_calloutKey_Offset              = 44
+
+	wtFwpmNetEventClassifyDrop1_Size                  = 32
+	wtFwpmNetEventClassifyDrop1_layerId_Offset        = 8
+	wtFwpmNetEventClassifyDrop1_reauthReason_Offset   = 12
+	wtFwpmNetEventClassifyDrop1_msFwpDirection_Offset = 24
+
+	wtFwpmNetEventEnumTemplate0_Size                       = 24
+	wtFwpmNetEventEnumTemplate0_numFilterConditions_Offset = 16
+	wtFwpmNetEventEnumTemplate0_filterCondition_Offset     = 20
+
+	wtFwpmNetEventHeader1_Size              = 128
+	wtFwpmNetEventHeader1_localAddr_Offset  = 20
+	wtFwpmNetEventHeader1_remoteAddr_Offset = 36
+	wtFwpmNetEventHeader1_localPort_Offset  = 52
+	wtFwpmNetEventHeader1_scopeId_Offset    = 56
+	wtFwpmNetEventHeader1_appId_Offset      = 60
+	wtFwpmNetEventHeader1_userId_Offset     = 68
+
+	wtFwpmNetEvent1_Size                = 136
+	wtFwpmNetEvent1_type_Offset         = 128
+	wtFwpmNetEvent1_classifyDrop_Offset = 132
+
+	wtFwpmNetEventSubscription0_Size              = 24
+	wtFwpmNetEventSubscription0_flags_Offset      = 4
+	wtFwpmNetEventSubscription0_sessionKey_Offset = 8
+
 	wtFwpmSession0_Size                        = 48
 	wtFwpmSession0_displayData_Offset          = 16
 	wtFwpmSession0_flags_Offset                = 24
@@ -61,6 +98,9 @@ const (
 	wtFwpProvider0_providerData_Offset = 28
 	wtFwpProvider0_serviceName_Offset  = 36
 
+	wtFwpRange0_Size             = 16
+	wtFwpRange0_valueHigh_Offset = 8
+
 	wtFwpTokenInformation_Size = 16
 
 	wtFwpValue0_Size         = 8
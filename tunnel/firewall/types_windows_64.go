@@ -39,6 +39,43 @@ const (
 	wtFwpmFilterCondition0_matchType_Offset      = 16
 	wtFwpmFilterCondition0_conditionValue_Offset = 24
 
+	wtFwpmSublayerEnumTemplate0_Size = 8
+
+	wtFwpmFilterEnumTemplate0_Size                           = 72
+	wtFwpmFilterEnumTemplate0_layerKey_Offset                = 8
+	wtFwpmFilterEnumTemplate0_enumType_Offset                = 24
+	wtFwpmFilterEnumTemplate0_flags_Offset                   = 28
+	wtFwpmFilterEnumTemplate0_providerContextTemplate_Offset = 32
+	wtFwpmFilterEnumTemplate0_numFilterConditions_Offset     = 40
+	wtFwpmFilterEnumTemplate0_filterCondition_Offset         = 48
+	wtFwpmFilterEnumTemplate0_actionMask_Offset              = 56
+	wtFwpmFilterEnumTemplate0_calloutKey_Offset              = 64
+
+	wtFwpmNetEventClassifyDrop1_Size                  = 32
+	wtFwpmNetEventClassifyDrop1_layerId_Offset        = 8
+	wtFwpmNetEventClassifyDrop1_reauthReason_Offset   = 12
+	wtFwpmNetEventClassifyDrop1_msFwpDirection_Offset = 24
+
+	wtFwpmNetEventEnumTemplate0_Size                       = 32
+	wtFwpmNetEventEnumTemplate0_numFilterConditions_Offset = 16
+	wtFwpmNetEventEnumTemplate0_filterCondition_Offset     = 24
+
+	wtFwpmNetEventHeader1_Size              = 144
+	wtFwpmNetEventHeader1_localAddr_Offset  = 20
+	wtFwpmNetEventHeader1_remoteAddr_Offset = 36
+	wtFwpmNetEventHeader1_localPort_Offset  = 52
+	wtFwpmNetEventHeader1_scopeId_Offset    = 56
+	wtFwpmNetEventHeader1_appId_Offset      = 64
+	wtFwpmNetEventHeader1_userId_Offset     = 80
+
+	wtFwpmNetEvent1_Size                = 160
+	wtFwpmNetEvent1_type_Offset         = 144
+	wtFwpmNetEvent1_classifyDrop_Offset = 152
+
+	wtFwpmNetEventSubscription0_Size              = 32
+	wtFwpmNetEventSubscription0_flags_Offset      = 8
+	wtFwpmNetEventSubscription0_sessionKey_Offset = 12
+
 	wtFwpmSession0_Size                        = 72
 	wtFwpmSession0_displayData_Offset          = 16
 	wtFwpmSession0_flags_Offset                = 32
@@ -61,6 +98,9 @@ const (
 	wtFwpProvider0_providerData_Offset = 40
 	wtFwpProvider0_serviceName_Offset  = 56
 
+	wtFwpRange0_Size             = 32
+	wtFwpRange0_valueHigh_Offset = 16
+
 	wtFwpValue0_Size         = 16
 	wtFwpValue0_value_Offset = 8
 )
@@ -342,6 +342,305 @@ func TestWtFwpmFilter0Offsets(t *testing.T) {
 	}
 }
 
+func TestWtFwpmSublayerEnumTemplate0Size(t *testing.T) {
+	const actualWtFwpmSublayerEnumTemplate0Size = unsafe.Sizeof(wtFwpmSublayerEnumTemplate0{})
+
+	if actualWtFwpmSublayerEnumTemplate0Size != wtFwpmSublayerEnumTemplate0_Size {
+		t.Errorf("Size of wtFwpmSublayerEnumTemplate0 is %d, although %d is expected.", actualWtFwpmSublayerEnumTemplate0Size,
+			wtFwpmSublayerEnumTemplate0_Size)
+	}
+}
+
+func TestWtFwpmFilterEnumTemplate0Size(t *testing.T) {
+	const actualWtFwpmFilterEnumTemplate0Size = unsafe.Sizeof(wtFwpmFilterEnumTemplate0{})
+
+	if actualWtFwpmFilterEnumTemplate0Size != wtFwpmFilterEnumTemplate0_Size {
+		t.Errorf("Size of wtFwpmFilterEnumTemplate0 is %d, although %d is expected.", actualWtFwpmFilterEnumTemplate0Size,
+			wtFwpmFilterEnumTemplate0_Size)
+	}
+}
+
+func TestWtFwpmFilterEnumTemplate0Offsets(t *testing.T) {
+	s := wtFwpmFilterEnumTemplate0{}
+	sp := uintptr(unsafe.Pointer(&s))
+
+	offset := uintptr(unsafe.Pointer(&s.layerKey)) - sp
+
+	if offset != wtFwpmFilterEnumTemplate0_layerKey_Offset {
+		t.Errorf("wtFwpmFilterEnumTemplate0.layerKey offset is %d although %d is expected", offset,
+			wtFwpmFilterEnumTemplate0_layerKey_Offset)
+		return
+	}
+
+	offset = uintptr(unsafe.Pointer(&s.enumType)) - sp
+
+	if offset != wtFwpmFilterEnumTemplate0_enumType_Offset {
+		t.Errorf("wtFwpmFilterEnumTemplate0.enumType offset is %d although %d is expected", offset,
+			wtFwpmFilterEnumTemplate0_enumType_Offset)
+		return
+	}
+
+	offset = uintptr(unsafe.Pointer(&s.flags)) - sp
+
+	if offset != wtFwpmFilterEnumTemplate0_flags_Offset {
+		t.Errorf("wtFwpmFilterEnumTemplate0.flags offset is %d although %d is expected", offset,
+			wtFwpmFilterEnumTemplate0_flags_Offset)
+		return
+	}
+
+	offset = uintptr(unsafe.Pointer(&s.providerContextTemplate)) - sp
+
+	if offset != wtFwpmFilterEnumTemplate0_providerContextTemplate_Offset {
+		t.Errorf("wtFwpmFilterEnumTemplate0.providerContextTemplate offset is %d although %d is expected", offset,
+			wtFwpmFilterEnumTemplate0_providerContextTemplate_Offset)
+		return
+	}
+
+	offset = uintptr(unsafe.Pointer(&s.numFilterConditions)) - sp
+
+	if offset != wtFwpmFilterEnumTemplate0_numFilterConditions_Offset {
+		t.Errorf("wtFwpmFilterEnumTemplate0.numFilterConditions offset is %d although %d is expected", offset,
+			wtFwpmFilterEnumTemplate0_numFilterConditions_Offset)
+		return
+	}
+
+	offset = uintptr(unsafe.Pointer(&s.filterCondition)) - sp
+
+	if offset != wtFwpmFilterEnumTemplate0_filterCondition_Offset {
+		t.Errorf("wtFwpmFilterEnumTemplate0.filterCondition offset is %d although %d is expected", offset,
+			wtFwpmFilterEnumTemplate0_filterCondition_Offset)
+		return
+	}
+
+	offset = uintptr(unsafe.Pointer(&s.actionMask)) - sp
+
+	if offset != wtFwpmFilterEnumTemplate0_actionMask_Offset {
+		t.Errorf("wtFwpmFilterEnumTemplate0.actionMask offset is %d although %d is expected", offset,
+			wtFwpmFilterEnumTemplate0_actionMask_Offset)
+		return
+	}
+
+	offset = uintptr(unsafe.Pointer(&s.calloutKey)) - sp
+
+	if offset != wtFwpmFilterEnumTemplate0_calloutKey_Offset {
+		t.Errorf("wtFwpmFilterEnumTemplate0.calloutKey offset is %d although %d is expected", offset,
+			wtFwpmFilterEnumTemplate0_calloutKey_Offset)
+		return
+	}
+}
+
+func TestWtFwpmNetEventClassifyDrop1Size(t *testing.T) {
+	const actualWtFwpmNetEventClassifyDrop1Size = unsafe.Sizeof(wtFwpmNetEventClassifyDrop1{})
+
+	if actualWtFwpmNetEventClassifyDrop1Size != wtFwpmNetEventClassifyDrop1_Size {
+		t.Errorf("Size of wtFwpmNetEventClassifyDrop1 is %d, although %d is expected.", actualWtFwpmNetEventClassifyDrop1Size,
+			wtFwpmNetEventClassifyDrop1_Size)
+	}
+}
+
+func TestWtFwpmNetEventClassifyDrop1Offsets(t *testing.T) {
+	s := wtFwpmNetEventClassifyDrop1{}
+	sp := uintptr(unsafe.Pointer(&s))
+
+	offset := uintptr(unsafe.Pointer(&s.layerId)) - sp
+
+	if offset != wtFwpmNetEventClassifyDrop1_layerId_Offset {
+		t.Errorf("wtFwpmNetEventClassifyDrop1.layerId offset is %d although %d is expected", offset,
+			wtFwpmNetEventClassifyDrop1_layerId_Offset)
+		return
+	}
+
+	offset = uintptr(unsafe.Pointer(&s.reauthReason)) - sp
+
+	if offset != wtFwpmNetEventClassifyDrop1_reauthReason_Offset {
+		t.Errorf("wtFwpmNetEventClassifyDrop1.reauthReason offset is %d although %d is expected", offset,
+			wtFwpmNetEventClassifyDrop1_reauthReason_Offset)
+		return
+	}
+
+	offset = uintptr(unsafe.Pointer(&s.msFwpDirection)) - sp
+
+	if offset != wtFwpmNetEventClassifyDrop1_msFwpDirection_Offset {
+		t.Errorf("wtFwpmNetEventClassifyDrop1.msFwpDirection offset is %d although %d is expected", offset,
+			wtFwpmNetEventClassifyDrop1_msFwpDirection_Offset)
+		return
+	}
+}
+
+func TestWtFwpmNetEventEnumTemplate0Size(t *testing.T) {
+	const actualWtFwpmNetEventEnumTemplate0Size = unsafe.Sizeof(wtFwpmNetEventEnumTemplate0{})
+
+	if actualWtFwpmNetEventEnumTemplate0Size != wtFwpmNetEventEnumTemplate0_Size {
+		t.Errorf("Size of wtFwpmNetEventEnumTemplate0 is %d, although %d is expected.", actualWtFwpmNetEventEnumTemplate0Size,
+			wtFwpmNetEventEnumTemplate0_Size)
+	}
+}
+
+func TestWtFwpmNetEventEnumTemplate0Offsets(t *testing.T) {
+	s := wtFwpmNetEventEnumTemplate0{}
+	sp := uintptr(unsafe.Pointer(&s))
+
+	offset := uintptr(unsafe.Pointer(&s.numFilterConditions)) - sp
+
+	if offset != wtFwpmNetEventEnumTemplate0_numFilterConditions_Offset {
+		t.Errorf("wtFwpmNetEventEnumTemplate0.numFilterConditions offset is %d although %d is expected", offset,
+			wtFwpmNetEventEnumTemplate0_numFilterConditions_Offset)
+		return
+	}
+
+	offset = uintptr(unsafe.Pointer(&s.filterCondition)) - sp
+
+	if offset != wtFwpmNetEventEnumTemplate0_filterCondition_Offset {
+		t.Errorf("wtFwpmNetEventEnumTemplate0.filterCondition offset is %d although %d is expected", offset,
+			wtFwpmNetEventEnumTemplate0_filterCondition_Offset)
+		return
+	}
+}
+
+func TestWtFwpmNetEventHeader1Size(t *testing.T) {
+	const actualWtFwpmNetEventHeader1Size = unsafe.Sizeof(wtFwpmNetEventHeader1{})
+
+	if actualWtFwpmNetEventHeader1Size != wtFwpmNetEventHeader1_Size {
+		t.Errorf("Size of wtFwpmNetEventHeader1 is %d, although %d is expected.", actualWtFwpmNetEventHeader1Size,
+			wtFwpmNetEventHeader1_Size)
+	}
+}
+
+func TestWtFwpmNetEventHeader1Offsets(t *testing.T) {
+	s := wtFwpmNetEventHeader1{}
+	sp := uintptr(unsafe.Pointer(&s))
+
+	offset := uintptr(unsafe.Pointer(&s.localAddr)) - sp
+
+	if offset != wtFwpmNetEventHeader1_localAddr_Offset {
+		t.Errorf("wtFwpmNetEventHeader1.localAddr offset is %d although %d is expected", offset,
+			wtFwpmNetEventHeader1_localAddr_Offset)
+		return
+	}
+
+	offset = uintptr(unsafe.Pointer(&s.remoteAddr)) - sp
+
+	if offset != wtFwpmNetEventHeader1_remoteAddr_Offset {
+		t.Errorf("wtFwpmNetEventHeader1.remoteAddr offset is %d although %d is expected", offset,
+			wtFwpmNetEventHeader1_remoteAddr_Offset)
+		return
+	}
+
+	offset = uintptr(unsafe.Pointer(&s.localPort)) - sp
+
+	if offset != wtFwpmNetEventHeader1_localPort_Offset {
+		t.Errorf("wtFwpmNetEventHeader1.localPort offset is %d although %d is expected", offset,
+			wtFwpmNetEventHeader1_localPort_Offset)
+		return
+	}
+
+	offset = uintptr(unsafe.Pointer(&s.scopeId)) - sp
+
+	if offset != wtFwpmNetEventHeader1_scopeId_Offset {
+		t.Errorf("wtFwpmNetEventHeader1.scopeId offset is %d although %d is expected", offset,
+			wtFwpmNetEventHeader1_scopeId_Offset)
+		return
+	}
+
+	offset = uintptr(unsafe.Pointer(&s.appId)) - sp
+
+	if offset != wtFwpmNetEventHeader1_appId_Offset {
+		t.Errorf("wtFwpmNetEventHeader1.appId offset is %d although %d is expected", offset,
+			wtFwpmNetEventHeader1_appId_Offset)
+		return
+	}
+
+	offset = uintptr(unsafe.Pointer(&s.userId)) - sp
+
+	if offset != wtFwpmNetEventHeader1_userId_Offset {
+		t.Errorf("wtFwpmNetEventHeader1.userId offset is %d although %d is expected", offset,
+			wtFwpmNetEventHeader1_userId_Offset)
+		return
+	}
+}
+
+func TestWtFwpmNetEvent1Size(t *testing.T) {
+	const actualWtFwpmNetEvent1Size = unsafe.Sizeof(wtFwpmNetEvent1{})
+
+	if actualWtFwpmNetEvent1Size != wtFwpmNetEvent1_Size {
+		t.Errorf("Size of wtFwpmNetEvent1 is %d, although %d is expected.", actualWtFwpmNetEvent1Size,
+			wtFwpmNetEvent1_Size)
+	}
+}
+
+func TestWtFwpmNetEvent1Offsets(t *testing.T) {
+	s := wtFwpmNetEvent1{}
+	sp := uintptr(unsafe.Pointer(&s))
+
+	offset := uintptr(unsafe.Pointer(&s._type)) - sp
+
+	if offset != wtFwpmNetEvent1_type_Offset {
+		t.Errorf("wtFwpmNetEvent1._type offset is %d although %d is expected", offset,
+			wtFwpmNetEvent1_type_Offset)
+		return
+	}
+
+	offset = uintptr(unsafe.Pointer(&s.classifyDrop)) - sp
+
+	if offset != wtFwpmNetEvent1_classifyDrop_Offset {
+		t.Errorf("wtFwpmNetEvent1.classifyDrop offset is %d although %d is expected", offset,
+			wtFwpmNetEvent1_classifyDrop_Offset)
+		return
+	}
+}
+
+func TestWtFwpmNetEventSubscription0Size(t *testing.T) {
+	const actualWtFwpmNetEventSubscription0Size = unsafe.Sizeof(wtFwpmNetEventSubscription0{})
+
+	if actualWtFwpmNetEventSubscription0Size != wtFwpmNetEventSubscription0_Size {
+		t.Errorf("Size of wtFwpmNetEventSubscription0 is %d, although %d is expected.", actualWtFwpmNetEventSubscription0Size,
+			wtFwpmNetEventSubscription0_Size)
+	}
+}
+
+func TestWtFwpmNetEventSubscription0Offsets(t *testing.T) {
+	s := wtFwpmNetEventSubscription0{}
+	sp := uintptr(unsafe.Pointer(&s))
+
+	offset := uintptr(unsafe.Pointer(&s.flags)) - sp
+
+	if offset != wtFwpmNetEventSubscription0_flags_Offset {
+		t.Errorf("wtFwpmNetEventSubscription0.flags offset is %d although %d is expected", offset,
+			wtFwpmNetEventSubscription0_flags_Offset)
+		return
+	}
+
+	offset = uintptr(unsafe.Pointer(&s.sessionKey)) - sp
+
+	if offset != wtFwpmNetEventSubscription0_sessionKey_Offset {
+		t.Errorf("wtFwpmNetEventSubscription0.sessionKey offset is %d although %d is expected", offset,
+			wtFwpmNetEventSubscription0_sessionKey_Offset)
+		return
+	}
+}
+
+func TestWtFwpRange0Size(t *testing.T) {
+	const actualWtFwpRange0Size = unsafe.Sizeof(wtFwpRange0{})
+
+	if actualWtFwpRange0Size != wtFwpRange0_Size {
+		t.Errorf("Size of wtFwpRange0 is %d, although %d is expected.", actualWtFwpRange0Size,
+			wtFwpRange0_Size)
+	}
+}
+
+func TestWtFwpRange0Offsets(t *testing.T) {
+	s := wtFwpRange0{}
+	sp := uintptr(unsafe.Pointer(&s))
+
+	offset := uintptr(unsafe.Pointer(&s.valueHigh)) - sp
+
+	if offset != wtFwpRange0_valueHigh_Offset {
+		t.Errorf("wtFwpRange0.valueHigh offset is %d although %d is expected", offset,
+			wtFwpRange0_valueHigh_Offset)
+		return
+	}
+}
+
 func TestWtFwpProvider0Size(t *testing.T) {
 	const actualWtFwpProvider0Size = unsafe.Sizeof(wtFwpProvider0{})
 
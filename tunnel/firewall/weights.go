@@ -0,0 +1,124 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2026 WireGuard LLC. All Rights Reserved.
+ */
+
+package firewall
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+	"golang.zx2c4.com/wireguard/windows/conf"
+)
+
+// sublayerWeight returns the weight of WireGuard's sublayers, which determines the order in which they are
+// evaluated relative to the sublayers of other providers, such as security products. It may be lowered by
+// administrators, should WireGuard's sublayers need to be evaluated after those of another product.
+func sublayerWeight() uint16 {
+	if weight, ok := conf.AdminInt("FirewallSublayerWeight"); ok && weight <= math.MaxUint16 {
+		return uint16(weight)
+	}
+	return math.MaxUint16
+}
+
+// filterWeightBase returns the exact weight of filters with a rule weight of 0, to which the rule weight is
+// added, or 0, in which case the rule weights are given to WFP as they are, and occupy its upper band.
+func filterWeightBase() uint64 {
+	if base, ok := conf.AdminInt("FirewallFilterWeightBase"); ok && base <= math.MaxUint64-15 {
+		return base
+	}
+	return 0
+}
+
+// Sublayer is a WFP sublayer of another provider, as listed by OutrankingSublayers.
+type Sublayer struct {
+	Name     string `json:"name"`
+	Key      string `json:"key"`
+	Weight   uint16 `json:"weight"`
+	Provider string `json:"provider,omitempty"`
+}
+
+func (s *Sublayer) String() string {
+	provider := s.Provider
+	if len(provider) == 0 {
+		provider = "no provider"
+	}
+	return fmt.Sprintf("%s %s, weight %d, of %s", s.Name, s.Key, s.Weight, provider)
+}
+
+// OutrankingSublayers returns the sublayers of other providers that are evaluated before, or, being of the
+// same weight, in an undefined order with, the sublayers of WireGuard, with the highest weight first. Blocking
+// filters in these sublayers take effect even if WireGuard permits traffic, and filters that clear the action
+// right in them override the blocking filters of WireGuard.
+func OutrankingSublayers() ([]Sublayer, error) {
+	session, err := createWfpSession(true)
+	if err != nil {
+		return nil, wrapErr(err)
+	}
+	defer fwpmEngineClose0(session)
+
+	type provider struct {
+		name      string
+		wireGuard bool
+	}
+	providers := make(map[windows.GUID]provider)
+	providerOf := func(key *windows.GUID) provider {
+		if key == nil {
+			return provider{}
+		}
+		p, ok := providers[*key]
+		if !ok {
+			p.name = key.String()
+			var fp *wtFwpmProvider0
+			if fwpmProviderGetByKey0(session, key, &fp) == nil {
+				name := windows.UTF16PtrToString(fp.displayData.name)
+				p.name = fmt.Sprintf("%s %v", name, *key)
+				p.wireGuard = name == "WireGuard"
+				fwpmFreeMemory0(unsafe.Pointer(&fp))
+			}
+			providers[*key] = p
+		}
+		return p
+	}
+
+	ours := uint16(0)
+	found := false
+	var others []Sublayer
+	err = forEachSublayer(session, func(sublayer *wtFwpmSublayer0) {
+		p := providerOf(sublayer.providerKey)
+		if p.wireGuard {
+			if !found || sublayer.weight < ours {
+				ours = sublayer.weight
+			}
+			found = true
+			return
+		}
+		others = append(others, Sublayer{
+			Name:     windows.UTF16PtrToString(sublayer.displayData.name),
+			Key:      sublayer.subLayerKey.String(),
+			Weight:   sublayer.weight,
+			Provider: p.name,
+		})
+	})
+	if err != nil {
+		return nil, wrapErr(err)
+	}
+	if !found {
+		ours = sublayerWeight()
+	}
+
+	var outranking []Sublayer
+	for _, sublayer := range others {
+		if sublayer.Weight >= ours {
+			outranking = append(outranking, sublayer)
+		}
+	}
+	sort.SliceStable(outranking, func(i, j int) bool {
+		return outranking[i].Weight > outranking[j].Weight
+	})
+	return outranking, nil
+}
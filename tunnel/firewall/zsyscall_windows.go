@@ -40,16 +40,30 @@ func errnoErr(e syscall.Errno) error {
 var (
 	modfwpuclnt = windows.NewLazySystemDLL("fwpuclnt.dll")
 
-	procFwpmEngineClose0          = modfwpuclnt.NewProc("FwpmEngineClose0")
-	procFwpmEngineOpen0           = modfwpuclnt.NewProc("FwpmEngineOpen0")
-	procFwpmFilterAdd0            = modfwpuclnt.NewProc("FwpmFilterAdd0")
-	procFwpmFreeMemory0           = modfwpuclnt.NewProc("FwpmFreeMemory0")
-	procFwpmGetAppIdFromFileName0 = modfwpuclnt.NewProc("FwpmGetAppIdFromFileName0")
-	procFwpmProviderAdd0          = modfwpuclnt.NewProc("FwpmProviderAdd0")
-	procFwpmSubLayerAdd0          = modfwpuclnt.NewProc("FwpmSubLayerAdd0")
-	procFwpmTransactionAbort0     = modfwpuclnt.NewProc("FwpmTransactionAbort0")
-	procFwpmTransactionBegin0     = modfwpuclnt.NewProc("FwpmTransactionBegin0")
-	procFwpmTransactionCommit0    = modfwpuclnt.NewProc("FwpmTransactionCommit0")
+	procFwpmEngineClose0               = modfwpuclnt.NewProc("FwpmEngineClose0")
+	procFwpmEngineGetOption0           = modfwpuclnt.NewProc("FwpmEngineGetOption0")
+	procFwpmEngineOpen0                = modfwpuclnt.NewProc("FwpmEngineOpen0")
+	procFwpmFilterAdd0                 = modfwpuclnt.NewProc("FwpmFilterAdd0")
+	procFwpmFilterCreateEnumHandle0    = modfwpuclnt.NewProc("FwpmFilterCreateEnumHandle0")
+	procFwpmFilterDeleteById0          = modfwpuclnt.NewProc("FwpmFilterDeleteById0")
+	procFwpmFilterDestroyEnumHandle0   = modfwpuclnt.NewProc("FwpmFilterDestroyEnumHandle0")
+	procFwpmFilterEnum0                = modfwpuclnt.NewProc("FwpmFilterEnum0")
+	procFwpmFreeMemory0                = modfwpuclnt.NewProc("FwpmFreeMemory0")
+	procFwpmGetAppIdFromFileName0      = modfwpuclnt.NewProc("FwpmGetAppIdFromFileName0")
+	procFwpmNetEventSubscribe0         = modfwpuclnt.NewProc("FwpmNetEventSubscribe0")
+	procFwpmNetEventUnsubscribe0       = modfwpuclnt.NewProc("FwpmNetEventUnsubscribe0")
+	procFwpmProviderAdd0               = modfwpuclnt.NewProc("FwpmProviderAdd0")
+	procFwpmProviderDeleteByKey0       = modfwpuclnt.NewProc("FwpmProviderDeleteByKey0")
+	procFwpmProviderGetByKey0          = modfwpuclnt.NewProc("FwpmProviderGetByKey0")
+	procFwpmSubLayerAdd0               = modfwpuclnt.NewProc("FwpmSubLayerAdd0")
+	procFwpmSubLayerCreateEnumHandle0  = modfwpuclnt.NewProc("FwpmSubLayerCreateEnumHandle0")
+	procFwpmSubLayerDeleteByKey0       = modfwpuclnt.NewProc("FwpmSubLayerDeleteByKey0")
+	procFwpmSubLayerDestroyEnumHandle0 = modfwpuclnt.NewProc("FwpmSubLayerDestroyEnumHandle0")
+	procFwpmSubLayerEnum0              = modfwpuclnt.NewProc("FwpmSubLayerEnum0")
+	procFwpmSubLayerGetByKey0          = modfwpuclnt.NewProc("FwpmSubLayerGetByKey0")
+	procFwpmTransactionAbort0          = modfwpuclnt.NewProc("FwpmTransactionAbort0")
+	procFwpmTransactionBegin0          = modfwpuclnt.NewProc("FwpmTransactionBegin0")
+	procFwpmTransactionCommit0         = modfwpuclnt.NewProc("FwpmTransactionCommit0")
 )
 
 func fwpmEngineClose0(engineHandle uintptr) (err error) {
@@ -60,6 +74,14 @@ func fwpmEngineClose0(engineHandle uintptr) (err error) {
 	return
 }
 
+func fwpmEngineGetOption0(engineHandle uintptr, option wtFwpmEngineOption, value **wtFwpValue0) (err error) {
+	r1, _, e1 := syscall.SyscallN(procFwpmEngineGetOption0.Addr(), uintptr(engineHandle), uintptr(option), uintptr(unsafe.Pointer(value)))
+	if r1 != 0 {
+		err = errnoErr(e1)
+	}
+	return
+}
+
 func fwpmEngineOpen0(serverName *uint16, authnService wtRpcCAuthN, authIdentity *uintptr, session *wtFwpmSession0, engineHandle unsafe.Pointer) (err error) {
 	r1, _, e1 := syscall.SyscallN(procFwpmEngineOpen0.Addr(), uintptr(unsafe.Pointer(serverName)), uintptr(authnService), uintptr(unsafe.Pointer(authIdentity)), uintptr(unsafe.Pointer(session)), uintptr(engineHandle))
 	if r1 != 0 {
@@ -76,6 +98,38 @@ func fwpmFilterAdd0(engineHandle uintptr, filter *wtFwpmFilter0, sd uintptr, id
 	return
 }
 
+func fwpmFilterCreateEnumHandle0(engineHandle uintptr, enumTemplate *wtFwpmFilterEnumTemplate0, enumHandle *uintptr) (err error) {
+	r1, _, e1 := syscall.SyscallN(procFwpmFilterCreateEnumHandle0.Addr(), uintptr(engineHandle), uintptr(unsafe.Pointer(enumTemplate)), uintptr(unsafe.Pointer(enumHandle)))
+	if r1 != 0 {
+		err = errnoErr(e1)
+	}
+	return
+}
+
+func fwpmFilterDeleteById0(engineHandle uintptr, id uint64) (err error) {
+	r1, _, e1 := syscall.SyscallN(procFwpmFilterDeleteById0.Addr(), uintptr(engineHandle), uintptr(id))
+	if r1 != 0 {
+		err = errnoErr(e1)
+	}
+	return
+}
+
+func fwpmFilterDestroyEnumHandle0(engineHandle uintptr, enumHandle uintptr) (err error) {
+	r1, _, e1 := syscall.SyscallN(procFwpmFilterDestroyEnumHandle0.Addr(), uintptr(engineHandle), uintptr(enumHandle))
+	if r1 != 0 {
+		err = errnoErr(e1)
+	}
+	return
+}
+
+func fwpmFilterEnum0(engineHandle uintptr, enumHandle uintptr, numEntriesRequested uint32, entries ***wtFwpmFilter0, numEntriesReturned *uint32) (err error) {
+	r1, _, e1 := syscall.SyscallN(procFwpmFilterEnum0.Addr(), uintptr(engineHandle), uintptr(enumHandle), uintptr(numEntriesRequested), uintptr(unsafe.Pointer(entries)), uintptr(unsafe.Pointer(numEntriesReturned)))
+	if r1 != 0 {
+		err = errnoErr(e1)
+	}
+	return
+}
+
 func fwpmFreeMemory0(p unsafe.Pointer) {
 	syscall.SyscallN(procFwpmFreeMemory0.Addr(), uintptr(p))
 	return
@@ -89,6 +143,22 @@ func fwpmGetAppIdFromFileName0(fileName *uint16, appID unsafe.Pointer) (err erro
 	return
 }
 
+func fwpmNetEventSubscribe0(engineHandle uintptr, subscription *wtFwpmNetEventSubscription0, callback uintptr, context uintptr, eventsHandle *uintptr) (err error) {
+	r1, _, e1 := syscall.SyscallN(procFwpmNetEventSubscribe0.Addr(), uintptr(engineHandle), uintptr(unsafe.Pointer(subscription)), uintptr(callback), uintptr(context), uintptr(unsafe.Pointer(eventsHandle)))
+	if r1 != 0 {
+		err = errnoErr(e1)
+	}
+	return
+}
+
+func fwpmNetEventUnsubscribe0(engineHandle uintptr, eventsHandle uintptr) (err error) {
+	r1, _, e1 := syscall.SyscallN(procFwpmNetEventUnsubscribe0.Addr(), uintptr(engineHandle), uintptr(eventsHandle))
+	if r1 != 0 {
+		err = errnoErr(e1)
+	}
+	return
+}
+
 func fwpmProviderAdd0(engineHandle uintptr, provider *wtFwpmProvider0, sd uintptr) (err error) {
 	r1, _, e1 := syscall.SyscallN(procFwpmProviderAdd0.Addr(), uintptr(engineHandle), uintptr(unsafe.Pointer(provider)), uintptr(sd))
 	if r1 != 0 {
@@ -97,6 +167,22 @@ func fwpmProviderAdd0(engineHandle uintptr, provider *wtFwpmProvider0, sd uintpt
 	return
 }
 
+func fwpmProviderDeleteByKey0(engineHandle uintptr, key *windows.GUID) (err error) {
+	r1, _, e1 := syscall.SyscallN(procFwpmProviderDeleteByKey0.Addr(), uintptr(engineHandle), uintptr(unsafe.Pointer(key)))
+	if r1 != 0 {
+		err = errnoErr(e1)
+	}
+	return
+}
+
+func fwpmProviderGetByKey0(engineHandle uintptr, key *windows.GUID, provider **wtFwpmProvider0) (err error) {
+	r1, _, e1 := syscall.SyscallN(procFwpmProviderGetByKey0.Addr(), uintptr(engineHandle), uintptr(unsafe.Pointer(key)), uintptr(unsafe.Pointer(provider)))
+	if r1 != 0 {
+		err = errnoErr(e1)
+	}
+	return
+}
+
 func fwpmSubLayerAdd0(engineHandle uintptr, subLayer *wtFwpmSublayer0, sd uintptr) (err error) {
 	r1, _, e1 := syscall.SyscallN(procFwpmSubLayerAdd0.Addr(), uintptr(engineHandle), uintptr(unsafe.Pointer(subLayer)), uintptr(sd))
 	if r1 != 0 {
@@ -105,6 +191,46 @@ func fwpmSubLayerAdd0(engineHandle uintptr, subLayer *wtFwpmSublayer0, sd uintpt
 	return
 }
 
+func fwpmSubLayerCreateEnumHandle0(engineHandle uintptr, enumTemplate *wtFwpmSublayerEnumTemplate0, enumHandle *uintptr) (err error) {
+	r1, _, e1 := syscall.SyscallN(procFwpmSubLayerCreateEnumHandle0.Addr(), uintptr(engineHandle), uintptr(unsafe.Pointer(enumTemplate)), uintptr(unsafe.Pointer(enumHandle)))
+	if r1 != 0 {
+		err = errnoErr(e1)
+	}
+	return
+}
+
+func fwpmSubLayerDeleteByKey0(engineHandle uintptr, key *windows.GUID) (err error) {
+	r1, _, e1 := syscall.SyscallN(procFwpmSubLayerDeleteByKey0.Addr(), uintptr(engineHandle), uintptr(unsafe.Pointer(key)))
+	if r1 != 0 {
+		err = errnoErr(e1)
+	}
+	return
+}
+
+func fwpmSubLayerDestroyEnumHandle0(engineHandle uintptr, enumHandle uintptr) (err error) {
+	r1, _, e1 := syscall.SyscallN(procFwpmSubLayerDestroyEnumHandle0.Addr(), uintptr(engineHandle), uintptr(enumHandle))
+	if r1 != 0 {
+		err = errnoErr(e1)
+	}
+	return
+}
+
+func fwpmSubLayerEnum0(engineHandle uintptr, enumHandle uintptr, numEntriesRequested uint32, entries ***wtFwpmSublayer0, numEntriesReturned *uint32) (err error) {
+	r1, _, e1 := syscall.SyscallN(procFwpmSubLayerEnum0.Addr(), uintptr(engineHandle), uintptr(enumHandle), uintptr(numEntriesRequested), uintptr(unsafe.Pointer(entries)), uintptr(unsafe.Pointer(numEntriesReturned)))
+	if r1 != 0 {
+		err = errnoErr(e1)
+	}
+	return
+}
+
+func fwpmSubLayerGetByKey0(engineHandle uintptr, key *windows.GUID, subLayer **wtFwpmSublayer0) (err error) {
+	r1, _, e1 := syscall.SyscallN(procFwpmSubLayerGetByKey0.Addr(), uintptr(engineHandle), uintptr(unsafe.Pointer(key)), uintptr(unsafe.Pointer(subLayer)))
+	if r1 != 0 {
+		err = errnoErr(e1)
+	}
+	return
+}
+
 func fwpmTransactionAbort0(engineHandle uintptr) (err error) {
 	r1, _, e1 := syscall.SyscallN(procFwpmTransactionAbort0.Addr(), uintptr(engineHandle))
 	if r1 != 0 {
@@ -0,0 +1,65 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2026 WireGuard LLC. All Rights Reserved.
+ */
+
+package hosts
+
+import (
+	"bytes"
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// Entry maps one or more names to an address.
+type Entry struct {
+	Address netip.Addr
+	Names   []string
+}
+
+func blockMarkers(tunnelName string) (begin, end string) {
+	return fmt.Sprintf("# BEGIN WireGuard tunnel: %s", tunnelName), fmt.Sprintf("# END WireGuard tunnel: %s", tunnelName)
+}
+
+// UpdateBlock returns the contents of a hosts file with the block of the tunnel replaced by the given entries, or
+// removed if there are none. Other lines, including blocks of other tunnels, are left untouched, and the line
+// endings of the file are kept.
+func UpdateBlock(content []byte, tunnelName string, entries []Entry) []byte {
+	newline := "\n"
+	if bytes.Contains(content, []byte("\r\n")) {
+		newline = "\r\n"
+	}
+	begin, end := blockMarkers(tunnelName)
+
+	var output bytes.Buffer
+	inBlock := false
+	lines := strings.SplitAfter(string(content), "\n")
+	for _, line := range lines {
+		trimmed := strings.TrimRight(line, "\r\n")
+		if !inBlock && trimmed == begin {
+			inBlock = true
+			continue
+		}
+		if inBlock {
+			if trimmed == end {
+				inBlock = false
+			}
+			continue
+		}
+		output.WriteString(line)
+	}
+
+	if len(entries) == 0 {
+		return output.Bytes()
+	}
+	if output.Len() > 0 && !bytes.HasSuffix(output.Bytes(), []byte("\n")) {
+		output.WriteString(newline)
+	}
+	output.WriteString(begin + newline)
+	for _, entry := range entries {
+		output.WriteString(fmt.Sprintf("%s %s%s", entry.Address, strings.Join(entry.Names, " "), newline))
+	}
+	output.WriteString(end + newline)
+	return output.Bytes()
+}
@@ -0,0 +1,64 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2026 WireGuard LLC. All Rights Reserved.
+ */
+
+package hosts
+
+import (
+	"net/netip"
+	"testing"
+)
+
+var testEntries = []Entry{
+	{netip.MustParseAddr("10.0.0.5"), []string{"git.corp.example", "wiki.corp.example"}},
+	{netip.MustParseAddr("fd00::5"), []string{"nas"}},
+}
+
+func TestUpdateBlockAdds(t *testing.T) {
+	const content = "# Copyright (c) 1993-2009 Microsoft Corp.\r\n127.0.0.1 localhost"
+	const expected = "# Copyright (c) 1993-2009 Microsoft Corp.\r\n127.0.0.1 localhost\r\n" +
+		"# BEGIN WireGuard tunnel: office\r\n" +
+		"10.0.0.5 git.corp.example wiki.corp.example\r\n" +
+		"fd00::5 nas\r\n" +
+		"# END WireGuard tunnel: office\r\n"
+	if actual := string(UpdateBlock([]byte(content), "office", testEntries)); actual != expected {
+		t.Errorf("Expected %q, got %q", expected, actual)
+	}
+}
+
+func TestUpdateBlockReplaces(t *testing.T) {
+	const content = "127.0.0.1 localhost\n" +
+		"# BEGIN WireGuard tunnel: office\n" +
+		"10.0.0.9 old.corp.example\n" +
+		"# END WireGuard tunnel: office\n" +
+		"# BEGIN WireGuard tunnel: home\n" +
+		"192.168.1.2 printer\n" +
+		"# END WireGuard tunnel: home\n"
+	const expected = "127.0.0.1 localhost\n" +
+		"# BEGIN WireGuard tunnel: home\n" +
+		"192.168.1.2 printer\n" +
+		"# END WireGuard tunnel: home\n" +
+		"# BEGIN WireGuard tunnel: office\n" +
+		"10.0.0.5 git.corp.example wiki.corp.example\n" +
+		"fd00::5 nas\n" +
+		"# END WireGuard tunnel: office\n"
+	if actual := string(UpdateBlock([]byte(content), "office", testEntries)); actual != expected {
+		t.Errorf("Expected %q, got %q", expected, actual)
+	}
+}
+
+func TestUpdateBlockRemoves(t *testing.T) {
+	const content = "127.0.0.1 localhost\r\n" +
+		"# BEGIN WireGuard tunnel: office\r\n" +
+		"10.0.0.5 git.corp.example\r\n" +
+		"# END WireGuard tunnel: office\r\n" +
+		"::1 localhost\r\n"
+	const expected = "127.0.0.1 localhost\r\n::1 localhost\r\n"
+	if actual := string(UpdateBlock([]byte(content), "office", nil)); actual != expected {
+		t.Errorf("Expected %q, got %q", expected, actual)
+	}
+	if actual := string(UpdateBlock([]byte(expected), "office", nil)); actual != expected {
+		t.Errorf("Removing a missing block changed %q to %q", expected, actual)
+	}
+}
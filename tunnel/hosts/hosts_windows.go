@@ -0,0 +1,109 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2026 WireGuard LLC. All Rights Reserved.
+ */
+
+package hosts
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+const (
+	// Serializes changes to the hosts file by the services of simultaneously running tunnels. It lives in a private
+	// namespace bound to Local System, so that other users cannot create it first and hold it.
+	namespaceName = "WireGuard"
+	mutexName     = namespaceName + `\HostsFile`
+
+	// How long to wait for the service of another tunnel to finish changing the hosts file.
+	mutexTimeout = time.Second * 30
+)
+
+// openNamespace creates the private namespace, or opens it if it already exists, for which the caller must be
+// Local System.
+func openNamespace() (windows.Handle, error) {
+	boundary, err := createBoundaryDescriptor(windows.StringToUTF16Ptr(namespaceName), 0)
+	if err != nil {
+		return 0, fmt.Errorf("unable to create boundary descriptor: %w", err)
+	}
+	defer deleteBoundaryDescriptor(boundary)
+	sid, err := windows.CreateWellKnownSid(windows.WinLocalSystemSid)
+	if err != nil {
+		return 0, err
+	}
+	err = addSIDToBoundaryDescriptor(&boundary, sid)
+	if err != nil {
+		return 0, fmt.Errorf("unable to add SID to boundary descriptor: %w", err)
+	}
+	sd, err := windows.SecurityDescriptorFromString("O:SYD:P(A;;GA;;;SY)")
+	if err != nil {
+		return 0, err
+	}
+	sa := &windows.SecurityAttributes{Length: uint32(unsafe.Sizeof(windows.SecurityAttributes{})), SecurityDescriptor: sd}
+	namespace, err := createPrivateNamespace(sa, boundary, windows.StringToUTF16Ptr(namespaceName))
+	if err == windows.ERROR_ALREADY_EXISTS {
+		namespace, err = openPrivateNamespace(boundary, windows.StringToUTF16Ptr(namespaceName))
+	}
+	if err != nil {
+		return 0, fmt.Errorf("unable to open private namespace: %w", err)
+	}
+	return namespace, nil
+}
+
+func hostsPath() (string, error) {
+	systemDirectory, err := windows.GetSystemDirectory()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(systemDirectory, "drivers", "etc", "hosts"), nil
+}
+
+// Set replaces the block of the tunnel in the system's hosts file with the given entries, or removes it if there
+// are none. The file is only written if it changes.
+func Set(tunnelName string, entries []Entry) error {
+	path, err := hostsPath()
+	if err != nil {
+		return err
+	}
+	namespace, err := openNamespace()
+	if err != nil {
+		return err
+	}
+	defer closePrivateNamespace(namespace, 0)
+	sd, err := windows.SecurityDescriptorFromString("O:SYD:P(A;;GA;;;SY)")
+	if err != nil {
+		return err
+	}
+	sa := &windows.SecurityAttributes{Length: uint32(unsafe.Sizeof(windows.SecurityAttributes{})), SecurityDescriptor: sd}
+	mutex, err := windows.CreateMutex(sa, false, windows.StringToUTF16Ptr(mutexName))
+	if mutex == 0 {
+		return fmt.Errorf("unable to create hosts file mutex: %w", err)
+	}
+	defer windows.CloseHandle(mutex)
+	event, err := windows.WaitForSingleObject(mutex, uint32(mutexTimeout.Milliseconds()))
+	if event == uint32(windows.WAIT_TIMEOUT) {
+		return errors.New("timed out waiting for hosts file mutex")
+	}
+	if event != windows.WAIT_OBJECT_0 && event != windows.WAIT_ABANDONED {
+		return fmt.Errorf("unable to acquire hosts file mutex: %w", err)
+	}
+	defer windows.ReleaseMutex(mutex)
+
+	content, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	updated := UpdateBlock(content, tunnelName, entries)
+	if bytes.Equal(content, updated) {
+		return nil
+	}
+	return os.WriteFile(path, updated, 0o644)
+}
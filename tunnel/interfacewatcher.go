@@ -17,6 +17,7 @@ import (
 	"golang.zx2c4.com/wireguard/windows/driver"
 	"golang.zx2c4.com/wireguard/windows/services"
 	"golang.zx2c4.com/wireguard/windows/tunnel/firewall"
+	"golang.zx2c4.com/wireguard/windows/tunnel/hosts"
 	"golang.zx2c4.com/wireguard/windows/tunnel/winipcfg"
 )
 
@@ -38,6 +39,9 @@ type interfaceWatcher struct {
 	adapter *driver.Adapter
 	luid    winipcfg.LUID
 
+	mtuEndpoints *mtuEndpoints
+	mtuProber    *pathMTUProber
+
 	setupMutex              sync.Mutex
 	interfaceChangeCallback winipcfg.ChangeCallback
 	changeCallbacks4        []winipcfg.ChangeCallback
@@ -68,8 +72,12 @@ func (iw *interfaceWatcher) setup(family winipcfg.AddressFamily) {
 	var err error
 
 	if iw.conf.Interface.MTU == 0 {
-		log.Printf("Monitoring MTU of default %s routes", ipversion)
-		*changeCallbacks, err = monitorMTU(family, iw.luid)
+		if len(iw.mtuEndpoints.get()) > 0 {
+			log.Printf("Monitoring MTU of %s routes to endpoints", ipversion)
+		} else {
+			log.Printf("Monitoring MTU of default %s routes", ipversion)
+		}
+		*changeCallbacks, err = monitorMTU(family, iw.mtuEndpoints, iw.luid, iw.mtuProber)
 		if err != nil {
 			iw.errors <- interfaceWatcherError{services.ErrorMonitorMTUChanges, err}
 			return
@@ -137,6 +145,19 @@ func (iw *interfaceWatcher) Configure(adapter *driver.Adapter, conf *conf.Config
 	iw.watchdog.Reset(time.Minute)
 
 	iw.adapter, iw.conf, iw.luid = adapter, conf, luid
+	iw.mtuEndpoints = newMTUEndpoints(conf)
+	if conf.Interface.MTUAutoProbe {
+		log.Println("Probing path MTU to endpoints")
+		iw.mtuProber = newPathMTUProber(conf, luid)
+	}
+	if len(conf.Interface.Hosts) > 0 {
+		log.Println("Adding hosts file entries")
+	}
+	// Setting no entries removes those left behind by a previous version of the configuration.
+	err := hosts.Set(conf.Name, hostsEntries(&conf.Interface))
+	if err != nil {
+		iw.errors <- interfaceWatcherError{services.ErrorSetNetConfig, fmt.Errorf("unable to set hosts file entries: %w", err)}
+	}
 	for _, event := range iw.storedEvents {
 		if event.luid == luid {
 			iw.setup(event.family)
@@ -152,6 +173,7 @@ func (iw *interfaceWatcher) Destroy() {
 	changeCallbacks6 := iw.changeCallbacks6
 	interfaceChangeCallback := iw.interfaceChangeCallback
 	luid := iw.luid
+	config := iw.conf
 	iw.setupMutex.Unlock()
 
 	if interfaceChangeCallback != nil {
@@ -178,7 +200,7 @@ func (iw *interfaceWatcher) Destroy() {
 	}
 	if luid != 0 && iw.luid == luid {
 		// It seems that the Windows networking stack doesn't like it when we destroy interfaces that have active
-		// routes, so to be certain, just remove everything before destroying.
+		// routes, so to be certain, just remove everything, including the routes of Route, before destroying.
 		luid.FlushRoutes(windows.AF_INET)
 		luid.FlushIPAddresses(windows.AF_INET)
 		luid.FlushDNS(windows.AF_INET)
@@ -186,6 +208,12 @@ func (iw *interfaceWatcher) Destroy() {
 		luid.FlushIPAddresses(windows.AF_INET6)
 		luid.FlushDNS(windows.AF_INET6)
 	}
+	if config != nil && len(config.Interface.Hosts) > 0 {
+		err := hosts.Set(config.Name, nil)
+		if err != nil {
+			log.Printf("Unable to remove hosts file entries: %v", err)
+		}
+	}
 	firewall.DisableFirewall()
 	iw.setupMutex.Unlock()
 }
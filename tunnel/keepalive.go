@@ -0,0 +1,279 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2026 WireGuard LLC. All Rights Reserved.
+ */
+
+package tunnel
+
+import (
+	"log"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sys/windows"
+	"golang.zx2c4.com/wireguard/windows/conf"
+	"golang.zx2c4.com/wireguard/windows/driver"
+	"golang.zx2c4.com/wireguard/windows/tunnel/nlm"
+	"golang.zx2c4.com/wireguard/windows/tunnel/winipcfg"
+)
+
+const (
+	keepaliveInitial      = 25  // The customary interval, which nearly all NATs tolerate
+	keepaliveStep         = 15  // By how much the interval is lengthened or shortened
+	keepaliveMaximum      = 300 // The binding lifetime recommended by RFC 4787, beyond which there is little to save
+	keepaliveProbation    = time.Minute * 10
+	keepalivePollInterval = time.Second * 10
+
+	// Handshakes are repeated every two minutes while there is traffic, so one that follows the previous one
+	// sooner than this means that the session stopped working in between.
+	keepalivePrematureHandshake = time.Second * 110
+
+	keepaliveDirectory = "Keepalive"
+)
+
+// keepaliveTuner adjusts the persistent keepalive of peers with PersistentKeepalive = auto. It starts with
+// keepaliveInitial and lengthens the interval after each keepaliveProbation, until a handshake is needed
+// prematurely, which is taken to mean that the NAT binding was lost while the tunnel was idle. The interval is
+// then shortened again, and kept for the network through which the endpoint is reached, in the data directory.
+// The network is looked up again only when the endpoint changes or Windows reports a change of connectivity.
+type keepaliveTuner struct {
+	adapter      *driver.Adapter
+	luid         winipcfg.LUID
+	peers        []*keepalivePeer
+	connectivity *nlm.ConnectivityChangeCallback
+	changed      chan struct{}
+	stop         chan struct{}
+	done         sync.WaitGroup
+}
+
+type keepalivePeer struct {
+	publicKey     conf.Key
+	endpoint      netip.Addr
+	network       string // The network ID of the interface through which the endpoint is reached, if known
+	interval      uint16
+	learned       bool // Whether interval has been learned for the network, and is no longer lengthened
+	since         time.Time
+	lastHandshake uint64
+}
+
+func startKeepaliveTuner(adapter *driver.Adapter, config *conf.Config, luid winipcfg.LUID) *keepaliveTuner {
+	tuner := &keepaliveTuner{adapter: adapter, luid: luid}
+	for _, peer := range config.Peers {
+		if !peer.PersistentKeepaliveAuto || peer.Endpoint.IsEmpty() {
+			continue
+		}
+		tuner.peers = append(tuner.peers, &keepalivePeer{publicKey: peer.PublicKey})
+	}
+	if len(tuner.peers) == 0 {
+		return nil
+	}
+	log.Println("Adjusting persistent keepalive automatically")
+	tuner.changed = make(chan struct{}, 1)
+	var err error
+	tuner.connectivity, err = nlm.RegisterConnectivityChangeCallback(func() {
+		select {
+		case tuner.changed <- struct{}{}:
+		default:
+		}
+	})
+	if err != nil {
+		log.Printf("Unable to register for changes of network connectivity, so the network of endpoints is looked up on each poll: %v", err)
+	}
+	tuner.stop = make(chan struct{})
+	tuner.done.Add(1)
+	go func() {
+		defer tuner.done.Done()
+		poll := time.NewTicker(keepalivePollInterval)
+		defer poll.Stop()
+		refresh := true
+		for {
+			tuner.adjust(refresh || tuner.connectivity == nil)
+			refresh = false
+			select {
+			case <-tuner.stop:
+				return
+			case <-tuner.changed:
+				refresh = true
+			case <-poll.C:
+			}
+		}
+	}()
+	return tuner
+}
+
+func (tuner *keepaliveTuner) close() {
+	if tuner == nil {
+		return
+	}
+	if tuner.connectivity != nil {
+		tuner.connectivity.Unregister()
+	}
+	close(tuner.stop)
+	tuner.done.Wait()
+}
+
+// adjust reads the last handshakes from the driver, and looks up the network of each endpoint again if refresh is
+// set, if the endpoint changed, or if Windows had not yet identified the network.
+func (tuner *keepaliveTuner) adjust(refresh bool) {
+	interfaze, err := tuner.adapter.Configuration()
+	if err != nil {
+		return
+	}
+	// The endpoints are those currently in use, which may differ from the configuration after recovery resolved them
+	// again, or after the peer roamed.
+	handshakes := make(map[conf.Key]uint64, interfaze.PeerCount)
+	endpoints := make(map[conf.Key]netip.Addr, interfaze.PeerCount)
+	p := interfaze.FirstPeer()
+	for i := uint32(0); i < interfaze.PeerCount; i++ {
+		handshakes[p.PublicKey] = p.LastHandshake
+		if p.Flags&driver.PeerHasEndpoint != 0 {
+			endpoints[p.PublicKey] = p.Endpoint.Addr().Unmap()
+		}
+		p = p.NextPeer()
+	}
+	now := time.Now()
+	for _, peer := range tuner.peers {
+		endpoint, ok := endpoints[peer.publicKey]
+		if !ok || !endpoint.IsValid() {
+			continue
+		}
+		lastHandshake := handshakes[peer.publicKey]
+		network := peer.network
+		if refresh || endpoint != peer.endpoint || len(network) == 0 {
+			peer.endpoint = endpoint
+			network = tuner.networkOf(endpoint)
+		}
+		if network != peer.network || peer.interval == 0 {
+			peer.network = network
+			peer.interval, peer.learned = keepaliveInitial, false
+			if learned, ok := learnedKeepalive(network); ok {
+				peer.interval, peer.learned = learned, true
+			}
+			peer.since, peer.lastHandshake = now, lastHandshake
+			log.Printf("Setting persistent keepalive of peer %s to %d seconds for network %s", peer.publicKey.String(), peer.interval, keepaliveNetworkName(network))
+			tuner.set(peer)
+			continue
+		}
+		if lastHandshake != peer.lastHandshake {
+			elapsed := time.Duration(lastHandshake-peer.lastHandshake) * 100
+			premature := peer.lastHandshake != 0 && elapsed < keepalivePrematureHandshake
+			peer.lastHandshake = lastHandshake
+			if premature {
+				peer.interval = max(peer.interval-keepaliveStep, keepaliveInitial)
+				peer.learned, peer.since = true, now
+				log.Printf("Handshake with peer %s after only %v, assuming lost NAT binding; lowering persistent keepalive to %d seconds", peer.publicKey.String(), elapsed.Round(time.Second), peer.interval)
+				storeLearnedKeepalive(peer.network, peer.interval)
+				tuner.set(peer)
+				continue
+			}
+		}
+		if !peer.learned && now.Sub(peer.since) >= keepaliveProbation {
+			peer.interval = min(peer.interval+keepaliveStep, keepaliveMaximum)
+			peer.since = now
+			if peer.interval == keepaliveMaximum {
+				peer.learned = true
+				storeLearnedKeepalive(peer.network, peer.interval)
+			}
+			log.Printf("Raising persistent keepalive of peer %s to %d seconds", peer.publicKey.String(), peer.interval)
+			tuner.set(peer)
+		}
+	}
+}
+
+// set updates the keepalive interval of the peer alone, leaving the rest of the configuration as it is.
+func (tuner *keepaliveTuner) set(peer *keepalivePeer) {
+	var c driver.ConfigBuilder
+	c.AppendInterface(&driver.Interface{PeerCount: 1})
+	c.AppendPeer(&driver.Peer{
+		Flags:               driver.PeerHasPublicKey | driver.PeerHasPersistentKeepalive | driver.PeerUpdateOnly,
+		PublicKey:           peer.publicKey,
+		PersistentKeepalive: peer.interval,
+	})
+	err := tuner.adapter.SetConfiguration(c.Interface())
+	if err != nil {
+		log.Printf("Unable to set persistent keepalive of peer %s: %v", peer.publicKey.String(), err)
+	}
+}
+
+// networkOf returns the ID that Windows gave to the network of the interface through which the endpoint is
+// reached, or an empty string if it is unknown.
+func (tuner *keepaliveTuner) networkOf(endpoint netip.Addr) string {
+	family := winipcfg.AddressFamily(windows.AF_INET)
+	if endpoint.Is6() {
+		family = windows.AF_INET6
+	}
+	routes, err := winipcfg.GetIPForwardTable2(family)
+	if err != nil {
+		return ""
+	}
+	luid := findEndpointLUID(endpoint, tuner.luid, routes)
+	if luid == 0 {
+		return ""
+	}
+	guid, err := luid.GUID()
+	if err != nil {
+		return ""
+	}
+	network, err := nlm.NetworkID(*guid)
+	if err != nil {
+		return ""
+	}
+	return network.String()
+}
+
+func keepaliveNetworkName(network string) string {
+	if len(network) == 0 {
+		return "(unidentified)"
+	}
+	return network
+}
+
+// keepalivePath returns the path of the file in the Keepalive directory of the data directory in which the interval
+// learned for the network is kept, which holds the number of seconds.
+func keepalivePath(network string) (string, error) {
+	root, err := conf.RootDirectory(true)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(root, keepaliveDirectory, network), nil
+}
+
+func learnedKeepalive(network string) (uint16, bool) {
+	if len(network) == 0 {
+		return 0, false
+	}
+	path, err := keepalivePath(network)
+	if err != nil {
+		return 0, false
+	}
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		return 0, false
+	}
+	val, err := strconv.ParseUint(strings.TrimSpace(string(bytes)), 10, 16)
+	if err != nil || val < keepaliveInitial || val > keepaliveMaximum {
+		return 0, false
+	}
+	return uint16(val), true
+}
+
+func storeLearnedKeepalive(network string, interval uint16) {
+	if len(network) == 0 {
+		return
+	}
+	path, err := keepalivePath(network)
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(path), os.ModeDir|0o700)
+	}
+	if err == nil {
+		err = os.WriteFile(path, []byte(strconv.FormatUint(uint64(interval), 10)+"\n"), 0o600)
+	}
+	if err != nil {
+		log.Printf("Unable to store persistent keepalive for network %s: %v", network, err)
+	}
+}
@@ -6,12 +6,20 @@
 package tunnel
 
 import (
+	"log"
+	"net/netip"
 	"sync"
 
 	"golang.org/x/sys/windows"
+	"golang.zx2c4.com/wireguard/windows/conf"
+	"golang.zx2c4.com/wireguard/windows/tunnel/pmtu"
 	"golang.zx2c4.com/wireguard/windows/tunnel/winipcfg"
 )
 
+// The overhead of encapsulation besides the outer IP header: the UDP header, and the header and authentication tag
+// of WireGuard's data messages.
+const encapsulationOverhead = 8 + 16 + 16
+
 func findDefaultLUID(family winipcfg.AddressFamily, ourLUID winipcfg.LUID, lastLUID *winipcfg.LUID, lastIndex *uint32) error {
 	r, err := winipcfg.GetIPForwardTable2(family)
 	if err != nil {
@@ -49,7 +57,257 @@ func findDefaultLUID(family winipcfg.AddressFamily, ourLUID winipcfg.LUID, lastL
 	return nil
 }
 
-func monitorMTU(family winipcfg.AddressFamily, ourLUID winipcfg.LUID) ([]winipcfg.ChangeCallback, error) {
+// findEndpointLUID returns the interface of the route that WireGuardNT uses to reach the endpoint, which is the most
+// specific one, and of those, the one with the lowest metric, excluding our own interface.
+func findEndpointLUID(endpoint netip.Addr, ourLUID winipcfg.LUID, routes []winipcfg.MibIPforwardRow2) winipcfg.LUID {
+	longestPrefix := -1
+	lowestMetric := ^uint64(0)
+	luid := winipcfg.LUID(0)
+	for i := range routes {
+		prefix := routes[i].DestinationPrefix.Prefix()
+		if routes[i].InterfaceLUID == ourLUID || !prefix.Contains(endpoint) || prefix.Bits() < longestPrefix {
+			continue
+		}
+		ifrow, err := routes[i].InterfaceLUID.Interface()
+		if err != nil || ifrow.OperStatus != winipcfg.IfOperStatusUp {
+			continue
+		}
+		iface, err := routes[i].InterfaceLUID.IPInterface(routes[i].DestinationPrefix.RawPrefix.Family)
+		if err != nil {
+			continue
+		}
+		combinedMetric := uint64(routes[i].Metric) + uint64(iface.Metric)
+		if prefix.Bits() > longestPrefix || combinedMetric < lowestMetric {
+			longestPrefix = prefix.Bits()
+			lowestMetric = combinedMetric
+			luid = routes[i].InterfaceLUID
+		}
+	}
+	return luid
+}
+
+// endpointsMTU returns the largest MTU with which encapsulated packets to each of the endpoints still fit the MTU
+// of the interface through which they are sent, or 0 if none of the endpoints are reachable.
+func endpointsMTU(endpoints []netip.Addr, ourLUID winipcfg.LUID) (uint32, error) {
+	var routes4, routes6 []winipcfg.MibIPforwardRow2
+	var err error
+	mtu := uint32(0)
+	for _, endpoint := range endpoints {
+		routes, overhead := &routes4, uint32(20+encapsulationOverhead)
+		family := winipcfg.AddressFamily(windows.AF_INET)
+		if endpoint.Is6() {
+			routes, overhead = &routes6, uint32(40+encapsulationOverhead)
+			family = windows.AF_INET6
+		}
+		if *routes == nil {
+			*routes, err = winipcfg.GetIPForwardTable2(family)
+			if err != nil {
+				return 0, err
+			}
+		}
+		luid := findEndpointLUID(endpoint, ourLUID, *routes)
+		if luid == 0 {
+			continue
+		}
+		iface, err := luid.Interface()
+		if err != nil {
+			return 0, err
+		}
+		if iface.MTU > overhead && (mtu == 0 || iface.MTU-overhead < mtu) {
+			mtu = iface.MTU - overhead
+		}
+	}
+	return mtu, nil
+}
+
+// endpointAddrs returns the addresses of the endpoints of the peers, which have been resolved already.
+func endpointAddrs(conf *conf.Config) []netip.Addr {
+	var endpoints []netip.Addr
+	for _, peer := range conf.Peers {
+		if addr, err := netip.ParseAddr(peer.Endpoint.Host); err == nil {
+			endpoints = append(endpoints, addr.Unmap())
+		}
+	}
+	return endpoints
+}
+
+// mtuEndpoints holds the addresses of the endpoints to which the MTU is monitored, which recovery updates when the
+// endpoints are resolved again.
+type mtuEndpoints struct {
+	mu    sync.Mutex
+	addrs []netip.Addr
+}
+
+func newMTUEndpoints(conf *conf.Config) *mtuEndpoints {
+	return &mtuEndpoints{addrs: endpointAddrs(conf)}
+}
+
+func (endpoints *mtuEndpoints) get() []netip.Addr {
+	endpoints.mu.Lock()
+	defer endpoints.mu.Unlock()
+	return endpoints.addrs
+}
+
+func (endpoints *mtuEndpoints) update(conf *conf.Config) {
+	addrs := endpointAddrs(conf)
+	endpoints.mu.Lock()
+	endpoints.addrs = addrs
+	endpoints.mu.Unlock()
+}
+
+// pathMTUProber probes the path MTU to the endpoints when the routes to them change, for MTU = auto-probe, so
+// that the MTU also accounts for links beyond the local network, whose MTU the routes do not tell.
+type pathMTUProber struct {
+	endpoints []netip.Addr
+	ourLUID   winipcfg.LUID
+
+	mu        sync.Mutex
+	mtu       uint32
+	probing   bool
+	again     bool
+	listeners map[*probeListener]bool
+}
+
+type probeListener struct {
+	prober *pathMTUProber
+	cb     func() error
+}
+
+func newPathMTUProber(conf *conf.Config, ourLUID winipcfg.LUID) *pathMTUProber {
+	return &pathMTUProber{
+		endpoints: endpointAddrs(conf),
+		ourLUID:   ourLUID,
+		listeners: make(map[*probeListener]bool),
+	}
+}
+
+// result returns the MTU found by the last probe, or 0 if there has been none.
+func (prober *pathMTUProber) result() uint32 {
+	prober.mu.Lock()
+	defer prober.mu.Unlock()
+	return prober.mtu
+}
+
+// listen registers a function that is called after each probe, which should apply the new result.
+func (prober *pathMTUProber) listen(cb func() error) *probeListener {
+	listener := &probeListener{prober, cb}
+	prober.mu.Lock()
+	prober.listeners[listener] = true
+	prober.mu.Unlock()
+	return listener
+}
+
+func (listener *probeListener) Unregister() error {
+	listener.prober.mu.Lock()
+	delete(listener.prober.listeners, listener)
+	listener.prober.mu.Unlock()
+	return nil
+}
+
+// trigger starts a probe in the background, or, if one is running, another one after it.
+func (prober *pathMTUProber) trigger() {
+	if len(prober.endpoints) == 0 {
+		return
+	}
+	prober.mu.Lock()
+	defer prober.mu.Unlock()
+	if prober.probing {
+		prober.again = true
+		return
+	}
+	prober.probing = true
+	go prober.run()
+}
+
+func (prober *pathMTUProber) run() {
+	for {
+		mtu := prober.probe()
+		prober.mu.Lock()
+		if mtu > 0 {
+			prober.mtu = mtu
+		}
+		again := prober.again
+		prober.again = false
+		prober.probing = again
+		listeners := make([]*probeListener, 0, len(prober.listeners))
+		for listener := range prober.listeners {
+			listeners = append(listeners, listener)
+		}
+		prober.mu.Unlock()
+		for _, listener := range listeners {
+			listener.cb()
+		}
+		if !again {
+			return
+		}
+	}
+}
+
+// probe returns the smallest MTU with which encapsulated packets reach each of the endpoints, or 0 if it could not be
+// found for any of them.
+func (prober *pathMTUProber) probe() uint32 {
+	var routes4, routes6 []winipcfg.MibIPforwardRow2
+	var addresses4, addresses6 []winipcfg.MibUnicastIPAddressRow
+	mtu := uint32(0)
+	for _, endpoint := range prober.endpoints {
+		routes, addresses := &routes4, &addresses4
+		family := winipcfg.AddressFamily(windows.AF_INET)
+		minSize, headerSize := 576, uint32(20)
+		if endpoint.Is6() {
+			routes, addresses = &routes6, &addresses6
+			family = windows.AF_INET6
+			minSize, headerSize = 1280, 40
+		}
+		if *routes == nil {
+			var err error
+			*routes, err = winipcfg.GetIPForwardTable2(family)
+			if err != nil {
+				log.Printf("Unable to probe path MTU: %v", err)
+				return 0
+			}
+			*addresses, err = winipcfg.GetUnicastIPAddressTable(family)
+			if err != nil {
+				log.Printf("Unable to probe path MTU: %v", err)
+				return 0
+			}
+		}
+		luid := findEndpointLUID(endpoint, prober.ourLUID, *routes)
+		if luid == 0 {
+			continue
+		}
+		iface, err := luid.Interface()
+		if err != nil {
+			log.Printf("Unable to probe path MTU to %v: %v", endpoint, err)
+			continue
+		}
+		// Sending from an address of that interface keeps the echo requests out of the tunnel, as Windows uses
+		// the strong host model for sending. Link-local addresses would only reach endpoints on the same link.
+		var source netip.Addr
+		for i := range *addresses {
+			row := &(*addresses)[i]
+			if row.InterfaceLUID == luid && !row.SkipAsSource && row.DadState == winipcfg.DadStatePreferred && (endpoint.Is4() || !row.Address.Addr().IsLinkLocalUnicast()) {
+				source = row.Address.Addr()
+				break
+			}
+		}
+		if !source.IsValid() {
+			continue
+		}
+		size, err := pmtu.Probe(source, endpoint, minSize, int(iface.MTU))
+		if err != nil {
+			log.Printf("Unable to probe path MTU to %v: %v", endpoint, err)
+			continue
+		}
+		endpointMTU := uint32(size) - headerSize - encapsulationOverhead
+		log.Printf("Probed path MTU to %v: %d, allowing a tunnel MTU of %d", endpoint, size, endpointMTU)
+		if mtu == 0 || endpointMTU < mtu {
+			mtu = endpointMTU
+		}
+	}
+	return mtu
+}
+
+func monitorMTU(family winipcfg.AddressFamily, endpoints *mtuEndpoints, ourLUID winipcfg.LUID, prober *pathMTUProber) ([]winipcfg.ChangeCallback, error) {
 	var minMTU int
 	if family == windows.AF_INET {
 		minMTU = 576
@@ -60,21 +318,42 @@ func monitorMTU(family winipcfg.AddressFamily, ourLUID winipcfg.LUID) ([]winipcf
 	lastLUID := winipcfg.LUID(0)
 	lastIndex := ^uint32(0)
 	lastMTU := uint32(0)
+	lastRouteMTU := uint32(0)
 	doIt := func() error {
 		mu.Lock()
 		defer mu.Unlock()
-		err := findDefaultLUID(family, ourLUID, &lastLUID, &lastIndex)
-		if err != nil {
-			return err
-		}
 		mtu := uint32(0)
-		if lastLUID != 0 {
-			iface, err := lastLUID.Interface()
+		if addrs := endpoints.get(); len(addrs) > 0 {
+			var err error
+			mtu, err = endpointsMTU(addrs, ourLUID)
+			if err != nil {
+				return err
+			}
+		} else {
+			// Without endpoints, such as on a server, fall back to the interface of the default route, assuming the
+			// larger overhead of IPv6.
+			err := findDefaultLUID(family, ourLUID, &lastLUID, &lastIndex)
 			if err != nil {
 				return err
 			}
-			if iface.MTU > 0 {
-				mtu = iface.MTU
+			if lastLUID != 0 {
+				iface, err := lastLUID.Interface()
+				if err != nil {
+					return err
+				}
+				if iface.MTU > 80 {
+					mtu = iface.MTU - 80
+				}
+			}
+		}
+		if prober != nil {
+			// The routes only tell the MTU of the first link, so probe the path again whenever they change.
+			if mtu != lastRouteMTU {
+				lastRouteMTU = mtu
+				prober.trigger()
+			}
+			if probed := prober.result(); probed > 0 && (mtu == 0 || probed < mtu) {
+				mtu = probed
 			}
 		}
 		if mtu > 0 && lastMTU != mtu {
@@ -82,7 +361,7 @@ func monitorMTU(family winipcfg.AddressFamily, ourLUID winipcfg.LUID) ([]winipcf
 			if err != nil {
 				return err
 			}
-			iface.NLMTU = uint32(max(int(mtu)-80, minMTU))
+			iface.NLMTU = uint32(max(int(mtu), minMTU))
 			err = iface.Set()
 			if err != nil {
 				return err
@@ -95,22 +374,32 @@ func monitorMTU(family winipcfg.AddressFamily, ourLUID winipcfg.LUID) ([]winipcf
 	if err != nil {
 		return nil, err
 	}
+	callbacks := make([]winipcfg.ChangeCallback, 0, 3)
+	if prober != nil {
+		callbacks = append(callbacks, prober.listen(doIt))
+	}
 	cbr, err := winipcfg.RegisterRouteChangeCallback(func(notificationType winipcfg.MibNotificationType, route *winipcfg.MibIPforwardRow2) {
-		if route != nil && route.DestinationPrefix.PrefixLength == 0 {
+		if route != nil && route.InterfaceLUID != ourLUID && (len(endpoints.get()) > 0 || route.DestinationPrefix.PrefixLength == 0) {
 			doIt()
 		}
 	})
 	if err != nil {
+		for _, cb := range callbacks {
+			cb.Unregister()
+		}
 		return nil, err
 	}
+	callbacks = append(callbacks, cbr)
 	cbi, err := winipcfg.RegisterInterfaceChangeCallback(func(notificationType winipcfg.MibNotificationType, iface *winipcfg.MibIPInterfaceRow) {
 		if notificationType == winipcfg.MibParameterNotification {
 			doIt()
 		}
 	})
 	if err != nil {
-		cbr.Unregister()
+		for _, cb := range callbacks {
+			cb.Unregister()
+		}
 		return nil, err
 	}
-	return []winipcfg.ChangeCallback{cbr, cbi}, nil
+	return append(callbacks, cbi), nil
 }
@@ -0,0 +1,140 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2026 WireGuard LLC. All Rights Reserved.
+ */
+
+package nlm
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"golang.org/x/sys/windows"
+)
+
+// ConnectivityChangeCallback is registered with RegisterConnectivityChangeCallback.
+type ConnectivityChangeCallback struct {
+	sink *eventSink
+	stop chan struct{}
+	done chan error
+}
+
+// eventSink implements INetworkListManagerEvents, which is handed to the Network List Manager, which holds on to it
+// until it is unadvised.
+type eventSink struct {
+	vtbl     *eventSinkVtbl
+	refs     atomic.Int32
+	callback func()
+}
+
+type eventSinkVtbl struct {
+	queryInterface      uintptr
+	addRef              uintptr
+	release             uintptr
+	connectivityChanged uintptr
+}
+
+var eventSinkVtable = sync.OnceValue(func() *eventSinkVtbl {
+	return &eventSinkVtbl{
+		queryInterface:      windows.NewCallback(eventSinkQueryInterface),
+		addRef:              windows.NewCallback(eventSinkAddRef),
+		release:             windows.NewCallback(eventSinkRelease),
+		connectivityChanged: windows.NewCallback(eventSinkConnectivityChanged),
+	}
+})
+
+func eventSinkQueryInterface(sink *eventSink, iid *windows.GUID, object **eventSink) uintptr {
+	if *iid != _IID_IUnknown && *iid != _IID_INetworkListManagerEvents {
+		*object = nil
+		return _E_NOINTERFACE
+	}
+	sink.refs.Add(1)
+	*object = sink
+	return 0
+}
+
+func eventSinkAddRef(sink *eventSink) uintptr {
+	return uintptr(sink.refs.Add(1))
+}
+
+// eventSinkRelease only counts, as the sink is kept alive by its ConnectivityChangeCallback.
+func eventSinkRelease(sink *eventSink) uintptr {
+	return uintptr(sink.refs.Add(-1))
+}
+
+func eventSinkConnectivityChanged(sink *eventSink, connectivity uint32) uintptr {
+	sink.callback()
+	return 0
+}
+
+// RegisterConnectivityChangeCallback registers a callback that Windows calls whenever the connectivity of the
+// machine changes, such as when it joins or leaves a network, or the Internet becomes reachable through it. The
+// callback is called on a thread of Windows and should return quickly. Returned ConnectivityChangeCallback.Unregister
+// method should be used to unregister.
+func RegisterConnectivityChangeCallback(callback func()) (*ConnectivityChangeCallback, error) {
+	cb := &ConnectivityChangeCallback{
+		sink: &eventSink{vtbl: eventSinkVtable(), callback: callback},
+		stop: make(chan struct{}),
+		done: make(chan error, 1),
+	}
+	registered := make(chan error, 1)
+	go cb.run(registered)
+	err := <-registered
+	if err != nil {
+		return nil, err
+	}
+	return cb, nil
+}
+
+// Unregister unregisters the callback.
+func (cb *ConnectivityChangeCallback) Unregister() error {
+	close(cb.stop)
+	return <-cb.done
+}
+
+// run advises the connection point of the Network List Manager of the sink, and unadvises it once stopped, on a
+// thread that stays in the multithreaded apartment in between, so that the events are delivered without a message
+// loop.
+func (cb *ConnectivityChangeCallback) run(registered chan<- error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	err := windows.CoInitializeEx(0, windows.COINIT_MULTITHREADED)
+	if err != nil && err != _S_FALSE {
+		registered <- err
+		return
+	}
+	defer windows.CoUninitialize()
+
+	var manager *comObject
+	err = coCreateInstance(&_CLSID_NetworkListManager, nil, _CLSCTX_ALL, &_IID_INetworkListManager, &manager)
+	if err != nil {
+		registered <- err
+		return
+	}
+	defer manager.release()
+	var container *comObject
+	err = manager.queryInterface(&_IID_IConnectionPointContainer, &container)
+	if err != nil {
+		registered <- err
+		return
+	}
+	defer container.release()
+	var connectionPoint *comObject
+	err = container.findConnectionPoint(&_IID_INetworkListManagerEvents, &connectionPoint)
+	if err != nil {
+		registered <- err
+		return
+	}
+	defer connectionPoint.release()
+	var cookie uint32
+	err = connectionPoint.advise(cb.sink, &cookie)
+	if err != nil {
+		registered <- err
+		return
+	}
+	registered <- nil
+
+	<-cb.stop
+	cb.done <- connectionPoint.unadvise(cookie)
+}
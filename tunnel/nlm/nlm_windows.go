@@ -0,0 +1,121 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2026 WireGuard LLC. All Rights Reserved.
+ */
+
+package nlm
+
+import (
+	"errors"
+	"runtime"
+
+	"golang.org/x/sys/windows"
+)
+
+// Category is an NLM_NETWORK_CATEGORY.
+type Category uint32
+
+const (
+	CategoryPublic Category = iota
+	CategoryPrivate
+	CategoryDomainAuthenticated
+)
+
+func (category Category) String() string {
+	switch category {
+	case CategoryPublic:
+		return "public"
+	case CategoryPrivate:
+		return "private"
+	case CategoryDomainAuthenticated:
+		return "domain"
+	}
+	return "unknown"
+}
+
+var (
+	ErrNoNetwork                = errors.New("Windows has not identified the network of the adapter")
+	ErrDomainCategoryUnchanging = errors.New("The category of a domain network cannot be changed")
+)
+
+// SetCategory sets the category of the network to which the adapter is connected. Windows identifies the network
+// only some time after the adapter has been given addresses, and until then, ErrNoNetwork is returned.
+func SetCategory(adapter windows.GUID, category Category) error {
+	return withNetwork(adapter, func(network *comObject) error {
+		var current Category
+		err := network.getCategory(&current)
+		if err != nil {
+			return err
+		}
+		if current == category {
+			return nil
+		}
+		if current == CategoryDomainAuthenticated {
+			return ErrDomainCategoryUnchanging
+		}
+		return network.setCategory(category)
+	})
+}
+
+// NetworkID returns the identifier of the network to which the adapter is connected, which Windows keeps for the
+// network across connections, or ErrNoNetwork if Windows has not identified it.
+func NetworkID(adapter windows.GUID) (windows.GUID, error) {
+	var id windows.GUID
+	err := withNetwork(adapter, func(network *comObject) error {
+		return network.getNetworkId(&id)
+	})
+	return id, err
+}
+
+// withNetwork calls fn with the network to which the adapter is connected.
+func withNetwork(adapter windows.GUID, fn func(network *comObject) error) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	err := windows.CoInitializeEx(0, windows.COINIT_MULTITHREADED)
+	if err != nil && err != _S_FALSE {
+		return err
+	}
+	defer windows.CoUninitialize()
+
+	var manager *comObject
+	err = coCreateInstance(&_CLSID_NetworkListManager, nil, _CLSCTX_ALL, &_IID_INetworkListManager, &manager)
+	if err != nil {
+		return err
+	}
+	defer manager.release()
+	var connections *comObject
+	err = manager.getNetworkConnections(&connections)
+	if err != nil {
+		return err
+	}
+	defer connections.release()
+	for {
+		var connection *comObject
+		var fetched uint32
+		err = connections.next(&connection, &fetched)
+		if err == _S_FALSE || (err == nil && fetched == 0) {
+			return ErrNoNetwork
+		} else if err != nil {
+			return err
+		}
+		found, err := withNetworkOfConnection(connection, adapter, fn)
+		connection.release()
+		if found {
+			return err
+		}
+	}
+}
+
+func withNetworkOfConnection(connection *comObject, adapter windows.GUID, fn func(network *comObject) error) (bool, error) {
+	var adapterId windows.GUID
+	if connection.getAdapterId(&adapterId) != nil || adapterId != adapter {
+		return false, nil
+	}
+	var network *comObject
+	err := connection.getNetwork(&network)
+	if err != nil {
+		return true, err
+	}
+	defer network.release()
+	return true, fn(network)
+}
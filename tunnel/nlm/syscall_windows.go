@@ -0,0 +1,148 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2026 WireGuard LLC. All Rights Reserved.
+ */
+
+package nlm
+
+import (
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+const (
+	_CLSCTX_ALL = 0x17
+
+	_S_FALSE = syscall.Errno(1)
+
+	_E_NOINTERFACE = 0x80004002
+)
+
+var (
+	// dcb00c01-570f-4a9b-8d69-199fdba5723b
+	_CLSID_NetworkListManager = windows.GUID{
+		Data1: 0xdcb00c01,
+		Data2: 0x570f,
+		Data3: 0x4a9b,
+		Data4: [8]byte{0x8d, 0x69, 0x19, 0x9f, 0xdb, 0xa5, 0x72, 0x3b},
+	}
+
+	// dcb00000-570f-4a9b-8d69-199fdba5723b
+	_IID_INetworkListManager = windows.GUID{
+		Data1: 0xdcb00000,
+		Data2: 0x570f,
+		Data3: 0x4a9b,
+		Data4: [8]byte{0x8d, 0x69, 0x19, 0x9f, 0xdb, 0xa5, 0x72, 0x3b},
+	}
+
+	// dcb00001-570f-4a9b-8d69-199fdba5723b
+	_IID_INetworkListManagerEvents = windows.GUID{
+		Data1: 0xdcb00001,
+		Data2: 0x570f,
+		Data3: 0x4a9b,
+		Data4: [8]byte{0x8d, 0x69, 0x19, 0x9f, 0xdb, 0xa5, 0x72, 0x3b},
+	}
+
+	// 00000000-0000-0000-c000-000000000046
+	_IID_IUnknown = windows.GUID{
+		Data4: [8]byte{0xc0, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x46},
+	}
+
+	// b196b284-bab4-101a-b69c-00aa00341d07
+	_IID_IConnectionPointContainer = windows.GUID{
+		Data1: 0xb196b284,
+		Data2: 0xbab4,
+		Data3: 0x101a,
+		Data4: [8]byte{0xb6, 0x9c, 0x00, 0xaa, 0x00, 0x34, 0x1d, 0x07},
+	}
+)
+
+// Vtable indices, which for these IDispatch-derived interfaces start after the three methods of IUnknown and the
+// four of IDispatch.
+const (
+	methodQueryInterface = 0
+	methodRelease        = 2
+
+	methodINetworkListManagerGetNetworkConnections = 9
+
+	methodIEnumNetworkConnectionsNext = 8
+
+	methodINetworkConnectionGetNetwork   = 7
+	methodINetworkConnectionGetAdapterId = 12
+
+	methodINetworkGetNetworkId = 11
+	methodINetworkGetCategory  = 18
+	methodINetworkSetCategory  = 19
+)
+
+// Vtable indices of the connection point interfaces, which derive from IUnknown alone.
+const (
+	methodIConnectionPointContainerFindConnectionPoint = 4
+
+	methodIConnectionPointAdvise   = 5
+	methodIConnectionPointUnadvise = 6
+)
+
+type comObject struct {
+	vtbl *[32]uintptr
+}
+
+func hresult(r uintptr, _ uintptr, _ syscall.Errno) error {
+	if r != 0 {
+		return syscall.Errno(r)
+	}
+	return nil
+}
+
+func (o *comObject) release() {
+	syscall.SyscallN(o.vtbl[methodRelease], uintptr(unsafe.Pointer(o)))
+}
+
+func (o *comObject) queryInterface(iid *windows.GUID, object **comObject) error {
+	return hresult(syscall.SyscallN(o.vtbl[methodQueryInterface], uintptr(unsafe.Pointer(o)), uintptr(unsafe.Pointer(iid)), uintptr(unsafe.Pointer(object))))
+}
+
+func (o *comObject) getNetworkConnections(connections **comObject) error {
+	return hresult(syscall.SyscallN(o.vtbl[methodINetworkListManagerGetNetworkConnections], uintptr(unsafe.Pointer(o)), uintptr(unsafe.Pointer(connections))))
+}
+
+// next returns _S_FALSE once there are no more connections.
+func (o *comObject) next(connection **comObject, fetched *uint32) error {
+	return hresult(syscall.SyscallN(o.vtbl[methodIEnumNetworkConnectionsNext], uintptr(unsafe.Pointer(o)), 1, uintptr(unsafe.Pointer(connection)), uintptr(unsafe.Pointer(fetched))))
+}
+
+func (o *comObject) getNetwork(network **comObject) error {
+	return hresult(syscall.SyscallN(o.vtbl[methodINetworkConnectionGetNetwork], uintptr(unsafe.Pointer(o)), uintptr(unsafe.Pointer(network))))
+}
+
+func (o *comObject) getAdapterId(adapterId *windows.GUID) error {
+	return hresult(syscall.SyscallN(o.vtbl[methodINetworkConnectionGetAdapterId], uintptr(unsafe.Pointer(o)), uintptr(unsafe.Pointer(adapterId))))
+}
+
+func (o *comObject) getNetworkId(networkId *windows.GUID) error {
+	return hresult(syscall.SyscallN(o.vtbl[methodINetworkGetNetworkId], uintptr(unsafe.Pointer(o)), uintptr(unsafe.Pointer(networkId))))
+}
+
+func (o *comObject) getCategory(category *Category) error {
+	return hresult(syscall.SyscallN(o.vtbl[methodINetworkGetCategory], uintptr(unsafe.Pointer(o)), uintptr(unsafe.Pointer(category))))
+}
+
+func (o *comObject) setCategory(category Category) error {
+	return hresult(syscall.SyscallN(o.vtbl[methodINetworkSetCategory], uintptr(unsafe.Pointer(o)), uintptr(category)))
+}
+
+func (o *comObject) findConnectionPoint(iid *windows.GUID, connectionPoint **comObject) error {
+	return hresult(syscall.SyscallN(o.vtbl[methodIConnectionPointContainerFindConnectionPoint], uintptr(unsafe.Pointer(o)), uintptr(unsafe.Pointer(iid)), uintptr(unsafe.Pointer(connectionPoint))))
+}
+
+func (o *comObject) advise(sink *eventSink, cookie *uint32) error {
+	return hresult(syscall.SyscallN(o.vtbl[methodIConnectionPointAdvise], uintptr(unsafe.Pointer(o)), uintptr(unsafe.Pointer(sink)), uintptr(unsafe.Pointer(cookie))))
+}
+
+func (o *comObject) unadvise(cookie uint32) error {
+	return hresult(syscall.SyscallN(o.vtbl[methodIConnectionPointUnadvise], uintptr(unsafe.Pointer(o)), uintptr(cookie)))
+}
+
+//sys	coCreateInstance(clsid *windows.GUID, outer unsafe.Pointer, context uint32, iid *windows.GUID, object **comObject) (ret error) = ole32.CoCreateInstance
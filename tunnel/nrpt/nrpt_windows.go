@@ -0,0 +1,70 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2026 WireGuard LLC. All Rights Reserved.
+ */
+
+package nrpt
+
+import (
+	"errors"
+	"fmt"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+)
+
+const (
+	localPolicyPath = `SYSTEM\CurrentControlSet\Services\Dnscache\Parameters\DnsPolicyConfig`
+	groupPolicyPath = `SOFTWARE\Policies\Microsoft\Windows NT\DNSClient\DnsPolicyConfig`
+
+	ruleVersion             = 2
+	configOptionsGenericDNS = 0x8
+)
+
+// Add adds the rule to the local Name Resolution Policy Table, replacing any previous rule of the same tunnel.
+func Add(rule *Rule) error {
+	key, _, err := registry.CreateKey(registry.LOCAL_MACHINE, localPolicyPath+`\`+rule.Key, registry.SET_VALUE)
+	if err != nil {
+		return fmt.Errorf("unable to create NRPT rule: %w", err)
+	}
+	defer key.Close()
+	for _, set := range []func() error{
+		func() error { return key.SetDWordValue("Version", ruleVersion) },
+		func() error { return key.SetStringsValue("Name", rule.Namespaces) },
+		func() error { return key.SetStringValue("GenericDNSServers", rule.GenericDNSServers()) },
+		func() error { return key.SetDWordValue("ConfigOptions", configOptionsGenericDNS) },
+		func() error { return key.SetStringValue("IPSECCARestriction", "") },
+		func() error { return key.SetStringValue("Comment", rule.Comment) },
+	} {
+		err = set()
+		if err != nil {
+			return fmt.Errorf("unable to set NRPT rule value: %w", err)
+		}
+	}
+	dnsFlushResolverCache()
+	return nil
+}
+
+// Remove removes the rule of the tunnel from the local Name Resolution Policy Table, if there is one.
+func Remove(tunnelName string) error {
+	err := registry.DeleteKey(registry.LOCAL_MACHINE, localPolicyPath+`\`+ruleKey(tunnelName))
+	if errors.Is(err, windows.ERROR_FILE_NOT_FOUND) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("unable to delete NRPT rule: %w", err)
+	}
+	dnsFlushResolverCache()
+	return nil
+}
+
+// GroupPolicyActive reports whether Group Policy configures NRPT rules, in which case the rules of the local table
+// are ignored by the DNS client.
+func GroupPolicyActive() bool {
+	key, err := registry.OpenKey(registry.LOCAL_MACHINE, groupPolicyPath, registry.QUERY_VALUE)
+	if err != nil {
+		return false
+	}
+	defer key.Close()
+	info, err := key.Stat()
+	return err == nil && info.SubKeyCount > 0
+}
@@ -0,0 +1,79 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2026 WireGuard LLC. All Rights Reserved.
+ */
+
+package nrpt
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"strings"
+
+	"golang.org/x/crypto/blake2s"
+)
+
+const ruleKeyLabel = "WireGuard NRPT rule v1"
+
+// Rule is a Name Resolution Policy Table rule that sends queries for the names in and under a set of domains to
+// the DNS servers of a tunnel, instead of to those of whichever interface Windows happens to pick.
+type Rule struct {
+	// Key is the name of the rule's registry key, which is derived from the tunnel name, so that a rule left
+	// behind by a crash is replaced when the tunnel starts again.
+	Key string
+	// Namespaces are the names to which the rule applies, where a leading dot matches all subdomains.
+	Namespaces []string
+	// Servers are the DNS servers that the names are resolved with.
+	Servers []netip.Addr
+	// Comment identifies the tunnel that created the rule to those inspecting the table.
+	Comment string
+}
+
+// NewRule returns a rule sending queries for the given domains and all of their subdomains to the given servers.
+func NewRule(tunnelName string, domains []string, servers []netip.Addr) (*Rule, error) {
+	if len(domains) == 0 {
+		return nil, errors.New("No domains given")
+	}
+	if len(servers) == 0 {
+		return nil, errors.New("No DNS servers given")
+	}
+	rule := &Rule{
+		Key:     ruleKey(tunnelName),
+		Servers: servers,
+		Comment: fmt.Sprintf("WireGuard tunnel: %s", tunnelName),
+	}
+	seen := make(map[string]bool, len(domains))
+	for _, domain := range domains {
+		domain = strings.TrimSuffix(strings.TrimPrefix(strings.ToLower(domain), "."), ".")
+		if len(domain) == 0 || seen[domain] {
+			continue
+		}
+		seen[domain] = true
+		// A suffix rule does not match the domain itself, so both forms are needed.
+		rule.Namespaces = append(rule.Namespaces, domain, "."+domain)
+	}
+	if len(rule.Namespaces) == 0 {
+		return nil, errors.New("No valid domains given")
+	}
+	return rule, nil
+}
+
+// GenericDNSServers returns the servers in the form expected by the GenericDNSServers registry value.
+func (rule *Rule) GenericDNSServers() string {
+	servers := make([]string, len(rule.Servers))
+	for i, server := range rule.Servers {
+		servers[i] = server.String()
+	}
+	return strings.Join(servers, ";")
+}
+
+func ruleKey(tunnelName string) string {
+	b2, _ := blake2s.New256(nil)
+	b2.Write([]byte(ruleKeyLabel))
+	b2.Write([]byte(tunnelName))
+	h := b2.Sum(nil)
+	h[6] = (h[6] & 0x0f) | 0x50
+	h[8] = (h[8] & 0x3f) | 0x80
+	return fmt.Sprintf("{%08X-%04X-%04X-%04X-%012X}", h[0:4], h[4:6], h[6:8], h[8:10], h[10:16])
+}
@@ -0,0 +1,69 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2026 WireGuard LLC. All Rights Reserved.
+ */
+
+package nrpt
+
+import (
+	"net/netip"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestNewRule(t *testing.T) {
+	servers := []netip.Addr{netip.MustParseAddr("10.0.0.1"), netip.MustParseAddr("fd00::1")}
+	rule, err := NewRule("office", []string{"Corp.Example.", ".example.internal", "corp.example"}, servers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"corp.example", ".corp.example", "example.internal", ".example.internal"}
+	if !reflect.DeepEqual(rule.Namespaces, expected) {
+		t.Errorf("Namespaces are %v, expected %v", rule.Namespaces, expected)
+	}
+	if servers := rule.GenericDNSServers(); servers != "10.0.0.1;fd00::1" {
+		t.Errorf("GenericDNSServers is %q", servers)
+	}
+	if rule.Comment != "WireGuard tunnel: office" {
+		t.Errorf("Comment is %q", rule.Comment)
+	}
+	if !regexp.MustCompile(`^\{[0-9A-F]{8}-[0-9A-F]{4}-5[0-9A-F]{3}-[89AB][0-9A-F]{3}-[0-9A-F]{12}\}$`).MatchString(rule.Key) {
+		t.Errorf("Key %q is not a GUID", rule.Key)
+	}
+}
+
+func TestRuleKeyIsStable(t *testing.T) {
+	servers := []netip.Addr{netip.MustParseAddr("10.0.0.1")}
+	a, err := NewRule("office", []string{"corp.example"}, servers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewRule("office", []string{"example.internal"}, servers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := NewRule("home", []string{"corp.example"}, servers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Key != b.Key {
+		t.Errorf("Keys of the same tunnel differ: %q and %q", a.Key, b.Key)
+	}
+	if a.Key == c.Key {
+		t.Errorf("Keys of different tunnels are both %q", a.Key)
+	}
+}
+
+func TestNewRuleRequiresDomainsAndServers(t *testing.T) {
+	servers := []netip.Addr{netip.MustParseAddr("10.0.0.1")}
+	if _, err := NewRule("office", nil, servers); err == nil {
+		t.Error("Error was expected without domains")
+	}
+	if _, err := NewRule("office", []string{"."}, servers); err == nil {
+		t.Error("Error was expected without valid domains")
+	}
+	if _, err := NewRule("office", []string{"corp.example"}, nil); err == nil {
+		t.Error("Error was expected without servers")
+	}
+}
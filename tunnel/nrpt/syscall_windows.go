@@ -0,0 +1,9 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2026 WireGuard LLC. All Rights Reserved.
+ */
+
+package nrpt
+
+// Undocumented, but used by ipconfig /flushdns.
+//sys	dnsFlushResolverCache() (ret bool) = dnsapi.DnsFlushResolverCache
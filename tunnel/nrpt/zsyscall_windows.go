@@ -0,0 +1,50 @@
+// Code generated by 'go generate'; DO NOT EDIT.
+
+package nrpt
+
+import (
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var _ unsafe.Pointer
+
+// Do the interface allocations only once for common
+// Errno values.
+const (
+	errnoERROR_IO_PENDING = 997
+)
+
+var (
+	errERROR_IO_PENDING error = syscall.Errno(errnoERROR_IO_PENDING)
+	errERROR_EINVAL     error = syscall.EINVAL
+)
+
+// errnoErr returns common boxed Errno values, to prevent
+// allocations at runtime.
+func errnoErr(e syscall.Errno) error {
+	switch e {
+	case 0:
+		return errERROR_EINVAL
+	case errnoERROR_IO_PENDING:
+		return errERROR_IO_PENDING
+	}
+	// TODO: add more here, after collecting data on the common
+	// error values see on Windows. (perhaps when running
+	// all.bat?)
+	return e
+}
+
+var (
+	moddnsapi = windows.NewLazySystemDLL("dnsapi.dll")
+
+	procDnsFlushResolverCache = moddnsapi.NewProc("DnsFlushResolverCache")
+)
+
+func dnsFlushResolverCache() (ret bool) {
+	r0, _, _ := syscall.SyscallN(procDnsFlushResolverCache.Addr())
+	ret = r0 != 0
+	return
+}
@@ -8,19 +8,26 @@ package tunnel
 import (
 	"log"
 	"net/netip"
+	"slices"
 	"strings"
 	"unsafe"
 
 	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
 	"golang.org/x/sys/windows/svc/mgr"
 	"golang.zx2c4.com/wireguard/windows/conf"
+	"golang.zx2c4.com/wireguard/windows/tunnel/firewall"
+	"golang.zx2c4.com/wireguard/windows/tunnel/nrpt"
 	"golang.zx2c4.com/wireguard/windows/tunnel/winipcfg"
 )
 
-func evaluateStaticPitfalls() {
+func evaluateStaticPitfalls(conf *conf.Config) {
 	go func() {
 		pitfallDnsCacheDisabled()
 		pitfallVirtioNetworkDriver()
+		pitfallEncryptedDNS(&conf.Interface)
+		pitfallRoutesOutsideAllowedIPs(conf)
+		pitfallDuplicateAdapterGUID(conf)
 	}()
 }
 
@@ -30,6 +37,85 @@ func evaluateDynamicPitfalls(family winipcfg.AddressFamily, conf *conf.Config, l
 	}()
 }
 
+func pitfallLockdownUnapproved(tunnelName string) {
+	enabled, serviceNames, err := firewall.LockdownStatus()
+	if err != nil || !enabled {
+		return
+	}
+	serviceName, err := conf.ServiceNameOfTunnel(tunnelName)
+	if err != nil || slices.Contains(serviceNames, serviceName) {
+		return
+	}
+
+	log.Println("Warning: lockdown mode is enabled, but this tunnel is not approved, so its traffic will remain blocked")
+}
+
+func pitfallNRPTGroupPolicy() {
+	if !nrpt.GroupPolicyActive() {
+		return
+	}
+
+	log.Println("Warning: Group Policy configures the Name Resolution Policy Table, so the NRPT rule for SplitDNS will be ignored")
+}
+
+func pitfallEncryptedDNS(interfaze *conf.Interface) {
+	hasTemplates := false
+	for _, encrypted := range interfaze.EncryptedDNS {
+		if len(encrypted.Template) > 0 {
+			hasTemplates = true
+		} else {
+			log.Printf("Warning: Windows does not support DNS over TLS for the DNS servers of an interface, so queries to %s will not be encrypted", encrypted.Server)
+		}
+	}
+	if !hasTemplates {
+		return
+	}
+	if !winipcfg.EncryptedDNSSupported() {
+		log.Println("Warning: DNS over HTTPS for the DNS servers of an interface requires Windows 11, so queries to the tunnel's DNS servers will not be encrypted")
+		return
+	}
+	key, err := registry.OpenKey(registry.LOCAL_MACHINE, `SOFTWARE\Policies\Microsoft\Windows NT\DNSClient`, registry.QUERY_VALUE)
+	if err != nil {
+		return
+	}
+	defer key.Close()
+	if policy, _, err := key.GetIntegerValue("DoHPolicy"); err == nil && policy == 1 {
+		log.Println("Warning: DNS over HTTPS is prohibited by Group Policy, so queries to the tunnel's DNS servers will not be encrypted")
+	}
+}
+
+func pitfallRoutesOutsideAllowedIPs(conf *conf.Config) {
+	for _, route := range conf.Interface.Routes {
+		covered := false
+		for _, peer := range conf.Peers {
+			for _, allowedip := range peer.AllowedIPs {
+				if allowedip.Bits() <= route.Destination.Bits() && allowedip.Masked().Contains(route.Destination.Addr()) {
+					covered = true
+				}
+			}
+		}
+		if !covered {
+			log.Printf("Warning: the route to %s is not within the allowed IPs of any peer, so its traffic will be dropped", route.Destination)
+		}
+	}
+}
+
+func pitfallDuplicateAdapterGUID(conf *conf.Config) {
+	if len(conf.Interface.AdapterGUID) == 0 {
+		return
+	}
+	guid, err := windows.GUIDFromString(conf.Interface.AdapterGUID)
+	if err != nil {
+		return
+	}
+	other := tunnelPinningAdapterGUID(conf.Name, &guid)
+	if len(other) == 0 {
+		return
+	}
+
+	log.Printf("Warning: the pinned adapter GUID is also pinned by tunnel %s, so only one of the two can run at a time", other)
+}
+
 func pitfallDnsCacheDisabled() {
 	scm, err := mgr.Connect()
 	if err != nil {
@@ -0,0 +1,147 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2026 WireGuard LLC. All Rights Reserved.
+ */
+
+package pmtu
+
+import (
+	"errors"
+	"net/netip"
+	"strconv"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+const (
+	ipv4HeaderSize = 20
+	ipv6HeaderSize = 40
+	icmpHeaderSize = 8
+
+	probeTimeoutMilliseconds = 1000
+	probeAttempts            = 2
+)
+
+var (
+	ErrNoReply        = errors.New("The endpoint does not answer ICMP echo requests")
+	ErrFamilyMismatch = errors.New("Path MTU probing requires addresses of the same family")
+)
+
+type prober struct {
+	handle     windows.Handle
+	headerSize int
+
+	// Of IPv4 probes
+	source4      uint32
+	destination4 uint32
+
+	// Of IPv6 probes
+	source6      *windows.RawSockaddrInet6
+	destination6 *windows.RawSockaddrInet6
+}
+
+// Probe returns the size of the largest IP packet, between minSize and maxSize, that reaches the destination
+// without being fragmented when sent from the source address, using ICMP echo requests with the don't fragment flag,
+// or ICMPv6 echo requests, which routers never fragment. Requests that go unanswered are taken to be too large, since
+// middleboxes may drop them silently, so the destination must first answer a request of minSize, or else ErrNoReply
+// is returned.
+func Probe(source, destination netip.Addr, minSize, maxSize int) (int, error) {
+	if source.Is4() != destination.Is4() {
+		return 0, ErrFamilyMismatch
+	}
+	p := &prober{headerSize: ipv4HeaderSize}
+	if destination.Is6() {
+		p.headerSize = ipv6HeaderSize
+	}
+	if minSize <= p.headerSize+icmpHeaderSize || maxSize < minSize {
+		return 0, errors.New("Invalid probe sizes")
+	}
+	var err error
+	if destination.Is4() {
+		p.handle, err = icmpCreateFile()
+		if err != nil {
+			return 0, err
+		}
+		source4, destination4 := source.As4(), destination.As4()
+		p.source4 = *(*uint32)(unsafe.Pointer(&source4[0]))
+		p.destination4 = *(*uint32)(unsafe.Pointer(&destination4[0]))
+	} else {
+		p.handle, err = icmp6CreateFile()
+		if err != nil {
+			return 0, err
+		}
+		p.source6 = sockaddrInet6(source)
+		p.destination6 = sockaddrInet6(destination)
+	}
+	defer icmpCloseHandle(p.handle)
+
+	fits, err := p.fits(minSize)
+	if err != nil {
+		return 0, err
+	}
+	if !fits {
+		return 0, ErrNoReply
+	}
+	low, high := minSize, maxSize
+	for low < high {
+		size := (low + high + 1) / 2
+		fits, err = p.fits(size)
+		if err != nil {
+			return 0, err
+		}
+		if fits {
+			low = size
+		} else {
+			high = size - 1
+		}
+	}
+	return low, nil
+}
+
+func sockaddrInet6(addr netip.Addr) *windows.RawSockaddrInet6 {
+	sa := &windows.RawSockaddrInet6{Family: windows.AF_INET6, Addr: addr.As16()}
+	if zone, err := strconv.ParseUint(addr.Zone(), 10, 32); err == nil {
+		sa.Scope_id = uint32(zone)
+	}
+	return sa
+}
+
+// fits reports whether an echo request making for a packet of the given size is answered.
+func (p *prober) fits(size int) (bool, error) {
+	dataSize := size - p.headerSize - icmpHeaderSize
+	data := make([]byte, dataSize)
+	replySize := unsafe.Sizeof(icmpEchoReply{})
+	if p.source6 != nil {
+		replySize = unsafe.Sizeof(icmpv6EchoReply{})
+	}
+	reply := make([]byte, int(replySize)+dataSize+8+16)
+	options := ipOptionInformation{ttl: 128, flags: _IP_FLAG_DF}
+	for range probeAttempts {
+		var replies uint32
+		var err error
+		var status uint32
+		if p.source6 != nil {
+			replies, err = icmp6SendEcho2(p.handle, 0, 0, 0, p.source6, p.destination6, unsafe.Pointer(&data[0]), uint16(dataSize), &options, unsafe.Pointer(&reply[0]), uint32(len(reply)), probeTimeoutMilliseconds)
+			status = (*icmpv6EchoReply)(unsafe.Pointer(&reply[0])).status
+		} else {
+			replies, err = icmpSendEcho2Ex(p.handle, 0, 0, 0, p.source4, p.destination4, unsafe.Pointer(&data[0]), uint16(dataSize), &options, unsafe.Pointer(&reply[0]), uint32(len(reply)), probeTimeoutMilliseconds)
+			status = (*icmpEchoReply)(unsafe.Pointer(&reply[0])).status
+		}
+		if replies > 0 {
+			err = syscall.Errno(status)
+			if err == syscall.Errno(_IP_SUCCESS) {
+				return true, nil
+			}
+		}
+		switch err {
+		case _IP_PACKET_TOO_BIG:
+			return false, nil
+		case _IP_REQ_TIMED_OUT:
+			continue
+		}
+		return false, err
+	}
+	return false, nil
+}
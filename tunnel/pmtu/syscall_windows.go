@@ -0,0 +1,51 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2026 WireGuard LLC. All Rights Reserved.
+ */
+
+package pmtu
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+const (
+	_IP_SUCCESS        = 0
+	_IP_PACKET_TOO_BIG = syscall.Errno(11009)
+	_IP_REQ_TIMED_OUT  = syscall.Errno(11010)
+
+	_IP_FLAG_DF = 0x2
+)
+
+type ipOptionInformation struct {
+	ttl         uint8
+	tos         uint8
+	flags       uint8
+	optionsSize uint8
+	optionsData *byte
+}
+
+type icmpEchoReply struct {
+	address       uint32
+	status        uint32
+	roundTripTime uint32
+	dataSize      uint16
+	reserved      uint16
+	data          unsafe.Pointer
+	options       ipOptionInformation
+}
+
+// icmpv6EchoReply is ICMPV6_ECHO_REPLY, whose Address field, an IPV6_ADDRESS_EX, is packed.
+type icmpv6EchoReply struct {
+	address       [26]byte
+	_             [2]byte
+	status        uint32
+	roundTripTime uint32
+}
+
+//sys	icmpCreateFile() (handle windows.Handle, err error) [failretval==windows.InvalidHandle] = iphlpapi.IcmpCreateFile
+//sys	icmpCloseHandle(handle windows.Handle) (err error) = iphlpapi.IcmpCloseHandle
+//sys	icmpSendEcho2Ex(handle windows.Handle, event windows.Handle, apcRoutine uintptr, apcContext uintptr, sourceAddress uint32, destinationAddress uint32, requestData unsafe.Pointer, requestSize uint16, requestOptions *ipOptionInformation, replyBuffer unsafe.Pointer, replySize uint32, timeout uint32) (replies uint32, err error) [failretval==0] = iphlpapi.IcmpSendEcho2Ex
+//sys	icmp6CreateFile() (handle windows.Handle, err error) [failretval==windows.InvalidHandle] = iphlpapi.Icmp6CreateFile
+//sys	icmp6SendEcho2(handle windows.Handle, event windows.Handle, apcRoutine uintptr, apcContext uintptr, sourceAddress *windows.RawSockaddrInet6, destinationAddress *windows.RawSockaddrInet6, requestData unsafe.Pointer, requestSize uint16, requestOptions *ipOptionInformation, replyBuffer unsafe.Pointer, replySize uint32, timeout uint32) (replies uint32, err error) [failretval==0] = iphlpapi.Icmp6SendEcho2
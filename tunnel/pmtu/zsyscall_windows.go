@@ -0,0 +1,92 @@
+// Code generated by 'go generate'; DO NOT EDIT.
+
+package pmtu
+
+import (
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var _ unsafe.Pointer
+
+// Do the interface allocations only once for common
+// Errno values.
+const (
+	errnoERROR_IO_PENDING = 997
+)
+
+var (
+	errERROR_IO_PENDING error = syscall.Errno(errnoERROR_IO_PENDING)
+	errERROR_EINVAL     error = syscall.EINVAL
+)
+
+// errnoErr returns common boxed Errno values, to prevent
+// allocations at runtime.
+func errnoErr(e syscall.Errno) error {
+	switch e {
+	case 0:
+		return errERROR_EINVAL
+	case errnoERROR_IO_PENDING:
+		return errERROR_IO_PENDING
+	}
+	// TODO: add more here, after collecting data on the common
+	// error values see on Windows. (perhaps when running
+	// all.bat?)
+	return e
+}
+
+var (
+	modiphlpapi = windows.NewLazySystemDLL("iphlpapi.dll")
+
+	procIcmp6CreateFile = modiphlpapi.NewProc("Icmp6CreateFile")
+	procIcmp6SendEcho2  = modiphlpapi.NewProc("Icmp6SendEcho2")
+	procIcmpCloseHandle = modiphlpapi.NewProc("IcmpCloseHandle")
+	procIcmpCreateFile  = modiphlpapi.NewProc("IcmpCreateFile")
+	procIcmpSendEcho2Ex = modiphlpapi.NewProc("IcmpSendEcho2Ex")
+)
+
+func icmp6CreateFile() (handle windows.Handle, err error) {
+	r0, _, e1 := syscall.SyscallN(procIcmp6CreateFile.Addr())
+	handle = windows.Handle(r0)
+	if handle == windows.InvalidHandle {
+		err = errnoErr(e1)
+	}
+	return
+}
+
+func icmp6SendEcho2(handle windows.Handle, event windows.Handle, apcRoutine uintptr, apcContext uintptr, sourceAddress *windows.RawSockaddrInet6, destinationAddress *windows.RawSockaddrInet6, requestData unsafe.Pointer, requestSize uint16, requestOptions *ipOptionInformation, replyBuffer unsafe.Pointer, replySize uint32, timeout uint32) (replies uint32, err error) {
+	r0, _, e1 := syscall.SyscallN(procIcmp6SendEcho2.Addr(), uintptr(handle), uintptr(event), uintptr(apcRoutine), uintptr(apcContext), uintptr(unsafe.Pointer(sourceAddress)), uintptr(unsafe.Pointer(destinationAddress)), uintptr(requestData), uintptr(requestSize), uintptr(unsafe.Pointer(requestOptions)), uintptr(replyBuffer), uintptr(replySize), uintptr(timeout))
+	replies = uint32(r0)
+	if replies == 0 {
+		err = errnoErr(e1)
+	}
+	return
+}
+
+func icmpCloseHandle(handle windows.Handle) (err error) {
+	r1, _, e1 := syscall.SyscallN(procIcmpCloseHandle.Addr(), uintptr(handle))
+	if r1 == 0 {
+		err = errnoErr(e1)
+	}
+	return
+}
+
+func icmpCreateFile() (handle windows.Handle, err error) {
+	r0, _, e1 := syscall.SyscallN(procIcmpCreateFile.Addr())
+	handle = windows.Handle(r0)
+	if handle == windows.InvalidHandle {
+		err = errnoErr(e1)
+	}
+	return
+}
+
+func icmpSendEcho2Ex(handle windows.Handle, event windows.Handle, apcRoutine uintptr, apcContext uintptr, sourceAddress uint32, destinationAddress uint32, requestData unsafe.Pointer, requestSize uint16, requestOptions *ipOptionInformation, replyBuffer unsafe.Pointer, replySize uint32, timeout uint32) (replies uint32, err error) {
+	r0, _, e1 := syscall.SyscallN(procIcmpSendEcho2Ex.Addr(), uintptr(handle), uintptr(event), uintptr(apcRoutine), uintptr(apcContext), uintptr(sourceAddress), uintptr(destinationAddress), uintptr(requestData), uintptr(requestSize), uintptr(unsafe.Pointer(requestOptions)), uintptr(replyBuffer), uintptr(replySize), uintptr(timeout))
+	replies = uint32(r0)
+	if replies == 0 {
+		err = errnoErr(e1)
+	}
+	return
+}
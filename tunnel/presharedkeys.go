@@ -0,0 +1,277 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2026 WireGuard LLC. All Rights Reserved.
+ */
+
+package tunnel
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+
+	"golang.zx2c4.com/wireguard/windows/conf"
+	"golang.zx2c4.com/wireguard/windows/driver"
+)
+
+const presharedKeyProviderRetryInterval = time.Second * 5
+
+var errPresharedKeyProviderDisabled = errors.New("dangerous script execution is safely disabled")
+
+// presharedKeyProvider opens a stream of lines, each of the public key of a peer and its new preshared key, both in
+// base64, separated by a space.
+type presharedKeyProvider interface {
+	open() (io.ReadCloser, error)
+}
+
+type pipeKeyProvider struct {
+	path string
+}
+
+func (provider *pipeKeyProvider) open() (io.ReadCloser, error) {
+	path16, err := windows.UTF16PtrFromString(provider.path)
+	if err != nil {
+		return nil, err
+	}
+	handle, err := windows.CreateFile(path16, windows.GENERIC_READ, 0, nil, windows.OPEN_EXISTING, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	// Anyone may create a named pipe of any name that is not taken yet, so only trust the pipes of privileged owners.
+	sd, err := windows.GetSecurityInfo(handle, windows.SE_KERNEL_OBJECT, windows.OWNER_SECURITY_INFORMATION)
+	if err != nil {
+		windows.CloseHandle(handle)
+		return nil, err
+	}
+	owner, _, err := sd.Owner()
+	if err != nil {
+		windows.CloseHandle(handle)
+		return nil, err
+	}
+	if !owner.IsWellKnown(windows.WinLocalSystemSid) && !owner.IsWellKnown(windows.WinBuiltinAdministratorsSid) {
+		windows.CloseHandle(handle)
+		return nil, fmt.Errorf("named pipe is owned by %v rather than by SYSTEM or Administrators", owner)
+	}
+	return os.NewFile(uintptr(handle), provider.path), nil
+}
+
+type commandKeyProvider struct {
+	command       string
+	interfaceName string
+}
+
+type commandKeyStream struct {
+	*os.File
+	process *os.Process
+}
+
+func (stream *commandKeyStream) Close() error {
+	stream.process.Kill()
+	stream.process.Wait()
+	return stream.File.Close()
+}
+
+func (provider *commandKeyProvider) open() (io.ReadCloser, error) {
+	if !conf.AdminBool("DangerousScriptExecution") {
+		return nil, errPresharedKeyProviderDisabled
+	}
+	system32, err := windows.GetSystemDirectory()
+	if err != nil {
+		return nil, err
+	}
+	comspec := filepath.Join(system32, "cmd.exe")
+
+	devNull, err := os.OpenFile(os.DevNull, os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer devNull.Close()
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	// Standard error is discarded rather than logged, since the command may well write key material there.
+	process, err := os.StartProcess(comspec, nil /* CmdLine below */, &os.ProcAttr{
+		Files: []*os.File{devNull, writer, devNull},
+		Env:   append(os.Environ(), "WIREGUARD_TUNNEL_NAME="+provider.interfaceName),
+		Sys: &syscall.SysProcAttr{
+			HideWindow: true,
+			CmdLine:    fmt.Sprintf("cmd /c %s", provider.command),
+		},
+	})
+	writer.Close()
+	if err != nil {
+		reader.Close()
+		return nil, err
+	}
+	return &commandKeyStream{reader, process}, nil
+}
+
+// presharedKeyRotator applies the preshared keys supplied by the provider of the configuration, reopening it
+// whenever it fails or ends.
+type presharedKeyRotator struct {
+	watcher  *interfaceWatcher
+	provider presharedKeyProvider
+	source   string
+
+	mu     sync.Mutex
+	stream io.ReadCloser
+	stop   chan struct{}
+}
+
+func startPresharedKeyRotator(watcher *interfaceWatcher, config *conf.Config) *presharedKeyRotator {
+	var provider presharedKeyProvider
+	if len(config.Interface.PresharedKeyProvider.Pipe) > 0 {
+		provider = &pipeKeyProvider{config.Interface.PresharedKeyProvider.Pipe}
+	} else if len(config.Interface.PresharedKeyProvider.Command) > 0 {
+		provider = &commandKeyProvider{config.Interface.PresharedKeyProvider.Command, config.Name}
+	} else {
+		return nil
+	}
+	rotator := &presharedKeyRotator{
+		watcher:  watcher,
+		provider: provider,
+		source:   config.Interface.PresharedKeyProvider.String(),
+		stop:     make(chan struct{}),
+	}
+	go rotator.run()
+	return rotator
+}
+
+// close stops reading from the provider. A read that is blocked on a named pipe may only return once the process
+// exits, which does not matter, since its keys are no longer applied.
+func (rotator *presharedKeyRotator) close() {
+	if rotator == nil {
+		return
+	}
+	rotator.mu.Lock()
+	defer rotator.mu.Unlock()
+	close(rotator.stop)
+	if rotator.stream != nil {
+		rotator.stream.Close()
+		rotator.stream = nil
+	}
+}
+
+func (rotator *presharedKeyRotator) run() {
+	for {
+		stream, err := rotator.provider.open()
+		if err == errPresharedKeyProviderDisabled {
+			log.Printf("Skipping preshared key provider, because %v: %#q", err, rotator.source)
+			return
+		}
+		if err != nil {
+			log.Printf("Unable to open preshared key provider %#q: %v", rotator.source, err)
+		} else {
+			rotator.mu.Lock()
+			select {
+			case <-rotator.stop:
+				rotator.mu.Unlock()
+				stream.Close()
+				return
+			default:
+			}
+			rotator.stream = stream
+			rotator.mu.Unlock()
+
+			log.Printf("Reading preshared keys from %#q", rotator.source)
+			scanner := bufio.NewScanner(stream)
+			for scanner.Scan() {
+				rotator.rotate(scanner.Bytes())
+			}
+			clear(scanner.Bytes())
+
+			rotator.mu.Lock()
+			if rotator.stream == stream {
+				rotator.stream = nil
+				stream.Close()
+			}
+			rotator.mu.Unlock()
+			log.Printf("Preshared key provider %#q ended", rotator.source)
+		}
+		select {
+		case <-rotator.stop:
+			return
+		case <-time.After(presharedKeyProviderRetryInterval):
+		}
+	}
+}
+
+// rotate applies a line from the provider. Neither the line nor the keys in it are ever logged.
+func (rotator *presharedKeyRotator) rotate(line []byte) {
+	defer clear(line)
+	fields := bytes.Fields(line)
+	var publicKey, presharedKey conf.Key
+	defer clear(presharedKey[:])
+	if len(fields) != 2 || !decodeKey(&publicKey, fields[0]) || !decodeKey(&presharedKey, fields[1]) {
+		log.Println("Ignoring malformed line from preshared key provider")
+		return
+	}
+	err := rotator.watcher.setPresharedKey(&publicKey, &presharedKey)
+	if err != nil {
+		log.Printf("Unable to rotate preshared key of peer %s: %v", publicKey.String(), err)
+		return
+	}
+	log.Printf("Rotated preshared key of peer %s", publicKey.String())
+}
+
+func decodeKey(key *conf.Key, b64 []byte) bool {
+	var decoded [conf.KeyLength + 1]byte // The padding of the 44 characters decodes to a 33rd byte.
+	defer clear(decoded[:])
+	if base64.StdEncoding.EncodedLen(conf.KeyLength) != len(b64) {
+		return false
+	}
+	n, err := base64.StdEncoding.Decode(decoded[:], b64)
+	if err != nil || n != conf.KeyLength {
+		return false
+	}
+	copy(key[:], decoded[:n])
+	return true
+}
+
+// setPresharedKey gives the peer a new preshared key, both in the driver, where it takes effect with the next
+// handshake, and in the configuration, so that it is kept when the adapter is configured again.
+func (iw *interfaceWatcher) setPresharedKey(publicKey, presharedKey *conf.Key) error {
+	iw.setupMutex.Lock()
+	defer iw.setupMutex.Unlock()
+	if iw.conf == nil {
+		return errors.New("tunnel is not configured yet")
+	}
+	var peer *conf.Peer
+	for i := range iw.conf.Peers {
+		if iw.conf.Peers[i].PublicKey == *publicKey {
+			peer = &iw.conf.Peers[i]
+			break
+		}
+	}
+	if peer == nil {
+		return errors.New("unknown peer")
+	}
+	var c driver.ConfigBuilder
+	c.AppendInterface(&driver.Interface{PeerCount: 1})
+	c.AppendPeer(&driver.Peer{
+		Flags:        driver.PeerHasPublicKey | driver.PeerHasPresharedKey | driver.PeerUpdateOnly,
+		PublicKey:    *publicKey,
+		PresharedKey: *presharedKey,
+	})
+	driverInterface, size := c.Interface()
+	defer clear(unsafe.Slice((*byte)(unsafe.Pointer(driverInterface)), size))
+	err := iw.adapter.SetConfiguration(driverInterface, size)
+	if err != nil {
+		return err
+	}
+	peer.PresharedKey = *presharedKey
+	return nil
+}
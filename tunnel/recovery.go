@@ -0,0 +1,251 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2026 WireGuard LLC. All Rights Reserved.
+ */
+
+package tunnel
+
+import (
+	"log"
+	"net/netip"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sys/windows"
+	"golang.zx2c4.com/wireguard/windows/conf"
+	"golang.zx2c4.com/wireguard/windows/driver"
+	"golang.zx2c4.com/wireguard/windows/tunnel/firewall"
+	"golang.zx2c4.com/wireguard/windows/tunnel/winipcfg"
+)
+
+const (
+	_PBT_APMRESUMEAUTOMATIC = 0x12
+
+	// Events tend to come in bursts, so recovery waits for them to settle.
+	recoveryDelay = time.Second * 2
+)
+
+// networkRecovery brings the tunnel back after the system resumes from sleep or the default route changes, such as
+// when switching from Wi-Fi to Ethernet, by resolving the endpoints anew, setting up the interface again if its
+// addresses have vanished, and evaluating the pitfalls again.
+type networkRecovery struct {
+	watcher             *interfaceWatcher
+	tunnelName          string
+	endpoints           []conf.Endpoint // As configured, before being resolved
+	routeChangeCallback winipcfg.ChangeCallback
+	timer               *time.Timer
+
+	mu      sync.Mutex
+	start   time.Time
+	reasons []string
+	closed  bool
+}
+
+func startNetworkRecovery(watcher *interfaceWatcher, tunnelName string, endpoints []conf.Endpoint, luid winipcfg.LUID) (*networkRecovery, error) {
+	recovery := &networkRecovery{watcher: watcher, tunnelName: tunnelName, endpoints: endpoints}
+	recovery.timer = time.AfterFunc(time.Duration(1<<63-1), recovery.run)
+	recovery.timer.Stop()
+	var err error
+	recovery.routeChangeCallback, err = winipcfg.RegisterRouteChangeCallback(func(notificationType winipcfg.MibNotificationType, route *winipcfg.MibIPforwardRow2) {
+		if route != nil && route.InterfaceLUID != luid && route.DestinationPrefix.PrefixLength == 0 {
+			recovery.trigger("default route change")
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return recovery, nil
+}
+
+func (recovery *networkRecovery) close() {
+	if recovery == nil {
+		return
+	}
+	recovery.routeChangeCallback.Unregister()
+	// Wait for a recovery in progress, so that it does not set up the interface while it is being destroyed.
+	recovery.mu.Lock()
+	recovery.closed = true
+	recovery.timer.Stop()
+	recovery.mu.Unlock()
+}
+
+func (recovery *networkRecovery) trigger(reason string) {
+	recovery.mu.Lock()
+	defer recovery.mu.Unlock()
+	if recovery.closed {
+		return
+	}
+	if len(recovery.reasons) == 0 {
+		recovery.start = time.Now()
+		log.Printf("Recovery: %s, waiting for the network to settle", reason)
+	}
+	for _, existing := range recovery.reasons {
+		if existing == reason {
+			reason = ""
+			break
+		}
+	}
+	if len(reason) > 0 {
+		recovery.reasons = append(recovery.reasons, reason)
+	}
+	recovery.timer.Reset(recoveryDelay)
+}
+
+func (recovery *networkRecovery) run() {
+	recovery.mu.Lock()
+	start, reasons := recovery.start, recovery.reasons
+	recovery.reasons = nil
+	recovery.mu.Unlock()
+	if len(reasons) == 0 {
+		return
+	}
+	log.Printf("Recovery: starting after %s (T+%v)", strings.Join(reasons, ", "), time.Since(start).Round(time.Millisecond))
+
+	resolved := &conf.Config{Peers: make([]conf.Peer, len(recovery.endpoints))}
+	for i := range recovery.endpoints {
+		resolved.Peers[i].Endpoint = recovery.endpoints[i]
+	}
+	unpermit := permitLockdownResolution(recovery.tunnelName, recovery.endpoints)
+	err := resolved.ResolveEndpointsPatiently()
+	unpermit()
+	if err != nil {
+		log.Printf("Recovery: unable to resolve endpoints (T+%v): %v", time.Since(start).Round(time.Millisecond), err)
+		resolved = nil
+	} else {
+		log.Printf("Recovery: resolved endpoints (T+%v)", time.Since(start).Round(time.Millisecond))
+	}
+	recovery.mu.Lock()
+	defer recovery.mu.Unlock()
+	if recovery.closed {
+		return
+	}
+	recovery.watcher.recover(resolved, start)
+	log.Printf("Recovery: complete (T+%v)", time.Since(start).Round(time.Millisecond))
+}
+
+// recover updates the endpoints of the peers that have changed, if resolved is not nil, and sets up the address
+// families again whose addresses have vanished, or all of them if endpoints changed, since the MTU depends on them.
+func (iw *interfaceWatcher) recover(resolved *conf.Config, start time.Time) {
+	iw.setupMutex.Lock()
+	defer iw.setupMutex.Unlock()
+	if iw.luid == 0 {
+		return
+	}
+
+	endpointsChanged := false
+	if resolved != nil {
+		var c driver.ConfigBuilder
+		peerCount := uint32(0)
+		for i := range resolved.Peers {
+			endpoint := &resolved.Peers[i].Endpoint
+			if endpoint.IsEmpty() || *endpoint == iw.conf.Peers[i].Endpoint {
+				continue
+			}
+			addr, err := netip.ParseAddr(endpoint.Host)
+			if err != nil {
+				continue
+			}
+			log.Printf("Recovery: endpoint of peer %s changed from %s to %s", iw.conf.Peers[i].PublicKey.String(), iw.conf.Peers[i].Endpoint.String(), endpoint.String())
+			iw.conf.Peers[i].Endpoint = *endpoint
+			if peerCount == 0 {
+				c.AppendInterface(&driver.Interface{})
+			}
+			driverPeer := &driver.Peer{
+				Flags:     driver.PeerHasPublicKey | driver.PeerHasEndpoint | driver.PeerUpdateOnly,
+				PublicKey: iw.conf.Peers[i].PublicKey,
+			}
+			driverPeer.Endpoint.SetAddrPort(netip.AddrPortFrom(addr, endpoint.Port))
+			c.AppendPeer(driverPeer)
+			peerCount++
+		}
+		if peerCount > 0 {
+			driverInterface, size := c.Interface()
+			driverInterface.PeerCount = peerCount
+			err := iw.adapter.SetConfiguration(driverInterface, size)
+			if err != nil {
+				log.Printf("Recovery: unable to update endpoints: %v", err)
+			}
+			endpointsChanged = true
+			iw.mtuEndpoints.update(iw.conf)
+			if iw.conf.Interface.MTUAutoProbe {
+				iw.mtuProber = newPathMTUProber(iw.conf, iw.luid)
+			}
+		}
+	}
+
+	addresses, err := winipcfg.GetUnicastIPAddressTable(windows.AF_UNSPEC)
+	if err != nil {
+		log.Printf("Recovery: unable to list addresses: %v", err)
+		return
+	}
+	for _, family := range []winipcfg.AddressFamily{windows.AF_INET, windows.AF_INET6} {
+		if _, err := iw.luid.IPInterface(family); err != nil {
+			// The interface of this family has not appeared yet, and is set up once it does.
+			continue
+		}
+		present := make(map[netip.Addr]bool)
+		for i := range addresses {
+			if addresses[i].InterfaceLUID == iw.luid && addresses[i].Address.Family == family {
+				present[addresses[i].Address.Addr()] = true
+			}
+		}
+		expected, missing := 0, 0
+		for _, address := range iw.conf.Interface.Addresses {
+			if address.Addr().Is4() != (family == windows.AF_INET) {
+				continue
+			}
+			expected++
+			if !present[address.Addr()] {
+				missing++
+			}
+		}
+		if missing > 0 {
+			log.Printf("Recovery: %d of %d %s addresses missing, setting up again (T+%v)", missing, expected, familyName(family), time.Since(start).Round(time.Millisecond))
+			iw.setup(family)
+		} else if endpointsChanged {
+			log.Printf("Recovery: setting up %s again for new endpoints (T+%v)", familyName(family), time.Since(start).Round(time.Millisecond))
+			iw.setup(family)
+		} else {
+			evaluateDynamicPitfalls(family, iw.conf, iw.luid)
+		}
+	}
+}
+
+// permitLockdownResolution permits DNS resolution despite lockdown mode while the endpoints are resolved, if they
+// have hostnames and the tunnel is approved, since the resolution goes through the DNS Client service, whose traffic
+// lockdown mode blocks. The returned function revokes it again.
+func permitLockdownResolution(tunnelName string, endpoints []conf.Endpoint) func() {
+	hostnames := false
+	for i := range endpoints {
+		if _, err := netip.ParseAddr(endpoints[i].Host); err != nil && !endpoints[i].IsEmpty() {
+			hostnames = true
+		}
+	}
+	if !hostnames {
+		return func() {}
+	}
+	enabled, serviceNames, err := firewall.LockdownStatus()
+	if err != nil || !enabled {
+		return func() {}
+	}
+	serviceName, err := conf.ServiceNameOfTunnel(tunnelName)
+	if err != nil || !slices.Contains(serviceNames, serviceName) {
+		return func() {}
+	}
+	unpermit, err := firewall.PermitLockdownResolution()
+	if err != nil {
+		log.Printf("Warning: unable to permit DNS resolution in lockdown mode: %v", err)
+		return func() {}
+	}
+	log.Println("Permitting DNS resolution in lockdown mode while resolving endpoints")
+	return unpermit
+}
+
+func familyName(family winipcfg.AddressFamily) string {
+	if family == windows.AF_INET6 {
+		return "IPv6"
+	}
+	return "IPv4"
+}
@@ -0,0 +1,91 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2026 WireGuard LLC. All Rights Reserved.
+ */
+
+package tunnel
+
+import (
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"golang.zx2c4.com/wireguard/windows/conf"
+	"golang.zx2c4.com/wireguard/windows/driver"
+)
+
+// runtimeConfigSaver saves the configuration of the running interface, such as peers added with wg(8), to the stored
+// configuration of tunnels with SaveConfig = true, when the tunnel stops or when asked to with
+// services.ServiceControlSaveConfig.
+type runtimeConfigSaver struct {
+	path    string
+	modTime time.Time // Of the stored configuration when it was loaded or last saved, so as not to overwrite edits
+	adapter *driver.Adapter
+}
+
+func newRuntimeConfigSaver(config *conf.Config, path string) *runtimeConfigSaver {
+	if !config.Interface.SaveConfig {
+		return nil
+	}
+	storePath, err := config.Path()
+	if err != nil || !strings.EqualFold(storePath, path) {
+		log.Println("Not saving runtime configuration, because the tunnel was not started from the configuration store")
+		return nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		log.Printf("Not saving runtime configuration, because the stored configuration cannot be found: %v", err)
+		return nil
+	}
+	return &runtimeConfigSaver{path: path, modTime: info.ModTime()}
+}
+
+// configured is called once the adapter has been given the configuration, since there is nothing to save before.
+func (saver *runtimeConfigSaver) configured(adapter *driver.Adapter) {
+	if saver == nil {
+		return
+	}
+	saver.adapter = adapter
+}
+
+func (saver *runtimeConfigSaver) save() {
+	if saver == nil || saver.adapter == nil {
+		return
+	}
+	info, err := os.Stat(saver.path)
+	if err != nil {
+		log.Printf("Not saving runtime configuration, because the stored configuration cannot be found: %v", err)
+		return
+	}
+	if !info.ModTime().Equal(saver.modTime) {
+		log.Println("Not saving runtime configuration, because the stored configuration was changed since it was loaded")
+		return
+	}
+	storedConfig, err := conf.LoadFromPath(saver.path)
+	if err != nil {
+		log.Printf("Unable to load stored configuration: %v", err)
+		return
+	}
+	interfaze, err := saver.adapter.Configuration()
+	if err != nil {
+		log.Printf("Unable to get runtime configuration: %v", err)
+		return
+	}
+	config := conf.MergeDriverConfiguration(interfaze, storedConfig)
+	// Changes made with wg(8) are not validated like the configuration is, and must not keep the tunnel from starting.
+	_, err = conf.FromWgQuickTolerant(config.ToWgQuick(), config.Name)
+	if err != nil {
+		log.Printf("Not saving runtime configuration, because it is not valid: %v", err)
+		return
+	}
+	err = config.Save(true)
+	if err != nil {
+		log.Printf("Unable to save runtime configuration: %v", err)
+		return
+	}
+	if info, err := os.Stat(saver.path); err == nil {
+		saver.modTime = info.ModTime()
+	}
+	log.Printf("Saved runtime configuration with %d peers", len(config.Peers))
+}
@@ -21,6 +21,7 @@ import (
 	"golang.zx2c4.com/wireguard/windows/elevate"
 	"golang.zx2c4.com/wireguard/windows/ringlogger"
 	"golang.zx2c4.com/wireguard/windows/services"
+	"golang.zx2c4.com/wireguard/windows/tunnel/firewall"
 	"golang.zx2c4.com/wireguard/windows/tunnel/winipcfg"
 )
 
@@ -33,6 +34,14 @@ func (service *tunnelService) Execute(args []string, r <-chan svc.ChangeRequest,
 	changes <- svc.Status{State: serviceState}
 
 	var watcher *interfaceWatcher
+	var portal *captivePortal
+	var categorizer *networkCategorizer
+	var keepalive *keepaliveTuner
+	var recovery *networkRecovery
+	var failover *failoverMonitor
+	var presharedKeys *presharedKeyRotator
+	var saver *runtimeConfigSaver
+	var splitDNS bool
 	var adapter *driver.Adapter
 	var luid winipcfg.LUID
 	var config *conf.Config
@@ -83,9 +92,21 @@ func (service *tunnelService) Execute(args []string, r <-chan svc.ChangeRequest,
 		if logErr == nil && adapter != nil && config != nil {
 			logErr = runScriptCommand(config.Interface.PreDown, config.Name)
 		}
+		if portal != nil {
+			portal.disable()
+		}
+		categorizer.close()
+		keepalive.close()
+		recovery.close()
+		failover.close()
+		presharedKeys.close()
+		saver.save()
 		if watcher != nil {
 			watcher.Destroy()
 		}
+		if splitDNS {
+			disableSplitDNS(config)
+		}
 		if adapter != nil {
 			adapter.Close()
 		}
@@ -114,6 +135,7 @@ func (service *tunnelService) Execute(args []string, r <-chan svc.ChangeRequest,
 		return
 	}
 	config.DeduplicateNetworkEntries()
+	saver = newRuntimeConfigSaver(config, service.Path)
 
 	log.SetPrefix(fmt.Sprintf("[%s] ", config.Name))
 
@@ -135,7 +157,7 @@ func (service *tunnelService) Execute(args []string, r <-chan svc.ChangeRequest,
 		}
 	}
 
-	evaluateStaticPitfalls()
+	evaluateStaticPitfalls(config)
 
 	log.Println("Watching network interfaces")
 	watcher, err = watchInterface()
@@ -144,20 +166,27 @@ func (service *tunnelService) Execute(args []string, r <-chan svc.ChangeRequest,
 		return
 	}
 
+	endpoints := make([]conf.Endpoint, len(config.Peers))
+	for i := range config.Peers {
+		endpoints[i] = config.Peers[i].Endpoint
+	}
 	log.Println("Resolving DNS names")
+	unpermit := permitLockdownResolution(config.Name, endpoints)
 	err = config.ResolveEndpoints()
+	unpermit()
 	if err != nil {
 		serviceError = services.ErrorDNSLookup
 		return
 	}
 
 	log.Println("Creating network adapter")
+	guid := adapterGUID(config)
 	for i := range 15 {
 		if i > 0 {
 			time.Sleep(time.Second)
 			log.Printf("Retrying adapter creation after failure because system just booted (T+%v): %v", windows.DurationSinceBoot(), err)
 		}
-		adapter, err = driver.CreateAdapter(config.Name, "WireGuard", deterministicGUID(config))
+		adapter, err = driver.CreateAdapter(config.Name, "WireGuard", guid)
 		if err == nil || !services.StartedAtBoot() {
 			break
 		}
@@ -192,6 +221,25 @@ func (service *tunnelService) Execute(args []string, r <-chan svc.ChangeRequest,
 		serviceError = services.ErrorFirewall
 		return
 	}
+	if sublayers, err := firewall.OutrankingSublayers(); err == nil {
+		for i := range sublayers {
+			log.Printf("Warning: firewall sublayer %s is evaluated before or alongside WireGuard's, and may override it", &sublayers[i])
+		}
+	}
+	err = enableSplitDNS(config)
+	if err != nil {
+		err = fmt.Errorf("Unable to configure split DNS: %w", err)
+		serviceError = services.ErrorSetNetConfig
+		return
+	}
+	splitDNS = true
+	if conf.AdminBool("LogFirewallDrops") {
+		log.Println("Enabling logging of packets dropped by firewall")
+		err = firewall.EnableDropLogging()
+		if err != nil {
+			log.Printf("Unable to log packets dropped by firewall: %v", err)
+		}
+	}
 
 	log.Println("Dropping privileges")
 	err = elevate.DropAllPrivileges(true)
@@ -212,6 +260,15 @@ func (service *tunnelService) Execute(args []string, r <-chan svc.ChangeRequest,
 		return
 	}
 	watcher.Configure(adapter, config, luid)
+	saver.configured(adapter)
+	keepalive = startKeepaliveTuner(adapter, config, luid)
+	failover = startFailoverMonitor(adapter, config)
+	presharedKeys = startPresharedKeyRotator(watcher, config)
+	recovery, err = startNetworkRecovery(watcher, config.Name, endpoints, luid)
+	if err != nil {
+		serviceError = services.ErrorSetNetConfig
+		return
+	}
 
 	err = runScriptCommand(config.Interface.PostUp, config.Name)
 	if err != nil {
@@ -219,7 +276,9 @@ func (service *tunnelService) Execute(args []string, r <-chan svc.ChangeRequest,
 		return
 	}
 
-	changes <- svc.Status{State: serviceState, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+	portal = newCaptivePortal(adapter, luid)
+
+	changes <- svc.Status{State: serviceState, Accepts: svc.AcceptStop | svc.AcceptShutdown | svc.AcceptPowerEvent}
 
 	var started bool
 	for {
@@ -230,16 +289,36 @@ func (service *tunnelService) Execute(args []string, r <-chan svc.ChangeRequest,
 				return
 			case svc.Interrogate:
 				changes <- c.CurrentStatus
+			case svc.PowerEvent:
+				if c.EventType == _PBT_APMRESUMEAUTOMATIC {
+					recovery.trigger("resume from sleep")
+				}
+			case services.ServiceControlCaptivePortal:
+				log.Printf("Enabling captive portal mode for up to %v", captivePortalDuration())
+				err := portal.enable()
+				if err != nil {
+					log.Printf("Unable to enable captive portal mode: %v", err)
+				}
+			case services.ServiceControlSaveConfig:
+				if saver == nil {
+					log.Println("Not saving runtime configuration, because SaveConfig is not enabled")
+				} else {
+					saver.save()
+				}
 			default:
 				log.Printf("Unexpected service control request #%d\n", c)
 			}
 		case <-watcher.started:
 			if !started {
 				serviceState = svc.Running
-				changes <- svc.Status{State: serviceState, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+				changes <- svc.Status{State: serviceState, Accepts: svc.AcceptStop | svc.AcceptShutdown | svc.AcceptPowerEvent}
 				log.Println("Startup complete")
 				started = true
+				categorizer = startNetworkCategorizer(&config.Interface, luid)
 			}
+		case reason := <-portal.finished:
+			log.Printf("Disabling captive portal mode: %s", reason)
+			portal.disable()
 		case e := <-watcher.errors:
 			serviceError, err = e.serviceError, e.err
 			return
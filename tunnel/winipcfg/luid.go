@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"net/netip"
 	"strings"
+	"unsafe"
 
 	"golang.org/x/sys/windows"
 	"golang.org/x/sys/windows/registry"
@@ -407,6 +408,85 @@ func (luid LUID) SetDNS(family AddressFamily, servers []netip.Addr, domains []st
 	return nil
 }
 
+// ErrEncryptedDNSUnsupported is returned by SetEncryptedDNS when Windows does not support per-interface encrypted DNS,
+// which was introduced in Windows 11.
+var ErrEncryptedDNSUnsupported = errors.New("encrypted DNS is not supported by this version of Windows")
+
+// EncryptedDNSSupported reports whether this version of Windows supports per-interface encrypted DNS.
+func EncryptedDNSSupported() bool {
+	return windows.RtlGetVersion().BuildNumber >= 22000
+}
+
+// SetEncryptedDNS method is like SetDNS, but additionally uses DNS over HTTPS, with the given URI templates, for
+// those servers that have one. It requires Windows 11, and otherwise returns ErrEncryptedDNSUnsupported.
+func (luid LUID) SetEncryptedDNS(family AddressFamily, servers []netip.Addr, domains []string, dohTemplates map[netip.Addr]string) error {
+	if family != windows.AF_INET && family != windows.AF_INET6 {
+		return windows.ERROR_PROTOCOL_UNREACHABLE
+	}
+	if !EncryptedDNSSupported() {
+		return ErrEncryptedDNSUnsupported
+	}
+
+	var filteredServers []string
+	var dohSettings []DnsDohServerSettings
+	var dohServerIndices []uint32
+	for _, server := range servers {
+		if (server.Is4() && family == windows.AF_INET) || (server.Is6() && family == windows.AF_INET6) {
+			if template, ok := dohTemplates[server]; ok {
+				template16, err := windows.UTF16PtrFromString(template)
+				if err != nil {
+					return err
+				}
+				dohSettings = append(dohSettings, DnsDohServerSettings{Template: template16, Flags: DnsDohServerSettingsFlagEnable})
+				dohServerIndices = append(dohServerIndices, uint32(len(filteredServers)))
+			}
+			filteredServers = append(filteredServers, server.String())
+		}
+	}
+	servers16, err := windows.UTF16PtrFromString(strings.Join(filteredServers, ","))
+	if err != nil {
+		return err
+	}
+	domains16, err := windows.UTF16PtrFromString(strings.Join(domains, ","))
+	if err != nil {
+		return err
+	}
+	guid, err := luid.GUID()
+	if err != nil {
+		return err
+	}
+	properties := make([]DnsServerProperty, len(dohSettings))
+	for i := range dohSettings {
+		properties[i] = DnsServerProperty{
+			Version:     DnsServerPropertyVersion1,
+			ServerIndex: dohServerIndices[i],
+			Type:        DnsServerDohProperty,
+			Property:    unsafe.Pointer(&dohSettings[i]),
+		}
+	}
+	dnsInterfaceSettings := &DnsInterfaceSettings3{
+		DnsInterfaceSettings: DnsInterfaceSettings{
+			Version:    DnsInterfaceSettingsVersion3,
+			Flags:      DnsInterfaceSettingsFlagNameserver | DnsInterfaceSettingsFlagSearchList | DnsInterfaceSettingsFlagDOH,
+			NameServer: servers16,
+			SearchList: domains16,
+		},
+		ServerPropertiesCount: uint32(len(properties)),
+	}
+	if len(properties) > 0 {
+		dnsInterfaceSettings.ServerProperties = &properties[0]
+	}
+	if family == windows.AF_INET6 {
+		dnsInterfaceSettings.Flags |= DnsInterfaceSettingsFlagIPv6
+	}
+	// The version field tells SetInterfaceDnsSettings that the rest of the larger structure follows.
+	err = SetInterfaceDnsSettings(*guid, &dnsInterfaceSettings.DnsInterfaceSettings)
+	if errors.Is(err, windows.ERROR_PROC_NOT_FOUND) || errors.Is(err, windows.ERROR_INVALID_PARAMETER) {
+		return ErrEncryptedDNSUnsupported
+	}
+	return err
+}
+
 // FlushDNS method clears all DNS servers associated with the adapter.
 func (luid LUID) FlushDNS(family AddressFamily) error {
 	return luid.SetDNS(family, nil, nil)
@@ -996,6 +996,42 @@ type DnsInterfaceSettings struct {
 	ProfileNameServer   *uint16
 }
 
+// DnsInterfaceSettings3 is meant to be used with SetInterfaceDnsSettings, and is supported as of Windows 11.
+type DnsInterfaceSettings3 struct {
+	DnsInterfaceSettings
+	DisableUnconstrainedQueries  uint32
+	SupplementalSearchList       *uint16
+	ServerPropertiesCount        uint32
+	ServerProperties             *DnsServerProperty
+	ProfileServerPropertiesCount uint32
+	ProfileServerProperties      *DnsServerProperty
+}
+
+// DnsServerProperty attaches a property, such as DNS over HTTPS settings, to the name server of the given index.
+type DnsServerProperty struct {
+	Version     uint32
+	ServerIndex uint32
+	Type        DnsServerPropertyType
+	Property    unsafe.Pointer
+}
+
+// DnsServerPropertyType enumeration type defines the type of DnsServerProperty.Property.
+type DnsServerPropertyType uint32
+
+const (
+	DnsServerInvalidProperty DnsServerPropertyType = iota
+	DnsServerDohProperty                           // *DnsDohServerSettings
+)
+
+const (
+	DnsServerPropertyVersion1 = 1
+
+	DnsDohServerSettingsFlagEnableAuto    = 0x0001
+	DnsDohServerSettingsFlagEnable        = 0x0002
+	DnsDohServerSettingsFlagFallbackToUDP = 0x0004
+	DnsDohServerSettingsFlagEnableDDR     = 0x0008
+)
+
 const (
 	DnsInterfaceSettingsVersion1 = 1 // for DnsInterfaceSettings
 	DnsInterfaceSettingsVersion2 = 2 // for DnsInterfaceSettingsEx
@@ -230,3 +230,10 @@ type mibIPforwardTable2 struct {
 	_          [4]byte
 	table      [anySize]MibIPforwardRow2
 }
+
+// DnsDohServerSettings structure contains the DNS over HTTPS settings of a name server.
+type DnsDohServerSettings struct {
+	Template *uint16
+	_        [4]byte
+	Flags    uint64
+}
@@ -218,3 +218,9 @@ type mibIPforwardTable2 struct {
 	numEntries uint32
 	table      [anySize]MibIPforwardRow2
 }
+
+// DnsDohServerSettings structure contains the DNS over HTTPS settings of a name server.
+type DnsDohServerSettings struct {
+	Template *uint16
+	Flags    uint64
+}
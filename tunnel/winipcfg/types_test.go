@@ -1054,3 +1054,68 @@ func TestMibIPforwardTable2(t *testing.T) {
 		t.Errorf("mibIPforwardTable2.table offset is %d although %d is expected", offset, mibIPforwardTable2TableOffset)
 	}
 }
+
+func TestDnsInterfaceSettings3(t *testing.T) {
+	s := DnsInterfaceSettings3{}
+	sp := uintptr(unsafe.Pointer(&s))
+	const actualDnsInterfaceSettings3Size = unsafe.Sizeof(s)
+
+	if actualDnsInterfaceSettings3Size != dnsInterfaceSettings3Size {
+		t.Errorf("Size of DnsInterfaceSettings3 is %d, although %d is expected.", actualDnsInterfaceSettings3Size, dnsInterfaceSettings3Size)
+	}
+
+	offset := uintptr(unsafe.Pointer(&s.DisableUnconstrainedQueries)) - sp
+	if offset != dnsInterfaceSettings3DisableUnconstrainedQueriesOffset {
+		t.Errorf("DnsInterfaceSettings3.DisableUnconstrainedQueries offset is %d although %d is expected", offset, dnsInterfaceSettings3DisableUnconstrainedQueriesOffset)
+	}
+
+	offset = uintptr(unsafe.Pointer(&s.ServerPropertiesCount)) - sp
+	if offset != dnsInterfaceSettings3ServerPropertiesCountOffset {
+		t.Errorf("DnsInterfaceSettings3.ServerPropertiesCount offset is %d although %d is expected", offset, dnsInterfaceSettings3ServerPropertiesCountOffset)
+	}
+
+	offset = uintptr(unsafe.Pointer(&s.ServerProperties)) - sp
+	if offset != dnsInterfaceSettings3ServerPropertiesOffset {
+		t.Errorf("DnsInterfaceSettings3.ServerProperties offset is %d although %d is expected", offset, dnsInterfaceSettings3ServerPropertiesOffset)
+	}
+
+	offset = uintptr(unsafe.Pointer(&s.ProfileServerPropertiesCount)) - sp
+	if offset != dnsInterfaceSettings3ProfileServerPropertiesCountOffset {
+		t.Errorf("DnsInterfaceSettings3.ProfileServerPropertiesCount offset is %d although %d is expected", offset, dnsInterfaceSettings3ProfileServerPropertiesCountOffset)
+	}
+
+	offset = uintptr(unsafe.Pointer(&s.ProfileServerProperties)) - sp
+	if offset != dnsInterfaceSettings3ProfileServerPropertiesOffset {
+		t.Errorf("DnsInterfaceSettings3.ProfileServerProperties offset is %d although %d is expected", offset, dnsInterfaceSettings3ProfileServerPropertiesOffset)
+	}
+}
+
+func TestDnsServerProperty(t *testing.T) {
+	s := DnsServerProperty{}
+	sp := uintptr(unsafe.Pointer(&s))
+	const actualDnsServerPropertySize = unsafe.Sizeof(s)
+
+	if actualDnsServerPropertySize != dnsServerPropertySize {
+		t.Errorf("Size of DnsServerProperty is %d, although %d is expected.", actualDnsServerPropertySize, dnsServerPropertySize)
+	}
+
+	offset := uintptr(unsafe.Pointer(&s.Property)) - sp
+	if offset != dnsServerPropertyPropertyOffset {
+		t.Errorf("DnsServerProperty.Property offset is %d although %d is expected", offset, dnsServerPropertyPropertyOffset)
+	}
+}
+
+func TestDnsDohServerSettings(t *testing.T) {
+	s := DnsDohServerSettings{}
+	sp := uintptr(unsafe.Pointer(&s))
+	const actualDnsDohServerSettingsSize = unsafe.Sizeof(s)
+
+	if actualDnsDohServerSettingsSize != dnsDohServerSettingsSize {
+		t.Errorf("Size of DnsDohServerSettings is %d, although %d is expected.", actualDnsDohServerSettingsSize, dnsDohServerSettingsSize)
+	}
+
+	offset := uintptr(unsafe.Pointer(&s.Flags)) - sp
+	if offset != dnsDohServerSettingsFlagsOffset {
+		t.Errorf("DnsDohServerSettings.Flags offset is %d although %d is expected", offset, dnsDohServerSettingsFlagsOffset)
+	}
+}
@@ -56,4 +56,17 @@ const (
 	ipAdapterAddressesDHCPv6ClientDUIDLengthOffset = 360
 	ipAdapterAddressesDHCPv6IAIDOffset             = 364
 	ipAdapterAddressesFirstDNSSuffixOffset         = 368
+
+	dnsInterfaceSettings3Size                               = 72
+	dnsInterfaceSettings3DisableUnconstrainedQueriesOffset  = 48
+	dnsInterfaceSettings3ServerPropertiesCountOffset        = 56
+	dnsInterfaceSettings3ServerPropertiesOffset             = 60
+	dnsInterfaceSettings3ProfileServerPropertiesCountOffset = 64
+	dnsInterfaceSettings3ProfileServerPropertiesOffset      = 68
+
+	dnsServerPropertySize           = 16
+	dnsServerPropertyPropertyOffset = 12
+
+	dnsDohServerSettingsSize        = 16
+	dnsDohServerSettingsFlagsOffset = 8
 )
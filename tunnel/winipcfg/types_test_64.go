@@ -56,4 +56,17 @@ const (
 	ipAdapterAddressesDHCPv6ClientDUIDLengthOffset = 428
 	ipAdapterAddressesDHCPv6IAIDOffset             = 432
 	ipAdapterAddressesFirstDNSSuffixOffset         = 440
+
+	dnsInterfaceSettings3Size                               = 112
+	dnsInterfaceSettings3DisableUnconstrainedQueriesOffset  = 64
+	dnsInterfaceSettings3ServerPropertiesCountOffset        = 80
+	dnsInterfaceSettings3ServerPropertiesOffset             = 88
+	dnsInterfaceSettings3ProfileServerPropertiesCountOffset = 96
+	dnsInterfaceSettings3ProfileServerPropertiesOffset      = 104
+
+	dnsServerPropertySize           = 24
+	dnsServerPropertyPropertyOffset = 16
+
+	dnsDohServerSettingsSize        = 16
+	dnsDohServerSettingsFlagsOffset = 8
 )
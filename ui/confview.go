@@ -44,16 +44,28 @@ type toggleActiveLine struct {
 }
 
 type interfaceView struct {
-	status       *labelStatusLine
-	publicKey    *labelTextLine
-	listenPort   *labelTextLine
-	mtu          *labelTextLine
-	addresses    *labelTextLine
-	dns          *labelTextLine
-	scripts      *labelTextLine
-	table        *labelTextLine
-	toggleActive *toggleActiveLine
-	lines        []widgetsLine
+	status          *labelStatusLine
+	publicKey       *labelTextLine
+	listenPort      *labelTextLine
+	mtu             *labelTextLine
+	metric          *labelTextLine
+	adapterGUID     *labelTextLine
+	networkCategory *labelTextLine
+	addresses       *labelTextLine
+	dns             *labelTextLine
+	splitDNS        *labelTextLine
+	encryptedDNS    *labelTextLine
+	hosts           *labelTextLine
+	proxy           *labelTextLine
+	scripts         *labelTextLine
+	table           *labelTextLine
+	routes          *labelTextLine
+	inbound         *labelTextLine
+	vms             *labelTextLine
+	pskProvider     *labelTextLine
+	saveConfig      *labelTextLine
+	toggleActive    *toggleActiveLine
+	lines           []widgetsLine
 }
 
 type peerView struct {
@@ -62,6 +74,7 @@ type peerView struct {
 	allowedIPs          *labelTextLine
 	endpoint            *labelTextLine
 	persistentKeepalive *labelTextLine
+	failoverGroup       *labelTextLine
 	latestHandshake     *labelTextLine
 	transfer            *labelTextLine
 	lines               []widgetsLine
@@ -312,10 +325,22 @@ func newInterfaceView(parent walk.Container) (*interfaceView, error) {
 		{l18n.Sprintf("Public key:"), &iv.publicKey},
 		{l18n.Sprintf("Listen port:"), &iv.listenPort},
 		{l18n.Sprintf("MTU:"), &iv.mtu},
+		{l18n.Sprintf("Metric:"), &iv.metric},
+		{l18n.Sprintf("Adapter GUID:"), &iv.adapterGUID},
+		{l18n.Sprintf("Network category:"), &iv.networkCategory},
 		{l18n.Sprintf("Addresses:"), &iv.addresses},
 		{l18n.Sprintf("DNS servers:"), &iv.dns},
+		{l18n.Sprintf("Split DNS:"), &iv.splitDNS},
+		{l18n.Sprintf("Encrypted DNS:"), &iv.encryptedDNS},
+		{l18n.Sprintf("Hosts:"), &iv.hosts},
+		{l18n.Sprintf("Proxy:"), &iv.proxy},
 		{l18n.Sprintf("Scripts:"), &iv.scripts},
 		{l18n.Sprintf("Table:"), &iv.table},
+		{l18n.Sprintf("Routes:"), &iv.routes},
+		{l18n.Sprintf("Inbound:"), &iv.inbound},
+		{l18n.Sprintf("Virtual machines:"), &iv.vms},
+		{l18n.Sprintf("Preshared key provider:"), &iv.pskProvider},
+		{l18n.Sprintf("Save config:"), &iv.saveConfig},
 	}
 	if iv.lines, err = createLabelTextLines(items, parent, &disposables); err != nil {
 		return nil, err
@@ -344,6 +369,7 @@ func newPeerView(parent walk.Container) (*peerView, error) {
 		{l18n.Sprintf("Allowed IPs:"), &pv.allowedIPs},
 		{l18n.Sprintf("Endpoint:"), &pv.endpoint},
 		{l18n.Sprintf("Persistent keepalive:"), &pv.persistentKeepalive},
+		{l18n.Sprintf("Failover group:"), &pv.failoverGroup},
 		{l18n.Sprintf("Latest handshake:"), &pv.latestHandshake},
 		{l18n.Sprintf("Transfer:"), &pv.transfer},
 	}
@@ -387,12 +413,46 @@ func (iv *interfaceView) apply(c *conf.Interface) {
 		iv.listenPort.hide()
 	}
 
-	if c.MTU > 0 {
+	if c.MTUAutoProbe && c.MTU > 0 {
+		iv.mtu.show(l18n.Sprintf("%d (auto-probe)", c.MTU))
+	} else if c.MTUAutoProbe {
+		iv.mtu.show(l18n.Sprintf("auto-probe"))
+	} else if c.MTU > 0 {
 		iv.mtu.show(strconv.Itoa(int(c.MTU)))
 	} else {
 		iv.mtu.hide()
 	}
 
+	if c.Metric > 0 || len(c.RouteMetrics) > 0 {
+		metricStrings := make([]string, 0, len(c.RouteMetrics)+1)
+		if c.Metric > 0 {
+			metricStrings = append(metricStrings, strconv.Itoa(int(c.Metric)))
+		} else {
+			metricStrings = append(metricStrings, l18n.Sprintf("automatic"))
+		}
+		for _, m := range c.RouteMetrics {
+			metricStrings = append(metricStrings, l18n.Sprintf("%s +%d", m.Destination.String(), m.Metric))
+		}
+		iv.metric.show(strings.Join(metricStrings, l18n.EnumerationSeparator()))
+	} else {
+		iv.metric.hide()
+	}
+
+	if len(c.AdapterGUID) > 0 {
+		iv.adapterGUID.show(c.AdapterGUID)
+	} else {
+		iv.adapterGUID.hide()
+	}
+
+	switch c.NetworkCategory {
+	case conf.NetworkCategoryPublic:
+		iv.networkCategory.show(l18n.Sprintf("public"))
+	case conf.NetworkCategoryPrivate:
+		iv.networkCategory.show(l18n.Sprintf("private"))
+	default:
+		iv.networkCategory.hide()
+	}
+
 	if len(c.Addresses) > 0 {
 		addrStrings := make([]string, len(c.Addresses))
 		for i, address := range c.Addresses {
@@ -406,7 +466,18 @@ func (iv *interfaceView) apply(c *conf.Interface) {
 	if len(c.DNS)+len(c.DNSSearch) > 0 {
 		addrStrings := make([]string, 0, len(c.DNS)+len(c.DNSSearch))
 		for _, address := range c.DNS {
-			addrStrings = append(addrStrings, address.String())
+			addrString := address.String()
+			for _, encrypted := range c.EncryptedDNS {
+				if encrypted.Server != address {
+					continue
+				}
+				if len(encrypted.Template) > 0 {
+					addrString = l18n.Sprintf("%s (DNS over HTTPS)", addrString)
+				} else {
+					addrString = l18n.Sprintf("%s (DNS over TLS)", addrString)
+				}
+			}
+			addrStrings = append(addrStrings, addrString)
 		}
 		addrStrings = append(addrStrings, c.DNSSearch...)
 		iv.dns.show(strings.Join(addrStrings[:], l18n.EnumerationSeparator()))
@@ -414,6 +485,45 @@ func (iv *interfaceView) apply(c *conf.Interface) {
 		iv.dns.hide()
 	}
 
+	if len(c.SplitDNS) > 0 {
+		iv.splitDNS.show(strings.Join(c.SplitDNS, l18n.EnumerationSeparator()))
+	} else {
+		iv.splitDNS.hide()
+	}
+
+	if c.BlockEncryptedDNS {
+		iv.encryptedDNS.show(l18n.Sprintf("blocked"))
+	} else {
+		iv.encryptedDNS.hide()
+	}
+
+	if len(c.Hosts) > 0 {
+		hostsStrings := make([]string, len(c.Hosts))
+		for i, entry := range c.Hosts {
+			hostsStrings[i] = entry.String()
+		}
+		iv.hosts.show(strings.Join(hostsStrings, l18n.EnumerationSeparator()))
+	} else {
+		iv.hosts.hide()
+	}
+
+	if !c.Proxy.IsEmpty() {
+		var proxyStrings []string
+		if len(c.Proxy.Server) > 0 {
+			if len(c.Proxy.Bypass) > 0 {
+				proxyStrings = append(proxyStrings, l18n.Sprintf("%s, except %s", c.Proxy.Server, strings.Join(c.Proxy.Bypass, l18n.EnumerationSeparator())))
+			} else {
+				proxyStrings = append(proxyStrings, c.Proxy.Server)
+			}
+		}
+		if len(c.Proxy.AutoConfig) > 0 {
+			proxyStrings = append(proxyStrings, l18n.Sprintf("auto-config from %s", c.Proxy.AutoConfig))
+		}
+		iv.proxy.show(strings.Join(proxyStrings, l18n.EnumerationSeparator()))
+	} else {
+		iv.proxy.hide()
+	}
+
 	var scriptsInUse []string
 	if len(c.PreUp) > 0 {
 		scriptsInUse = append(scriptsInUse, l18n.Sprintf("pre-up"))
@@ -442,6 +552,51 @@ func (iv *interfaceView) apply(c *conf.Interface) {
 	} else {
 		iv.table.hide()
 	}
+
+	if len(c.Routes) > 0 {
+		routeStrings := make([]string, len(c.Routes))
+		for i, route := range c.Routes {
+			routeStrings[i] = route.String()
+		}
+		iv.routes.show(strings.Join(routeStrings, l18n.EnumerationSeparator()))
+	} else {
+		iv.routes.hide()
+	}
+
+	if c.Inbound == conf.InboundBlock {
+		if len(c.AllowInbound) > 0 {
+			ruleStrings := make([]string, len(c.AllowInbound))
+			for i, rule := range c.AllowInbound {
+				ruleStrings[i] = rule.String()
+			}
+			iv.inbound.show(l18n.Sprintf("blocked, except %s", strings.Join(ruleStrings, l18n.EnumerationSeparator())))
+		} else {
+			iv.inbound.show(l18n.Sprintf("blocked"))
+		}
+	} else {
+		iv.inbound.hide()
+	}
+
+	switch c.VirtualMachines {
+	case conf.VirtualMachinesAllow:
+		iv.vms.show(l18n.Sprintf("allowed"))
+	case conf.VirtualMachinesTunnel:
+		iv.vms.show(l18n.Sprintf("through tunnel only"))
+	default:
+		iv.vms.hide()
+	}
+
+	if !c.PresharedKeyProvider.IsEmpty() {
+		iv.pskProvider.show(c.PresharedKeyProvider.String())
+	} else {
+		iv.pskProvider.hide()
+	}
+
+	if c.SaveConfig {
+		iv.saveConfig.show(l18n.Sprintf("enabled"))
+	} else {
+		iv.saveConfig.hide()
+	}
 }
 
 func (pv *peerView) widgetsLines() []widgetsLine {
@@ -477,12 +632,22 @@ func (pv *peerView) apply(c *conf.Peer) {
 		pv.endpoint.hide()
 	}
 
-	if c.PersistentKeepalive > 0 {
+	if c.PersistentKeepaliveAuto && c.PersistentKeepalive > 0 {
+		pv.persistentKeepalive.show(l18n.Sprintf("%d (auto)", c.PersistentKeepalive))
+	} else if c.PersistentKeepaliveAuto {
+		pv.persistentKeepalive.show(l18n.Sprintf("auto"))
+	} else if c.PersistentKeepalive > 0 {
 		pv.persistentKeepalive.show(strconv.Itoa(int(c.PersistentKeepalive)))
 	} else {
 		pv.persistentKeepalive.hide()
 	}
 
+	if len(c.FailoverGroup) > 0 {
+		pv.failoverGroup.show(c.FailoverGroup)
+	} else {
+		pv.failoverGroup.hide()
+	}
+
 	if !c.LastHandshakeTime.IsEmpty() {
 		pv.latestHandshake.show(c.LastHandshakeTime.String())
 	} else {
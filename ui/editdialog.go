@@ -195,7 +195,7 @@ func (dlg *EditDialog) onBlockUntunneledTrafficCBCheckedChanged() {
 	)
 
 	block := dlg.blockUntunneledTrafficCB.Checked()
-	cfg, err := conf.FromWgQuick(dlg.syntaxEdit.Text(), "temporary")
+	cfg, err := conf.FromWgQuickTolerant(dlg.syntaxEdit.Text(), "temporary")
 	var newAllowedIPs []netip.Prefix
 
 	if err != nil {
@@ -334,7 +334,7 @@ func (dlg *EditDialog) onSaveButtonClicked() {
 		}
 	}
 
-	cfg, err := conf.FromWgQuick(dlg.syntaxEdit.Text(), newName)
+	cfg, err := conf.FromWgQuickTolerant(dlg.syntaxEdit.Text(), newName)
 	if err != nil {
 		showErrorCustom(dlg, l18n.Sprintf("Unable to create new configuration"), err.Error())
 		return
@@ -0,0 +1,192 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2026 WireGuard LLC. All Rights Reserved.
+ */
+
+package proxy
+
+import (
+	"errors"
+	"runtime"
+	"strings"
+	"sync"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+
+	"golang.zx2c4.com/wireguard/windows/conf"
+)
+
+// Where the settings of the user from before the first tunnel's proxy was applied are kept, so that they survive
+// the UI process exiting uncleanly, and are restored by the next one.
+const backupKeyPath = `Software\WireGuard\ProxyBackup`
+
+type settings struct {
+	flags      uint32
+	server     string
+	bypass     string
+	autoConfig string
+}
+
+var lock sync.Mutex
+
+func newOptionList(options []_INTERNET_PER_CONN_OPTION) *_INTERNET_PER_CONN_OPTION_LIST {
+	list := &_INTERNET_PER_CONN_OPTION_LIST{
+		optionCount: uint32(len(options)),
+		options:     &options[0],
+	}
+	list.size = uint32(unsafe.Sizeof(*list))
+	return list
+}
+
+// query returns the settings of the user's LAN connection, which are the ones in effect, unless a dial-up or VPN
+// connection of Windows itself is established.
+func query() (*settings, error) {
+	options := []_INTERNET_PER_CONN_OPTION{
+		{option: _INTERNET_PER_CONN_FLAGS},
+		{option: _INTERNET_PER_CONN_PROXY_SERVER},
+		{option: _INTERNET_PER_CONN_PROXY_BYPASS},
+		{option: _INTERNET_PER_CONN_AUTOCONFIG_URL},
+	}
+	list := newOptionList(options)
+	size := list.size
+	err := internetQueryOption(0, _INTERNET_OPTION_PER_CONNECTION_OPTION, unsafe.Pointer(list), &size)
+	if err != nil {
+		return nil, err
+	}
+	s := &settings{flags: options[0].uint32()}
+	for i, value := range []*string{&s.server, &s.bypass, &s.autoConfig} {
+		if str := options[i+1].string(); str != nil {
+			*value = windows.UTF16PtrToString(str)
+			globalFree(unsafe.Pointer(str))
+		}
+	}
+	return s, nil
+}
+
+func optionalUTF16PtrFromString(s string) (*uint16, error) {
+	if len(s) == 0 {
+		return nil, nil
+	}
+	return windows.UTF16PtrFromString(s)
+}
+
+// set changes the settings of the user's LAN connection, and notifies running applications of the change.
+func set(s *settings) error {
+	var strs [3]*uint16
+	var err error
+	for i, value := range []string{s.server, s.bypass, s.autoConfig} {
+		strs[i], err = optionalUTF16PtrFromString(value)
+		if err != nil {
+			return err
+		}
+	}
+	options := []_INTERNET_PER_CONN_OPTION{
+		{option: _INTERNET_PER_CONN_FLAGS},
+		{option: _INTERNET_PER_CONN_PROXY_SERVER},
+		{option: _INTERNET_PER_CONN_PROXY_BYPASS},
+		{option: _INTERNET_PER_CONN_AUTOCONFIG_URL},
+	}
+	options[0].setUint32(s.flags)
+	for i := range strs {
+		options[i+1].setString(strs[i])
+	}
+	list := newOptionList(options)
+	err = internetSetOption(0, _INTERNET_OPTION_PER_CONNECTION_OPTION, unsafe.Pointer(list), list.size)
+	runtime.KeepAlive(strs)
+	if err != nil {
+		return err
+	}
+	internetSetOption(0, _INTERNET_OPTION_SETTINGS_CHANGED, nil, 0)
+	internetSetOption(0, _INTERNET_OPTION_REFRESH, nil, 0)
+	return nil
+}
+
+func backup() error {
+	key, existing, err := registry.CreateKey(registry.CURRENT_USER, backupKeyPath, registry.QUERY_VALUE|registry.SET_VALUE)
+	if err != nil {
+		return err
+	}
+	defer key.Close()
+	if existing {
+		return nil
+	}
+	original, err := query()
+	if err == nil {
+		err = key.SetDWordValue("Flags", original.flags)
+	}
+	if err == nil {
+		err = key.SetStringValue("Server", original.server)
+	}
+	if err == nil {
+		err = key.SetStringValue("Bypass", original.bypass)
+	}
+	if err == nil {
+		err = key.SetStringValue("AutoConfigURL", original.autoConfig)
+	}
+	if err != nil {
+		key.Close()
+		registry.DeleteKey(registry.CURRENT_USER, backupKeyPath)
+		return err
+	}
+	return nil
+}
+
+// Apply makes the given proxy settings those of the user, having first backed up the user's own settings, unless
+// already backed up. Empty settings restore the user's own settings instead.
+func Apply(proxy *conf.ProxySettings) error {
+	if proxy.IsEmpty() {
+		return Restore()
+	}
+	lock.Lock()
+	defer lock.Unlock()
+
+	err := backup()
+	if err != nil {
+		return err
+	}
+	s := &settings{
+		flags:      _PROXY_TYPE_DIRECT,
+		server:     proxy.Server,
+		bypass:     strings.Join(proxy.Bypass, ";"),
+		autoConfig: proxy.AutoConfig,
+	}
+	if len(s.server) > 0 {
+		s.flags |= _PROXY_TYPE_PROXY
+	}
+	if len(s.autoConfig) > 0 {
+		s.flags |= _PROXY_TYPE_AUTO_PROXY_URL
+	}
+	return set(s)
+}
+
+// Restore restores the user's own proxy settings, if they have been backed up by Apply.
+func Restore() error {
+	lock.Lock()
+	defer lock.Unlock()
+
+	key, err := registry.OpenKey(registry.CURRENT_USER, backupKeyPath, registry.QUERY_VALUE)
+	if errors.Is(err, windows.ERROR_FILE_NOT_FOUND) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	var original settings
+	flags, _, err := key.GetIntegerValue("Flags")
+	if err == nil {
+		original.flags = uint32(flags)
+	} else {
+		original.flags = _PROXY_TYPE_DIRECT | _PROXY_TYPE_AUTO_DETECT
+	}
+	original.server, _, _ = key.GetStringValue("Server")
+	original.bypass, _, _ = key.GetStringValue("Bypass")
+	original.autoConfig, _, _ = key.GetStringValue("AutoConfigURL")
+	key.Close()
+
+	err = set(&original)
+	if err != nil {
+		return err
+	}
+	return registry.DeleteKey(registry.CURRENT_USER, backupKeyPath)
+}
@@ -0,0 +1,59 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2026 WireGuard LLC. All Rights Reserved.
+ */
+
+package proxy
+
+import (
+	"unsafe"
+)
+
+const (
+	_INTERNET_OPTION_REFRESH               = 37
+	_INTERNET_OPTION_SETTINGS_CHANGED      = 39
+	_INTERNET_OPTION_PER_CONNECTION_OPTION = 75
+
+	_INTERNET_PER_CONN_FLAGS          = 1
+	_INTERNET_PER_CONN_PROXY_SERVER   = 2
+	_INTERNET_PER_CONN_PROXY_BYPASS   = 3
+	_INTERNET_PER_CONN_AUTOCONFIG_URL = 4
+
+	_PROXY_TYPE_DIRECT         = 0x00000001
+	_PROXY_TYPE_PROXY          = 0x00000002
+	_PROXY_TYPE_AUTO_PROXY_URL = 0x00000004
+	_PROXY_TYPE_AUTO_DETECT    = 0x00000008
+)
+
+type _INTERNET_PER_CONN_OPTION struct {
+	option uint32
+	value  uint64 // Union of a DWORD, an LPWSTR, and a FILETIME, which, like this, is only 4-byte aligned on 32-bit.
+}
+
+func (o *_INTERNET_PER_CONN_OPTION) uint32() uint32 {
+	return *(*uint32)(unsafe.Pointer(&o.value))
+}
+
+func (o *_INTERNET_PER_CONN_OPTION) setUint32(v uint32) {
+	*(*uint32)(unsafe.Pointer(&o.value)) = v
+}
+
+func (o *_INTERNET_PER_CONN_OPTION) string() *uint16 {
+	return *(**uint16)(unsafe.Pointer(&o.value))
+}
+
+func (o *_INTERNET_PER_CONN_OPTION) setString(v *uint16) {
+	*(**uint16)(unsafe.Pointer(&o.value)) = v
+}
+
+type _INTERNET_PER_CONN_OPTION_LIST struct {
+	size        uint32
+	connection  *uint16
+	optionCount uint32
+	optionError uint32
+	options     *_INTERNET_PER_CONN_OPTION
+}
+
+//sys	internetSetOption(internet uintptr, option uint32, buffer unsafe.Pointer, bufferLen uint32) (err error) = wininet.InternetSetOptionW
+//sys	internetQueryOption(internet uintptr, option uint32, buffer unsafe.Pointer, bufferLen *uint32) (err error) = wininet.InternetQueryOptionW
+//sys	globalFree(mem unsafe.Pointer) = kernel32.GlobalFree
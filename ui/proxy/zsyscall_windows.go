@@ -0,0 +1,68 @@
+// Code generated by 'go generate'; DO NOT EDIT.
+
+package proxy
+
+import (
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var _ unsafe.Pointer
+
+// Do the interface allocations only once for common
+// Errno values.
+const (
+	errnoERROR_IO_PENDING = 997
+)
+
+var (
+	errERROR_IO_PENDING error = syscall.Errno(errnoERROR_IO_PENDING)
+	errERROR_EINVAL     error = syscall.EINVAL
+)
+
+// errnoErr returns common boxed Errno values, to prevent
+// allocations at runtime.
+func errnoErr(e syscall.Errno) error {
+	switch e {
+	case 0:
+		return errERROR_EINVAL
+	case errnoERROR_IO_PENDING:
+		return errERROR_IO_PENDING
+	}
+	// TODO: add more here, after collecting data on the common
+	// error values see on Windows. (perhaps when running
+	// all.bat?)
+	return e
+}
+
+var (
+	modkernel32 = windows.NewLazySystemDLL("kernel32.dll")
+	modwininet  = windows.NewLazySystemDLL("wininet.dll")
+
+	procGlobalFree           = modkernel32.NewProc("GlobalFree")
+	procInternetQueryOptionW = modwininet.NewProc("InternetQueryOptionW")
+	procInternetSetOptionW   = modwininet.NewProc("InternetSetOptionW")
+)
+
+func globalFree(mem unsafe.Pointer) {
+	syscall.SyscallN(procGlobalFree.Addr(), uintptr(mem))
+	return
+}
+
+func internetQueryOption(internet uintptr, option uint32, buffer unsafe.Pointer, bufferLen *uint32) (err error) {
+	r1, _, e1 := syscall.SyscallN(procInternetQueryOptionW.Addr(), uintptr(internet), uintptr(option), uintptr(buffer), uintptr(unsafe.Pointer(bufferLen)))
+	if r1 == 0 {
+		err = errnoErr(e1)
+	}
+	return
+}
+
+func internetSetOption(internet uintptr, option uint32, buffer unsafe.Pointer, bufferLen uint32) (err error) {
+	r1, _, e1 := syscall.SyscallN(procInternetSetOptionW.Addr(), uintptr(internet), uintptr(option), uintptr(buffer), uintptr(bufferLen))
+	if r1 == 0 {
+		err = errnoErr(e1)
+	}
+	return
+}
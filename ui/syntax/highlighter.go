@@ -28,6 +28,7 @@ const (
 	highlightDelimiter
 	highlightTable
 	highlightCmd
+	highlightKeyword
 	highlightError
 )
 
@@ -257,10 +258,65 @@ func (s stringSpan) isValidMTU() bool {
 	return s.isValidUint(false, 576, 65535)
 }
 
+func (s stringSpan) isValidMetric() bool {
+	return s.isValidUint(false, 1, 9999)
+}
+
+func (s stringSpan) isValidGUID() bool {
+	if s.len == 38 {
+		if *s.at(0) != '{' || *s.at(37) != '}' {
+			return false
+		}
+		s = stringSpan{s.at(1), 36}
+	}
+	if s.len != 36 {
+		return false
+	}
+	for i := 0; i < s.len; i++ {
+		switch i {
+		case 8, 13, 18, 23:
+			if *s.at(i) != '-' {
+				return false
+			}
+		default:
+			if !isHexadecimal(*s.at(i)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
 func (s stringSpan) isValidTable() bool {
 	return s.isSame("off") || s.isSame("auto") || s.isSame("main") || s.isValidUint(false, 0, (1<<32)-1)
 }
 
+func (s stringSpan) isValidBool() bool {
+	return s.isCaselessSame("true") || s.isCaselessSame("yes") || s.isCaselessSame("on") ||
+		s.isCaselessSame("false") || s.isCaselessSame("no") || s.isCaselessSame("off")
+}
+
+func (s stringSpan) isValidInboundPolicy() bool {
+	return s.isCaselessSame("allow") || s.isCaselessSame("block")
+}
+
+func (s stringSpan) isValidNetworkCategory() bool {
+	return s.isCaselessSame("unchanged") || s.isCaselessSame("public") || s.isCaselessSame("private")
+}
+
+func (s stringSpan) isValidVirtualMachinePolicy() bool {
+	return s.isCaselessSame("block") || s.isCaselessSame("allow") || s.isCaselessSame("tunnel")
+}
+
+func (s stringSpan) isValidPortRange() bool {
+	for i := 0; i < s.len; i++ {
+		if *s.at(i) == '-' {
+			return stringSpan{s.s, i}.isValidUint(false, 1, 65535) && stringSpan{s.at(i + 1), s.len - i - 1}.isValidUint(false, 1, 65535)
+		}
+	}
+	return s.isValidUint(false, 1, 65535)
+}
+
 func (s stringSpan) isValidPersistentKeepAlive() bool {
 	if s.isSame("off") {
 		return true
@@ -268,6 +324,28 @@ func (s stringSpan) isValidPersistentKeepAlive() bool {
 	return s.isValidUint(false, 0, 65535)
 }
 
+func (s stringSpan) isValidFailoverGroup() bool {
+	for i := 0; i < s.len; i++ {
+		if *s.at(i) == ' ' || *s.at(i) == '\t' {
+			return false
+		}
+	}
+	return s.len != 0
+}
+
+func (s stringSpan) isValidKeyFile() bool {
+	if s.isSame(".") || s.isSame("..") {
+		return false
+	}
+	for i := 0; i < s.len; i++ {
+		switch *s.at(i) {
+		case '\\', '/', ':', '*', '?', '"', '<', '>', '|':
+			return false
+		}
+	}
+	return s.len != 0
+}
+
 // It's probably not worthwhile to try to validate a bash expression. So instead we just demand non-zero length.
 func (s stringSpan) isValidPrePostUpDown() bool {
 	return s.len != 0
@@ -361,21 +439,42 @@ type field int32
 const (
 	fieldInterfaceSection field = iota
 	fieldPrivateKey
+	fieldPrivateKeyFile
 	fieldListenPort
 	fieldAddress
 	fieldDNS
+	fieldSplitDNS
+	fieldHosts
+	fieldProxy
+	fieldProxyBypass
+	fieldProxyAutoConfig
 	fieldMTU
+	fieldMetric
+	fieldAdapterGUID
+	fieldNetworkCategory
+	fieldRoute
+	fieldRouteMetric
 	fieldTable
 	fieldPreUp
 	fieldPostUp
 	fieldPreDown
 	fieldPostDown
+	fieldBlockEncryptedDNS
+	fieldEncryptedDNS
+	fieldInbound
+	fieldAllowInbound
+	fieldVirtualMachines
+	fieldPresharedKeyProvider
+	fieldSaveConfig
+	fieldUnsupported
 	fieldPeerSection
 	fieldPublicKey
 	fieldPresharedKey
+	fieldPresharedKeyFile
 	fieldAllowedIPs
 	fieldEndpoint
 	fieldPersistentKeepalive
+	fieldFailoverGroup
 	fieldInvalid
 )
 
@@ -393,26 +492,52 @@ func (s stringSpan) field() field {
 	switch {
 	case s.isCaselessSame("PrivateKey"):
 		return fieldPrivateKey
+	case s.isCaselessSame("PrivateKeyFile"):
+		return fieldPrivateKeyFile
 	case s.isCaselessSame("ListenPort"):
 		return fieldListenPort
 	case s.isCaselessSame("Address"):
 		return fieldAddress
 	case s.isCaselessSame("DNS"):
 		return fieldDNS
+	case s.isCaselessSame("SplitDNS"):
+		return fieldSplitDNS
+	case s.isCaselessSame("Hosts"):
+		return fieldHosts
+	case s.isCaselessSame("Proxy"):
+		return fieldProxy
+	case s.isCaselessSame("ProxyBypass"):
+		return fieldProxyBypass
+	case s.isCaselessSame("ProxyAutoConfig"):
+		return fieldProxyAutoConfig
 	case s.isCaselessSame("MTU"):
 		return fieldMTU
+	case s.isCaselessSame("Metric"):
+		return fieldMetric
+	case s.isCaselessSame("AdapterGUID"):
+		return fieldAdapterGUID
+	case s.isCaselessSame("NetworkCategory"):
+		return fieldNetworkCategory
+	case s.isCaselessSame("Route"):
+		return fieldRoute
+	case s.isCaselessSame("RouteMetric"):
+		return fieldRouteMetric
 	case s.isCaselessSame("Table"):
 		return fieldTable
 	case s.isCaselessSame("PublicKey"):
 		return fieldPublicKey
 	case s.isCaselessSame("PresharedKey"):
 		return fieldPresharedKey
+	case s.isCaselessSame("PresharedKeyFile"):
+		return fieldPresharedKeyFile
 	case s.isCaselessSame("AllowedIPs"):
 		return fieldAllowedIPs
 	case s.isCaselessSame("Endpoint"):
 		return fieldEndpoint
 	case s.isCaselessSame("PersistentKeepalive"):
 		return fieldPersistentKeepalive
+	case s.isCaselessSame("FailoverGroup"):
+		return fieldFailoverGroup
 	case s.isCaselessSame("PreUp"):
 		return fieldPreUp
 	case s.isCaselessSame("PostUp"):
@@ -421,6 +546,22 @@ func (s stringSpan) field() field {
 		return fieldPreDown
 	case s.isCaselessSame("PostDown"):
 		return fieldPostDown
+	case s.isCaselessSame("BlockEncryptedDNS"):
+		return fieldBlockEncryptedDNS
+	case s.isCaselessSame("EncryptedDNS"):
+		return fieldEncryptedDNS
+	case s.isCaselessSame("Inbound"):
+		return fieldInbound
+	case s.isCaselessSame("AllowInbound"):
+		return fieldAllowInbound
+	case s.isCaselessSame("VirtualMachines"):
+		return fieldVirtualMachines
+	case s.isCaselessSame("PresharedKeyProvider"):
+		return fieldPresharedKeyProvider
+	case s.isCaselessSame("SaveConfig"):
+		return fieldSaveConfig
+	case s.isCaselessSame("FwMark"), s.isCaselessSame("IncludedApplications"), s.isCaselessSame("ExcludedApplications"):
+		return fieldUnsupported
 	}
 	return fieldInvalid
 }
@@ -454,7 +595,15 @@ func (hsa *highlightSpanArray) highlightMultivalueValue(parent, s stringSpan, se
 		} else {
 			hsa.append(parent.s, s, highlightError)
 		}
-	case fieldAddress, fieldAllowedIPs:
+	case fieldProxyBypass:
+		hsa.append(parent.s, s, highlightHost)
+	case fieldSplitDNS:
+		if s.isValidHostname() {
+			hsa.append(parent.s, s, highlightHost)
+		} else {
+			hsa.append(parent.s, s, highlightError)
+		}
+	case fieldAddress, fieldAllowedIPs, fieldAllowInbound:
 		if !s.isValidNetwork() {
 			hsa.append(parent.s, s, highlightError)
 			break
@@ -506,6 +655,194 @@ func (hsa *highlightSpanArray) highlightMultivalue(parent, s stringSpan, section
 	}
 }
 
+func (hsa *highlightSpanArray) highlightPresharedKeyProvider(parent, s stringSpan) {
+	i := 0
+	for i < s.len && *s.at(i) != ' ' && *s.at(i) != '\t' {
+		i++
+	}
+	kind := stringSpan{s.s, i}
+	for i < s.len && (*s.at(i) == ' ' || *s.at(i) == '\t') {
+		i++
+	}
+	target := stringSpan{s.at(i), s.len - i}
+	if target.len == 0 || !(kind.isCaselessSame("pipe") || kind.isCaselessSame("command")) {
+		hsa.append(parent.s, s, highlightError)
+		return
+	}
+	hsa.append(parent.s, kind, highlightKeyword)
+	hsa.append(parent.s, target, highlightCmd)
+}
+
+func (hsa *highlightSpanArray) highlightRoute(parent, s stringSpan) {
+	isSpace := func(i int) bool {
+		return *s.at(i) == ' ' || *s.at(i) == '\t'
+	}
+	words := 0
+	var keyword stringSpan
+	for i := 0; i < s.len; {
+		for i < s.len && isSpace(i) {
+			i++
+		}
+		start := i
+		for i < s.len && !isSpace(i) {
+			i++
+		}
+		word := stringSpan{s.at(start), i - start}
+		if word.len == 0 {
+			break
+		}
+		switch {
+		case words == 0:
+			hsa.highlightMultivalueValue(parent, word, fieldAllowedIPs)
+		case words%2 == 1:
+			keyword = word
+			hsa.append(parent.s, word, validateHighlight(word.isCaselessSame("via") || word.isCaselessSame("metric"), highlightKeyword))
+		case keyword.isCaselessSame("via"):
+			hsa.append(parent.s, word, validateHighlight(word.isValidIPv4() || word.isValidIPv6(), highlightIP))
+		default:
+			hsa.append(parent.s, word, validateHighlight(word.isValidUint(false, 0, 9999), highlightMTU))
+		}
+		words++
+	}
+	if words%2 == 0 {
+		hsa.append(parent.s, s, highlightError)
+	}
+}
+
+func (hsa *highlightSpanArray) highlightRouteMetric(parent, s stringSpan) {
+	isSpace := func(i int) bool {
+		return *s.at(i) == ' ' || *s.at(i) == '\t'
+	}
+	words := 0
+	for i := 0; i < s.len; {
+		for i < s.len && isSpace(i) {
+			i++
+		}
+		start := i
+		for i < s.len && !isSpace(i) {
+			i++
+		}
+		word := stringSpan{s.at(start), i - start}
+		if word.len == 0 {
+			break
+		}
+		switch words {
+		case 0:
+			hsa.highlightMultivalueValue(parent, word, fieldAllowedIPs)
+		case 1:
+			hsa.append(parent.s, word, validateHighlight(word.isValidUint(false, 0, 9999), highlightMTU))
+		default:
+			hsa.append(parent.s, word, highlightError)
+		}
+		words++
+	}
+	if words < 2 {
+		hsa.append(parent.s, s, highlightError)
+	}
+}
+
+func (hsa *highlightSpanArray) highlightHostsEntry(parent, s stringSpan) {
+	isSpace := func(i int) bool {
+		return *s.at(i) == ' ' || *s.at(i) == '\t'
+	}
+	names := 0
+	for i := 0; i < s.len; {
+		for i < s.len && isSpace(i) {
+			i++
+		}
+		start := i
+		for i < s.len && !isSpace(i) {
+			i++
+		}
+		word := stringSpan{s.at(start), i - start}
+		if word.len == 0 {
+			break
+		}
+		if start == 0 {
+			hsa.append(parent.s, word, validateHighlight(word.isValidIPv4() || word.isValidIPv6(), highlightIP))
+		} else {
+			hsa.append(parent.s, word, validateHighlight(word.isValidHostname(), highlightHost))
+			names++
+		}
+	}
+	if names == 0 {
+		hsa.append(parent.s, s, highlightError)
+	}
+}
+
+func (hsa *highlightSpanArray) highlightEncryptedDNSServer(parent, s stringSpan) {
+	isSpace := func(i int) bool {
+		return *s.at(i) == ' ' || *s.at(i) == '\t'
+	}
+	i := 0
+	for i < s.len && !isSpace(i) {
+		i++
+	}
+	server := stringSpan{s.s, i}
+	hsa.append(parent.s, server, validateHighlight(server.isValidIPv4() || server.isValidIPv6(), highlightIP))
+	for i < s.len && isSpace(i) {
+		i++
+	}
+	start := i
+	for i < s.len && !isSpace(i) {
+		i++
+	}
+	target := stringSpan{s.at(start), i - start}
+	if i < s.len || target.len == 0 {
+		hsa.append(parent.s, stringSpan{s.at(start), s.len - start}, highlightError)
+		return
+	}
+	scheme := 0
+	if target.len > 8 && (stringSpan{target.s, 8}).isCaselessSame("https://") {
+		scheme = 8
+	} else if target.len > 6 && (stringSpan{target.s, 6}).isCaselessSame("tls://") {
+		scheme = 6
+	}
+	if scheme == 0 {
+		hsa.append(parent.s, target, highlightError)
+		return
+	}
+	hsa.append(parent.s, stringSpan{target.s, scheme}, highlightKeyword)
+	rest := stringSpan{target.at(scheme), target.len - scheme}
+	hsa.append(parent.s, rest, validateHighlight(scheme == 8 || rest.isValidHostname(), highlightHost))
+}
+
+func (hsa *highlightSpanArray) highlightInboundRule(parent, s stringSpan) {
+	isSpace := func(i int) bool {
+		return *s.at(i) == ' ' || *s.at(i) == '\t'
+	}
+	i := 0
+	for i < s.len && *s.at(i) != '/' && !isSpace(i) {
+		i++
+	}
+	protocol := stringSpan{s.s, i}
+	hasPorts := protocol.isCaselessSame("tcp") || protocol.isCaselessSame("udp")
+	hsa.append(parent.s, protocol, validateHighlight(hasPorts || protocol.isCaselessSame("icmp") || protocol.isCaselessSame("any"), highlightKeyword))
+	if i < s.len && *s.at(i) == '/' {
+		hsa.append(parent.s, stringSpan{s.at(i), 1}, highlightDelimiter)
+		i++
+		start := i
+		for i < s.len && !isSpace(i) {
+			i++
+		}
+		ports := stringSpan{s.at(start), i - start}
+		hsa.append(parent.s, ports, validateHighlight(hasPorts && ports.isValidPortRange(), highlightPort))
+	}
+	for i < s.len && isSpace(i) {
+		i++
+	}
+	if i == s.len {
+		return
+	}
+	from := stringSpan{s.at(i), 4}
+	if i+5 >= s.len || !from.isCaselessSame("from") || !isSpace(i+4) {
+		hsa.append(parent.s, stringSpan{s.at(i), s.len - i}, highlightError)
+		return
+	}
+	hsa.append(parent.s, from, highlightKeyword)
+	hsa.highlightMultivalue(parent, stringSpan{s.at(i + 5), s.len - i - 5}, fieldAllowInbound)
+}
+
 func (hsa *highlightSpanArray) highlightValue(parent, s stringSpan, section field) {
 	switch section {
 	case fieldPrivateKey:
@@ -514,16 +851,57 @@ func (hsa *highlightSpanArray) highlightValue(parent, s stringSpan, section fiel
 		hsa.append(parent.s, s, validateHighlight(s.isValidKey(), highlightPublicKey))
 	case fieldPresharedKey:
 		hsa.append(parent.s, s, validateHighlight(s.isValidKey(), highlightPresharedKey))
+	case fieldPrivateKeyFile, fieldPresharedKeyFile:
+		hsa.append(parent.s, s, validateHighlight(s.isValidKeyFile(), highlightKeyword))
 	case fieldMTU:
-		hsa.append(parent.s, s, validateHighlight(s.isValidMTU(), highlightMTU))
+		if s.isCaselessSame("auto-probe") {
+			hsa.append(parent.s, s, highlightKeyword)
+		} else {
+			hsa.append(parent.s, s, validateHighlight(s.isValidMTU(), highlightMTU))
+		}
+	case fieldMetric:
+		hsa.append(parent.s, s, validateHighlight(s.isValidMetric(), highlightMTU))
+	case fieldAdapterGUID:
+		hsa.append(parent.s, s, validateHighlight(s.isValidGUID(), highlightKeyword))
+	case fieldNetworkCategory:
+		hsa.append(parent.s, s, validateHighlight(s.isValidNetworkCategory(), highlightKeyword))
+	case fieldRoute:
+		hsa.highlightRoute(parent, s)
+	case fieldRouteMetric:
+		hsa.highlightRouteMetric(parent, s)
 	case fieldTable:
 		hsa.append(parent.s, s, validateHighlight(s.isValidTable(), highlightTable))
 	case fieldPreUp, fieldPostUp, fieldPreDown, fieldPostDown:
 		hsa.append(parent.s, s, validateHighlight(s.isValidPrePostUpDown(), highlightCmd))
+	case fieldBlockEncryptedDNS, fieldSaveConfig:
+		hsa.append(parent.s, s, validateHighlight(s.isValidBool(), highlightKeyword))
+	case fieldEncryptedDNS:
+		hsa.highlightEncryptedDNSServer(parent, s)
+	case fieldHosts:
+		hsa.highlightHostsEntry(parent, s)
+	case fieldProxy, fieldProxyAutoConfig:
+		hsa.append(parent.s, s, highlightHost)
+	case fieldInbound:
+		hsa.append(parent.s, s, validateHighlight(s.isValidInboundPolicy(), highlightKeyword))
+	case fieldAllowInbound:
+		hsa.highlightInboundRule(parent, s)
+	case fieldVirtualMachines:
+		hsa.append(parent.s, s, validateHighlight(s.isValidVirtualMachinePolicy(), highlightKeyword))
+	case fieldPresharedKeyProvider:
+		hsa.highlightPresharedKeyProvider(parent, s)
+	case fieldUnsupported:
+		// These are kept but have no effect on Windows, so they look like comments.
+		hsa.append(parent.s, s, highlightComment)
 	case fieldListenPort:
 		hsa.append(parent.s, s, validateHighlight(s.isValidPort(), highlightPort))
 	case fieldPersistentKeepalive:
-		hsa.append(parent.s, s, validateHighlight(s.isValidPersistentKeepAlive(), highlightKeepalive))
+		if s.isCaselessSame("auto") {
+			hsa.append(parent.s, s, highlightKeyword)
+		} else {
+			hsa.append(parent.s, s, validateHighlight(s.isValidPersistentKeepAlive(), highlightKeepalive))
+		}
+	case fieldFailoverGroup:
+		hsa.append(parent.s, s, validateHighlight(s.isValidFailoverGroup(), highlightKeyword))
 	case fieldEndpoint:
 		if !s.isValidEndpoint() {
 			hsa.append(parent.s, s, highlightError)
@@ -539,7 +917,7 @@ func (hsa *highlightSpanArray) highlightValue(parent, s stringSpan, section fiel
 		hsa.append(parent.s, stringSpan{s.s, colon}, highlightHost)
 		hsa.append(parent.s, stringSpan{s.at(colon), 1}, highlightDelimiter)
 		hsa.append(parent.s, stringSpan{s.at(colon + 1), s.len - colon - 1}, highlightPort)
-	case fieldAddress, fieldDNS, fieldAllowedIPs:
+	case fieldAddress, fieldDNS, fieldSplitDNS, fieldProxyBypass, fieldAllowedIPs:
 		hsa.highlightMultivalue(parent, s, section)
 	default:
 		hsa.append(parent.s, s, highlightError)
@@ -105,6 +105,7 @@ var stylemap = map[highlight]spanStyle{
 	highlightComment:      {color: win.RGB(0x53, 0x65, 0x79), effects: win.CFE_ITALIC},
 	highlightDelimiter:    {color: win.RGB(0x00, 0x00, 0x00)},
 	highlightCmd:          {color: win.RGB(0x63, 0x75, 0x89)},
+	highlightKeyword:      {color: win.RGB(0x1C, 0x00, 0xCF)},
 	highlightError:        {color: win.RGB(0xC4, 0x1A, 0x16), effects: win.CFE_UNDERLINE},
 }
 
@@ -10,11 +10,13 @@ import (
 	"strings"
 	"time"
 
+	"golang.org/x/sys/windows"
 	"golang.zx2c4.com/wireguard/windows/conf"
 	"golang.zx2c4.com/wireguard/windows/l18n"
 	"golang.zx2c4.com/wireguard/windows/manager"
 
 	"github.com/lxn/walk"
+	"github.com/lxn/win"
 )
 
 // Status + active CIDRs + separator
@@ -29,6 +31,8 @@ type Tray struct {
 
 	mtw *ManageTunnelsWindow
 
+	captivePortalAction *walk.Action
+
 	tunnelChangedCB  *manager.TunnelChangeCallback
 	tunnelsChangedCB *manager.TunnelsChangeCallback
 
@@ -76,6 +80,7 @@ func (tray *Tray) setup() error {
 		hidden    bool
 		separator bool
 		defawlt   bool
+		store     **walk.Action
 	}{
 		{label: l18n.Sprintf("Status: Unknown")},
 		{label: l18n.Sprintf("Addresses: None"), hidden: true},
@@ -83,6 +88,7 @@ func (tray *Tray) setup() error {
 		{separator: true},
 		{label: l18n.Sprintf("&Manage tunnels…"), handler: tray.onManageTunnels, enabled: true, defawlt: true},
 		{label: l18n.Sprintf("&Import tunnel(s) from file…"), handler: tray.onImport, enabled: true, hidden: !IsAdmin},
+		{label: l18n.Sprintf("Sign in to &captive portal…"), handler: tray.onCaptivePortal, enabled: true, hidden: true, store: &tray.captivePortalAction},
 		{separator: true},
 		{label: l18n.Sprintf("&About WireGuard…"), handler: tray.onAbout, enabled: true},
 		{label: l18n.Sprintf("E&xit"), handler: onQuit, enabled: true, hidden: !IsAdmin},
@@ -101,6 +107,9 @@ func (tray *Tray) setup() error {
 			}
 		}
 
+		if item.store != nil {
+			*item.store = action
+		}
 		tray.ContextMenu().Actions().Add(action)
 	}
 	tray.tunnelChangedCB = manager.IPCClientRegisterTunnelChange(tray.onTunnelChange)
@@ -295,6 +304,7 @@ func (tray *Tray) updateGlobalState(globalState manager.TunnelState) {
 		statusAction.SetImage(stateIcon)
 	}
 	statusAction.SetText(l18n.Sprintf("Status: %s", stateText))
+	tray.captivePortalAction.SetVisible(globalState == manager.TunnelStarted)
 
 	go func() {
 		var addrs []string
@@ -386,6 +396,39 @@ func (tray *Tray) onAbout() {
 	}
 }
 
+func (tray *Tray) onCaptivePortal() {
+	go func() {
+		var opened bool
+		var lastErr error
+		tunnels, err := manager.IPCClientTunnels()
+		if err == nil {
+			for i := range tunnels {
+				state, err := tunnels[i].State()
+				if err != nil || state != manager.TunnelStarted {
+					continue
+				}
+				err = tunnels[i].OpenCaptivePortal()
+				if err != nil {
+					lastErr = err
+					continue
+				}
+				opened = true
+			}
+		} else {
+			lastErr = err
+		}
+		tray.mtw.Synchronize(func() {
+			if !opened {
+				if lastErr != nil {
+					showErrorCustom(tray.mtw, l18n.Sprintf("Failed to enable captive portal mode"), lastErr.Error())
+				}
+				return
+			}
+			win.ShellExecute(tray.mtw.Handle(), nil, windows.StringToUTF16Ptr("http://www.msftconnecttest.com/redirect"), nil, nil, win.SW_SHOWNORMAL)
+		})
+	}()
+}
+
 func (tray *Tray) onImport() {
 	raise(tray.mtw.Handle())
 	tray.mtw.tunnelsPage.onImport()
@@ -233,6 +233,11 @@ func (tp *TunnelsPage) CreateToolbar() error {
 	editAction.Triggered().Attach(tp.onEditTunnel)
 	contextMenu.Actions().Add(editAction)
 	tp.ShortcutActions().Add(editAction)
+	saveRuntimeConfigAction := walk.NewAction()
+	saveRuntimeConfigAction.SetText(l18n.Sprintf("Sa&ve runtime configuration"))
+	saveRuntimeConfigAction.SetVisible(IsAdmin)
+	saveRuntimeConfigAction.Triggered().Attach(tp.onSaveRuntimeConfig)
+	contextMenu.Actions().Add(saveRuntimeConfigAction)
 	deleteAction2 := walk.NewAction()
 	deleteAction2.SetText(l18n.Sprintf("&Remove selected tunnel(s)"))
 	deleteAction2.SetShortcut(walk.Shortcut{0, walk.KeyDelete})
@@ -257,6 +262,7 @@ func (tp *TunnelsPage) CreateToolbar() error {
 		toggleAction.SetEnabled(selected == 1)
 		selectAllAction.SetEnabled(selected < all)
 		editAction.SetEnabled(selected == 1)
+		saveRuntimeConfigAction.SetEnabled(selected == 1)
 	}
 	tp.listView.SelectedIndexesChanged().Attach(setSelectionOrientedOptions)
 	setSelectionOrientedOptions()
@@ -359,13 +365,14 @@ func (tp *TunnelsPage) importFiles(paths []string) {
 		}
 
 		configCount := 0
+		var unsupported []string
 		tp.listView.SetSuspendTunnelsUpdate(true)
 		for _, unparsedConfig := range unparsedConfigs {
 			if existingLowerTunnels[strings.ToLower(unparsedConfig.Name)] {
 				lastErr = errors.New(l18n.Sprintf("Another tunnel already exists with the name ‘%s’", unparsedConfig.Name))
 				continue
 			}
-			config, err := conf.FromWgQuickWithUnknownEncoding(unparsedConfig.Config, unparsedConfig.Name)
+			config, err := conf.FromWgQuickTolerantWithUnknownEncoding(unparsedConfig.Config, unparsedConfig.Name)
 			if err != nil {
 				lastErr = err
 				continue
@@ -376,6 +383,9 @@ func (tp *TunnelsPage) importFiles(paths []string) {
 				continue
 			}
 			configCount++
+			for _, key := range config.Interface.Unsupported {
+				unsupported = append(unsupported, l18n.Sprintf("%s: %s = %s", config.Name, key.Key, key.Value))
+			}
 		}
 		tp.listView.SetSuspendTunnelsUpdate(false)
 
@@ -390,6 +400,9 @@ func (tp *TunnelsPage) importFiles(paths []string) {
 		case m != n:
 			syncedMsgBox(l18n.Sprintf("Imported tunnels"), l18n.Sprintf("Imported %d of %d tunnels", m, n), walk.MsgBoxIconWarning)
 		}
+		if len(unsupported) > 0 {
+			syncedMsgBox(l18n.Sprintf("Unsupported settings"), l18n.Sprintf("These settings have no effect on Windows and are ignored, but kept in the configuration:\n\n%s", strings.Join(unsupported, "\n")), walk.MsgBoxIconWarning)
+		}
 	}()
 }
 
@@ -471,6 +484,22 @@ func (tp *TunnelsPage) onEditTunnel() {
 	}
 }
 
+func (tp *TunnelsPage) onSaveRuntimeConfig() {
+	tunnel := tp.listView.CurrentTunnel()
+	if tunnel == nil {
+		return
+	}
+
+	go func() {
+		err := tunnel.SaveRuntimeConfig()
+		if err != nil {
+			tp.Synchronize(func() {
+				showErrorCustom(tp.Form(), l18n.Sprintf("Unable to save runtime configuration"), err.Error())
+			})
+		}
+	}()
+}
+
 func (tp *TunnelsPage) onAddTunnel() {
 	if config := runEditDialog(tp.Form(), nil); config != nil {
 		// Save new
@@ -15,8 +15,10 @@ import (
 	"github.com/lxn/win"
 	"golang.org/x/sys/windows"
 
+	"golang.zx2c4.com/wireguard/windows/conf"
 	"golang.zx2c4.com/wireguard/windows/l18n"
 	"golang.zx2c4.com/wireguard/windows/manager"
+	"golang.zx2c4.com/wireguard/windows/ui/proxy"
 	"golang.zx2c4.com/wireguard/windows/version"
 )
 
@@ -91,6 +93,16 @@ func RunUI() {
 		}
 	}()
 
+	proxyChangeCB := manager.IPCClientRegisterProxyChange(func(settings *conf.ProxySettings) {
+		proxy.Apply(settings)
+	})
+	go func() {
+		settings, err := manager.IPCClientProxySettings()
+		if err == nil {
+			proxy.Apply(&settings)
+		}
+	}()
+
 	if tray == nil {
 		win.ShowWindow(mtw.Handle(), win.SW_MINIMIZE)
 	}
@@ -101,6 +113,9 @@ func RunUI() {
 	}
 	mtw.Dispose()
 
+	proxyChangeCB.Unregister()
+	proxy.Restore()
+
 	if shouldQuitManagerWhenExiting {
 		_, err := manager.IPCClientQuit(true)
 		if err != nil {